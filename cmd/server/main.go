@@ -3,20 +3,28 @@ package main
 import (
 	"context"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/openfeature/posthog-proxy/internal/audit"
 	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/flagd"
 	"github.com/openfeature/posthog-proxy/internal/handlers"
+	"github.com/openfeature/posthog-proxy/internal/jwtauth"
 	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/openfeature/posthog-proxy/internal/ratelimit"
+	"github.com/openfeature/posthog-proxy/internal/stream"
 	"github.com/openfeature/posthog-proxy/internal/telemetry"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"google.golang.org/grpc"
 )
 
 var (
@@ -60,16 +68,110 @@ func main() {
 	}
 
 	// Initialize PostHog client with insecure mode flag for logging
-	posthogClient := posthog.NewClient(cfg.PostHog, cfg.Proxy.InsecureMode)
+	apiClient := posthog.NewClient(cfg.PostHog, cfg.Proxy.InsecureMode)
+	var posthogClient posthog.ClientInterface = apiClient
+
+	// Fail fast while PostHog is down rather than making every request wait out the retries
+	breaker := apiClient.Breaker()
+	if breaker != nil {
+		err := metrics.ObserveCircuitBreaker(
+			func() int64 { return int64(breaker.State()) },
+			breaker.Rejections,
+		)
+		if err != nil {
+			slog.Warn("Failed to register circuit breaker metrics", "error", err)
+		}
+	}
+
+	// Share one PostHog request between concurrent identical reads, such as a fleet of
+	// pods fetching the manifest at once
+	posthogClient = posthog.NewCoalescingClient(posthogClient, metrics)
+
+	// Keep the flag list in memory so manifest reads don't crawl PostHog every time
+	if cfg.Cache.Enabled {
+		cachedClient := posthog.NewCachedClient(posthogClient, time.Duration(cfg.Cache.RefreshInterval)*time.Second, metrics)
+		cachedClient.Start(ctx)
+		defer cachedClient.Stop()
+		posthogClient = cachedClient
+		slog.Info("Feature flag cache enabled", "refresh_interval_seconds", cfg.Cache.RefreshInterval)
+	}
+
+	var handlerOpts []handlers.Option
+
+	// Poll PostHog for changes and push them to manifest stream and flagd sync subscribers
+	var watcher *stream.Watcher
+	if cfg.Stream.Enabled || cfg.FlagdSync.Enabled {
+		watcher = stream.NewWatcher(posthogClient, cfg.FeatureFlags.TypeCoercion, time.Duration(cfg.Stream.PollInterval)*time.Second, cfg.Stream.HistorySize)
+		watcher.Start(ctx)
+		defer watcher.Stop()
+	}
+	if cfg.Stream.Enabled {
+		handlerOpts = append(handlerOpts, handlers.WithManifestWatcher(watcher))
+		slog.Info("Manifest stream enabled", "poll_interval_seconds", cfg.Stream.PollInterval)
+	}
+
+	// Record every flag change made through the proxy
+	if len(cfg.Audit.Sinks) > 0 {
+		var sinks audit.MultiSink
+		for _, name := range cfg.Audit.Sinks {
+			switch name {
+			case "file":
+				fileSink, err := audit.NewFileSink(cfg.Audit.FilePath)
+				if err != nil {
+					slog.Error("Failed to open audit log", "error", err)
+					os.Exit(1)
+				}
+				sinks = append(sinks, fileSink)
+			case "otlp":
+				sinks = append(sinks, audit.NewLogSink(slog.Default()))
+			}
+		}
+		defer sinks.Close()
+		handlerOpts = append(handlerOpts, handlers.WithAuditSink(sinks))
+		slog.Info("Audit log enabled", "sinks", cfg.Audit.Sinks)
+	}
+
+	// Accept JWTs from the configured OIDC issuer alongside the static tokens
+	var verifier *jwtauth.Verifier
+	if cfg.Proxy.Auth.JWT.Enabled() {
+		verifier, err = jwtauth.NewVerifier(cfg.Proxy.Auth.JWT)
+		if err != nil {
+			slog.Error("Failed to initialize JWT authentication", "error", err)
+			os.Exit(1)
+		}
+		handlerOpts = append(handlerOpts, handlers.WithJWTVerifier(verifier))
+		slog.Info("JWT authentication enabled", "issuer", cfg.Proxy.Auth.JWT.Issuer, "audience", cfg.Proxy.Auth.JWT.Audience)
+	}
+
+	// Throttle callers so none of them can use up the PostHog API rate limit
+	if cfg.RateLimit.Enabled {
+		limiters := make(map[string]*ratelimit.Limiter)
+		if cfg.RateLimit.ReadRate > 0 {
+			limiters["read"] = ratelimit.New(cfg.RateLimit.ReadRate, cfg.RateLimit.ReadBurst)
+		}
+		if cfg.RateLimit.WriteRate > 0 {
+			limiters["write"] = ratelimit.New(cfg.RateLimit.WriteRate, cfg.RateLimit.WriteBurst)
+		}
+		if cfg.RateLimit.GlobalRate > 0 {
+			limiters["global"] = ratelimit.New(cfg.RateLimit.GlobalRate, cfg.RateLimit.GlobalBurst)
+		}
+		handlerOpts = append(handlerOpts, handlers.WithRateLimiters(limiters))
+		slog.Info("Rate limiting enabled",
+			"read_rps", cfg.RateLimit.ReadRate,
+			"write_rps", cfg.RateLimit.WriteRate,
+			"global_rps", cfg.RateLimit.GlobalRate,
+		)
+	}
 
 	// Initialize handlers
-	handler := handlers.NewHandler(posthogClient, cfg, metrics)
+	handler := handlers.NewHandler(posthogClient, cfg, metrics, handlerOpts...)
 
 	// Setup router
 	router := gin.Default()
 
 	// Add OpenTelemetry Middleware
 	router.Use(otelgin.Middleware(cfg.Telemetry.ServiceName))
+	router.Use(handlers.RequestIDMiddleware())
 
 	// Prometheus Metrics Endpoint
 	if cfg.Telemetry.Prometheus {
@@ -89,6 +191,16 @@ func main() {
 		if cfg.Proxy.InsecureMode {
 			status["warning"] = "Running in INSECURE MODE - authentication disabled"
 		}
+
+		// The proxy itself is up while PostHog is failing, so this degrades rather than
+		// fails the health check
+		if breaker != nil {
+			state := breaker.State()
+			status["posthog_circuit"] = state.String()
+			if state != posthog.BreakerClosed {
+				status["status"] = "degraded"
+			}
+		}
 		
 		c.JSON(200, status)
 	})
@@ -101,14 +213,31 @@ func main() {
 	
 	{
 		// Read operations (require 'read' capability)
-		api.GET("/manifest", handler.RequireCapability("read"), handler.GetManifest)
+		api.GET("/manifest", handler.RateLimit("read"), handler.RequireCapability("read"), handler.GetManifest)
+		api.GET("/manifest/stream", handler.RateLimit("read"), handler.RequireCapability("read"), handler.StreamManifest)
+		api.GET("/manifest/flags/:key", handler.RateLimit("read"), handler.RequireCapability("read"), handler.GetFlag)
+		api.GET("/manifest/flags/:key/history", handler.RateLimit("read"), handler.RequireCapability("read"), handler.GetFlagHistory)
+		// Diffing only reads flags, so it is allowed with 'read' despite being a POST
+		api.POST("/manifest/diff", handler.RateLimit("read"), handler.RequireCapability("read"), handler.DiffManifest)
 		
 		// Write operations (require 'write' capability)
-		api.POST("/manifest/flags", handler.RequireCapability("write"), handler.CreateFlag)
-		api.PUT("/manifest/flags/:key", handler.RequireCapability("write"), handler.UpdateFlag)
+		api.POST("/manifest/flags", handler.RateLimit("write"), handler.RequireCapability("write"), handler.CreateFlag)
+		api.PUT("/manifest/flags/:key", handler.RateLimit("write"), handler.RequireCapability("write"), handler.UpdateFlag)
+		api.POST("/manifest/flags/:key/restore", handler.RateLimit("write"), handler.RequireCapability("write"), handler.RestoreFlag)
+
+		// Bulk import may archive flags, so it also requires 'delete'
+		api.PUT("/manifest", handler.RateLimit("write"), handler.RequireCapability("write"), handler.RequireCapability("delete"), handler.ImportManifest)
 		
 		// Delete operations (require 'delete' capability)
-		api.DELETE("/manifest/flags/:key", handler.RequireCapability("delete"), handler.DeleteFlag)
+		api.DELETE("/manifest/flags/:key", handler.RateLimit("write"), handler.RequireCapability("delete"), handler.DeleteFlag)
+	}
+
+	// OpenFeature Remote Evaluation Protocol routes
+	ofrep := router.Group("/ofrep/v1")
+	ofrep.Use(handler.AuthMiddleware())
+	{
+		ofrep.POST("/evaluate/flags", handler.RateLimit("read"), handler.RequireCapability("read"), handler.EvaluateFlags)
+		ofrep.POST("/evaluate/flags/:key", handler.RateLimit("read"), handler.RequireCapability("read"), handler.EvaluateFlag)
 	}
 
 	// Start server
@@ -141,6 +270,25 @@ func main() {
 		}
 	}()
 
+	// Serve flagd's sync.v1 protocol so flagd can source flags from PostHog
+	var grpcServer *grpc.Server
+	if cfg.FlagdSync.Enabled {
+		listener, err := net.Listen("tcp", ":"+strconv.Itoa(cfg.FlagdSync.Port))
+		if err != nil {
+			slog.Error("Failed to listen for flagd sync", "error", err)
+			os.Exit(1)
+		}
+		grpcServer = flagd.NewGRPCServer(flagd.NewServer(posthogClient, cfg.FeatureFlags.TypeCoercion, watcher), cfg, verifier)
+		slog.Info("flagd sync enabled", "port", cfg.FlagdSync.Port)
+
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				slog.Error("Failed to start flagd sync server", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server with
 	// a timeout of 5 seconds.
 	quit := make(chan os.Signal, 1)
@@ -159,6 +307,11 @@ func main() {
 		slog.Error("Server forced to shutdown", "error", err)
 	}
 
+	// Sync streams only end when the client disconnects, so don't wait for them
+	if grpcServer != nil {
+		grpcServer.Stop()
+	}
+
 	// Shutdown telemetry
 	if shutdown != nil {
 		if err := shutdown(context.Background()); err != nil {
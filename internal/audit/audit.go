@@ -0,0 +1,76 @@
+// Package audit records flag changes made through the proxy. PostHog sees every
+// change as the proxy's API key, so the proxy keeps its own record of which caller
+// changed which flag and how.
+package audit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+// Action is the kind of change an event records
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionArchive Action = "archive"
+	ActionRestore Action = "restore"
+	ActionDelete  Action = "delete"
+)
+
+// Result reports whether the change was applied in PostHog
+type Result string
+
+const (
+	ResultSuccess Result = "success"
+	ResultFailure Result = "failure"
+)
+
+// Event is one write operation on a flag. Before and After are the flag's OpenFeature
+// representation; Before is nil for creates and After is nil for deletes and failures.
+type Event struct {
+	Time      time.Time            `json:"time"`
+	RequestID string               `json:"requestId,omitempty"`
+	Caller    string               `json:"caller"`
+	Action    Action               `json:"action"`
+	FlagKey   string               `json:"flagKey"`
+	Before    *models.ManifestFlag `json:"before,omitempty"`
+	After     *models.ManifestFlag `json:"after,omitempty"`
+	Result    Result               `json:"result"`
+	Error     string               `json:"error,omitempty"`
+}
+
+// Sink receives audit events
+type Sink interface {
+	Write(ctx context.Context, event Event) error
+	Close() error
+}
+
+// MultiSink writes every event to all of its sinks
+type MultiSink []Sink
+
+// Write writes the event to every sink, returning the errors of those that failed
+func (m MultiSink) Write(ctx context.Context, event Event) error {
+	var errs []error
+	for _, sink := range m {
+		if err := sink.Write(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every sink
+func (m MultiSink) Close() error {
+	var errs []error
+	for _, sink := range m {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
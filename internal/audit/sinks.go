@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileSink appends events to a file as JSON lines
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink opens, creating if needed, the JSON-lines file at path
+func NewFileSink(path string) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	return &FileSink{file: file}, nil
+}
+
+// Write appends the event as a single line
+func (s *FileSink) Write(_ context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding audit event: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(line); err != nil {
+		return fmt.Errorf("writing audit event: %w", err)
+	}
+	return nil
+}
+
+// Close closes the file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// LogSink writes events as log records, so they are exported over OTLP along with
+// the rest of the proxy's logs when handed the telemetry logger
+type LogSink struct {
+	logger *slog.Logger
+}
+
+// NewLogSink creates a sink that logs to the given logger
+func NewLogSink(logger *slog.Logger) *LogSink {
+	return &LogSink{logger: logger}
+}
+
+// Write logs the event. The flag representations are JSON-encoded so every log
+// backend receives them the same way.
+func (s *LogSink) Write(ctx context.Context, event Event) error {
+	attrs := []slog.Attr{
+		slog.String("audit.action", string(event.Action)),
+		slog.String("audit.flag_key", event.FlagKey),
+		slog.String("audit.caller", event.Caller),
+		slog.String("audit.result", string(event.Result)),
+	}
+	if event.RequestID != "" {
+		attrs = append(attrs, slog.String("audit.request_id", event.RequestID))
+	}
+	if event.Before != nil {
+		before, err := json.Marshal(event.Before)
+		if err != nil {
+			return fmt.Errorf("encoding audit event: %w", err)
+		}
+		attrs = append(attrs, slog.String("audit.before", string(before)))
+	}
+	if event.After != nil {
+		after, err := json.Marshal(event.After)
+		if err != nil {
+			return fmt.Errorf("encoding audit event: %w", err)
+		}
+		attrs = append(attrs, slog.String("audit.after", string(after)))
+	}
+	if event.Error != "" {
+		attrs = append(attrs, slog.String("audit.error", event.Error))
+	}
+
+	level := slog.LevelInfo
+	if event.Result == ResultFailure {
+		level = slog.LevelWarn
+	}
+	s.logger.LogAttrs(ctx, level, "Flag change audited", attrs...)
+	return nil
+}
+
+// Close is a no-op; the logger is owned by the telemetry setup
+func (s *LogSink) Close() error {
+	return nil
+}
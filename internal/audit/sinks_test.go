@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent() Event {
+	return Event{
+		Time:      time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+		RequestID: "req-1",
+		Caller:    "token:0123456789ab",
+		Action:    ActionUpdate,
+		FlagKey:   "checkout",
+		Before:    &models.ManifestFlag{Key: "checkout", Type: models.FlagTypeBoolean, DefaultValue: true, State: models.FlagStateEnabled},
+		After:     &models.ManifestFlag{Key: "checkout", Type: models.FlagTypeBoolean, DefaultValue: true, State: models.FlagStateDisabled},
+		Result:    ResultSuccess,
+	}
+}
+
+func TestFileSink_AppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(context.Background(), testEvent()))
+
+	failed := testEvent()
+	failed.Action = ActionDelete
+	failed.After = nil
+	failed.Result = ResultFailure
+	failed.Error = "PostHog API error (status 500)"
+	require.NoError(t, sink.Write(context.Background(), failed))
+	require.NoError(t, sink.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.Len(t, events, 2)
+	assert.Equal(t, "checkout", events[0].FlagKey)
+	assert.Equal(t, models.FlagStateDisabled, events[0].After.State)
+	assert.Equal(t, ResultFailure, events[1].Result)
+	assert.Nil(t, events[1].After)
+}
+
+func TestLogSink_WritesRecord(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewLogSink(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	require.NoError(t, sink.Write(context.Background(), testEvent()))
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, "update", record["audit.action"])
+	assert.Equal(t, "checkout", record["audit.flag_key"])
+	assert.Equal(t, "token:0123456789ab", record["audit.caller"])
+	assert.Equal(t, "req-1", record["audit.request_id"])
+	assert.JSONEq(t, `{"key":"checkout","type":"boolean","defaultValue":true,"state":"DISABLED"}`, record["audit.after"].(string))
+}
+
+type failingSink struct{ err error }
+
+func (f failingSink) Write(context.Context, Event) error { return f.err }
+func (f failingSink) Close() error                       { return nil }
+
+func TestMultiSink_WritesToAllSinks(t *testing.T) {
+	var buf bytes.Buffer
+	sinks := MultiSink{
+		failingSink{err: errors.New("disk full")},
+		NewLogSink(slog.New(slog.NewJSONHandler(&buf, nil))),
+	}
+
+	err := sinks.Write(context.Background(), testEvent())
+
+	assert.ErrorContains(t, err, "disk full")
+	assert.Contains(t, buf.String(), "Flag change audited")
+}
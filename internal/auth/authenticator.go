@@ -0,0 +1,82 @@
+// Package auth resolves the bearer tokens sent to every API the proxy serves, static
+// tokens from the configuration as well as JWTs, to the callers they identify.
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/jwtauth"
+	"github.com/openfeature/posthog-proxy/internal/ratelimit"
+)
+
+// Failed bcrypt attempts a client may make: a burst of bcryptFailureBurst, then one
+// every second. Every attempt with an unknown token costs one bcrypt comparison per
+// bcrypt-hashed token, so an unthrottled client could keep the CPU busy.
+const (
+	bcryptFailureRate  = 1
+	bcryptFailureBurst = 10
+)
+
+// ErrUnknownToken is returned for tokens that are neither a configured static token
+// nor a JWT the proxy accepts
+var ErrUnknownToken = errors.New("token does not match any configured token")
+
+// ThrottledError is returned while a client that keeps sending unknown tokens has to
+// wait before its next token is checked against the bcrypt hashes
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return "too many failed authentication attempts"
+}
+
+// Authenticator resolves bearer tokens to callers. Every API authenticates through it
+// so all of them accept the same static tokens and JWTs.
+type Authenticator struct {
+	auth     config.AuthConfig
+	verifier *jwtauth.Verifier
+	failures *ratelimit.Limiter
+}
+
+// NewAuthenticator creates an authenticator for the configured static tokens and, when
+// verifier is not nil, JWTs
+func NewAuthenticator(auth config.AuthConfig, verifier *jwtauth.Verifier) *Authenticator {
+	a := &Authenticator{auth: auth, verifier: verifier}
+	if auth.HasBcryptTokens() {
+		a.failures = ratelimit.New(bcryptFailureRate, bcryptFailureBurst)
+	}
+	return a
+}
+
+// Authenticate resolves a token sent by client, usually its IP address. Plaintext and
+// SHA-256 tokens are looked up by digest and anything that looks like a JWT goes to the
+// verifier, so neither pays for bcrypt. Only the remaining tokens are checked against
+// the bcrypt hashes, and clients whose tokens keep failing are throttled before that.
+func (a *Authenticator) Authenticate(ctx context.Context, token, client string) (*jwtauth.Identity, error) {
+	if authToken, ok := a.auth.Lookup(token); ok {
+		return identityFor(authToken), nil
+	}
+	if a.verifier != nil && jwtauth.LooksLikeJWT(token) {
+		return a.verifier.Verify(ctx, token)
+	}
+	if a.failures == nil {
+		return nil, ErrUnknownToken
+	}
+
+	if ok, wait := a.failures.Check(client); !ok {
+		return nil, &ThrottledError{RetryAfter: wait}
+	}
+	if authToken, ok := a.auth.VerifyBcrypt(token); ok {
+		return identityFor(authToken), nil
+	}
+	a.failures.Allow(client)
+	return nil, ErrUnknownToken
+}
+
+func identityFor(authToken config.AuthToken) *jwtauth.Identity {
+	return &jwtauth.Identity{Name: authToken.Name, Source: authToken.Name, Capabilities: authToken.Capabilities, Scopes: authToken.Scopes}
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/jwtauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuthenticator_StaticTokens(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("bcrypt-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	a := NewAuthenticator(config.AuthConfig{Tokens: []config.AuthToken{
+		{Name: "plain", Token: "plain-secret", Capabilities: []string{"read"}, Scopes: []string{"checkout-*"}},
+		{Name: "bcrypted", Hash: string(hash), Capabilities: []string{"write"}},
+	}}, nil)
+
+	identity, err := a.Authenticate(context.Background(), "plain-secret", "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, &jwtauth.Identity{Name: "plain", Source: "plain", Capabilities: []string{"read"}, Scopes: []string{"checkout-*"}}, identity)
+
+	identity, err = a.Authenticate(context.Background(), "bcrypt-secret", "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "bcrypted", identity.Name)
+
+	_, err = a.Authenticate(context.Background(), "nope", "10.0.0.1")
+	assert.ErrorIs(t, err, ErrUnknownToken)
+}
+
+func TestAuthenticator_ThrottlesFailedBcryptAttempts(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("bcrypt-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	a := NewAuthenticator(config.AuthConfig{Tokens: []config.AuthToken{
+		{Name: "plain", Token: "plain-secret"},
+		{Name: "bcrypted", Hash: string(hash)},
+	}}, nil)
+
+	for i := 0; i < bcryptFailureBurst; i++ {
+		_, err := a.Authenticate(context.Background(), "guess", "10.0.0.1")
+		require.ErrorIs(t, err, ErrUnknownToken, "attempt %d", i)
+	}
+
+	// Further guesses are turned away before bcrypt runs, even with the right token
+	var throttled *ThrottledError
+	_, err = a.Authenticate(context.Background(), "bcrypt-secret", "10.0.0.1")
+	require.True(t, errors.As(err, &throttled))
+	assert.Positive(t, throttled.RetryAfter)
+
+	// Tokens found without bcrypt and other clients are unaffected
+	_, err = a.Authenticate(context.Background(), "plain-secret", "10.0.0.1")
+	assert.NoError(t, err)
+	_, err = a.Authenticate(context.Background(), "bcrypt-secret", "10.0.0.2")
+	assert.NoError(t, err)
+}
+
+func TestAuthenticator_WithoutBcryptTokensNeverThrottles(t *testing.T) {
+	a := NewAuthenticator(config.AuthConfig{Tokens: []config.AuthToken{{Name: "plain", Token: "plain-secret"}}}, nil)
+
+	for i := 0; i < bcryptFailureBurst*2; i++ {
+		_, err := a.Authenticate(context.Background(), "guess", "10.0.0.1")
+		require.ErrorIs(t, err, ErrUnknownToken)
+	}
+}
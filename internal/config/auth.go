@@ -0,0 +1,98 @@
+package config
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// sha256HashPrefix marks a token hash as the hex-encoded SHA-256 of the token
+const sha256HashPrefix = "sha256:"
+
+// Lookup finds the plaintext or SHA-256 token matching a bearer token. Every entry is
+// compared by the SHA-256 digest of the token in constant time, so the time taken
+// reveals neither the length of a configured token nor which entry matched.
+func (a AuthConfig) Lookup(token string) (AuthToken, bool) {
+	if token == "" {
+		return AuthToken{}, false
+	}
+
+	digest := sha256.Sum256([]byte(token))
+	match := -1
+	for i, authToken := range a.Tokens {
+		var expected []byte
+		switch {
+		case authToken.Hash == "":
+			sum := sha256.Sum256([]byte(authToken.Token))
+			expected = sum[:]
+		case strings.HasPrefix(authToken.Hash, sha256HashPrefix):
+			expected, _ = hex.DecodeString(strings.TrimPrefix(authToken.Hash, sha256HashPrefix))
+		default:
+			continue
+		}
+		if subtle.ConstantTimeCompare(expected, digest[:]) == 1 && match < 0 {
+			match = i
+		}
+	}
+	if match >= 0 {
+		return a.Tokens[match], true
+	}
+	return AuthToken{}, false
+}
+
+// VerifyBcrypt checks a bearer token against the bcrypt hashes. Each hash costs a
+// deliberately slow comparison, so callers should only get here for tokens Lookup
+// rejected and should throttle clients whose attempts keep failing.
+func (a AuthConfig) VerifyBcrypt(token string) (AuthToken, bool) {
+	if token == "" {
+		return AuthToken{}, false
+	}
+	for _, authToken := range a.Tokens {
+		if isBcryptHash(authToken.Hash) && bcrypt.CompareHashAndPassword([]byte(authToken.Hash), []byte(token)) == nil {
+			return authToken, true
+		}
+	}
+	return AuthToken{}, false
+}
+
+// HasBcryptTokens reports whether any token is configured as a bcrypt hash
+func (a AuthConfig) HasBcryptTokens() bool {
+	for _, authToken := range a.Tokens {
+		if isBcryptHash(authToken.Hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTokenSecret turns a configured secret into a token: a "sha256:<hex>" value or a
+// bcrypt hash is stored as a hash, anything else is taken as the plaintext token
+func parseTokenSecret(name, secret string, capabilities []string) (AuthToken, error) {
+	authToken := AuthToken{Name: name, Capabilities: capabilities}
+	switch {
+	case strings.HasPrefix(secret, sha256HashPrefix):
+		sum, err := hex.DecodeString(strings.TrimPrefix(secret, sha256HashPrefix))
+		if err != nil || len(sum) != sha256.Size {
+			return AuthToken{}, fmt.Errorf("token %q: sha256 hash must be 64 hex characters", name)
+		}
+		authToken.Hash = sha256HashPrefix + hex.EncodeToString(sum)
+	case isBcryptHash(secret):
+		if _, err := bcrypt.Cost([]byte(secret)); err != nil {
+			return AuthToken{}, fmt.Errorf("token %q: invalid bcrypt hash: %w", name, err)
+		}
+		authToken.Hash = secret
+	case secret == "":
+		return AuthToken{}, fmt.Errorf("token %q: token is empty", name)
+	default:
+		authToken.Token = secret
+	}
+	return authToken, nil
+}
+
+func isBcryptHash(value string) bool {
+	return strings.HasPrefix(value, "$2a$") || strings.HasPrefix(value, "$2b$") || strings.HasPrefix(value, "$2y$")
+}
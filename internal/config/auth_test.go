@@ -0,0 +1,153 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func sha256Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestLookupAndVerifyBcrypt(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("bcrypt-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	auth := AuthConfig{Tokens: []AuthToken{
+		{Name: "plain", Token: "plain-secret", Capabilities: []string{"read"}},
+		{Name: "hashed", Hash: sha256Hash("sha-secret"), Capabilities: []string{"read", "write"}},
+		{Name: "bcrypted", Hash: string(bcryptHash), Capabilities: []string{"read", "write", "delete"}},
+	}}
+
+	tests := []struct {
+		token    string
+		wantName string
+	}{
+		{token: "plain-secret", wantName: "plain"},
+		{token: "sha-secret", wantName: "hashed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantName, func(t *testing.T) {
+			token, ok := auth.Lookup(tt.token)
+			require.True(t, ok)
+			assert.Equal(t, tt.wantName, token.Name)
+		})
+	}
+
+	token, ok := auth.VerifyBcrypt("bcrypt-secret")
+	require.True(t, ok)
+	assert.Equal(t, "bcrypted", token.Name)
+
+	for _, token := range []string{"", "unknown", "plain-secre", sha256Hash("sha-secret"), string(bcryptHash)} {
+		_, ok := auth.Lookup(token)
+		assert.False(t, ok, "token %q should not be found", token)
+		_, ok = auth.VerifyBcrypt(token)
+		assert.False(t, ok, "token %q should not match a bcrypt hash", token)
+	}
+}
+
+func TestLookupSkipsBcrypt(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("bcrypt-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	auth := AuthConfig{Tokens: []AuthToken{
+		{Name: "plain", Token: "plain-secret"},
+		{Name: "bcrypted", Hash: string(bcryptHash)},
+	}}
+
+	token, ok := auth.Lookup("plain-secret")
+	require.True(t, ok)
+	assert.Equal(t, "plain", token.Name)
+
+	_, ok = auth.Lookup("bcrypt-secret")
+	assert.False(t, ok)
+	assert.True(t, auth.HasBcryptTokens())
+	assert.False(t, AuthConfig{Tokens: auth.Tokens[:1]}.HasBcryptTokens())
+}
+
+func TestLoadAuthTokens(t *testing.T) {
+	t.Setenv("READ_TOKEN", "read-secret")
+	t.Setenv("ADMIN_TOKEN_HASH", sha256Hash("admin-secret"))
+	t.Setenv("CUSTOM_TOKEN_PAYMENTS_CI", sha256Hash("ci-secret")+":read, write")
+	t.Setenv("CUSTOM_TOKEN_LEGACY", "legacy-secret:read")
+
+	tokens, err := loadAuthTokens()
+	require.NoError(t, err)
+	auth := AuthConfig{Tokens: tokens}
+
+	read, ok := auth.Lookup("read-secret")
+	require.True(t, ok)
+	assert.Equal(t, "read", read.Name)
+
+	admin, ok := auth.Lookup("admin-secret")
+	require.True(t, ok)
+	assert.Equal(t, "admin", admin.Name)
+	assert.Equal(t, []string{"read", "write", "delete"}, admin.Capabilities)
+
+	ci, ok := auth.Lookup("ci-secret")
+	require.True(t, ok)
+	assert.Equal(t, "payments-ci", ci.Name)
+	assert.Equal(t, []string{"read", "write"}, ci.Capabilities)
+
+	legacy, ok := auth.Lookup("legacy-secret")
+	require.True(t, ok)
+	assert.Equal(t, "legacy", legacy.Name)
+}
+
+func TestLoadAuthTokens_InvalidHash(t *testing.T) {
+	tests := map[string]string{
+		"WRITE_TOKEN_HASH":   "not-a-hash",
+		"CUSTOM_TOKEN_SHORT": "sha256:abcd:read",
+		"CUSTOM_TOKEN_NOCAP": sha256Hash("secret"),
+		"CUSTOM_TOKEN_BCRYP": "$2a$10$tooshort:read",
+	}
+	for env, value := range tests {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, value)
+			_, err := loadAuthTokens()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadJWTConfig(t *testing.T) {
+	t.Setenv("JWT_ISSUER", "https://sso.example.com")
+	t.Setenv("JWT_AUDIENCE", "posthog-proxy")
+	t.Setenv("JWT_JWKS_URL", "https://sso.example.com/jwks")
+	t.Setenv("JWT_CAPABILITY_MAPPING", "flag-admins=read,write,delete; flag-readers=read")
+
+	jwt, err := loadJWTConfig()
+	require.NoError(t, err)
+	assert.True(t, jwt.Enabled())
+	assert.Equal(t, "capabilities", jwt.CapabilitiesClaim)
+	assert.Equal(t, "sub", jwt.NameClaim)
+	assert.Equal(t, map[string][]string{
+		"flag-admins":  {"read", "write", "delete"},
+		"flag-readers": {"read"},
+	}, jwt.CapabilityMapping)
+
+	t.Setenv("JWT_JWKS_FILE", "/etc/proxy/jwks.json")
+	_, err = loadJWTConfig()
+	assert.Error(t, err, "a JWKS URL and file are mutually exclusive")
+}
+
+func TestLoadAuthTokens_Scopes(t *testing.T) {
+	t.Setenv("CUSTOM_TOKEN_PAYMENTS_CI", "ci-secret:read,write")
+	t.Setenv("TOKEN_SCOPES_PAYMENTS_CI", "checkout-*, payments-*")
+
+	tokens, err := loadAuthTokens()
+	require.NoError(t, err)
+	token, ok := AuthConfig{Tokens: tokens}.Lookup("ci-secret")
+	require.True(t, ok)
+	assert.Equal(t, []string{"checkout-*", "payments-*"}, token.Scopes)
+
+	t.Setenv("TOKEN_SCOPES_PAYMENTS_CI", "checkout-[")
+	_, err = loadAuthTokens()
+	assert.Error(t, err)
+}
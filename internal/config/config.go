@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 )
@@ -12,6 +13,11 @@ type Config struct {
 	PostHog      PostHogConfig      `json:"posthog"`
 	Proxy        ProxyConfig        `json:"proxy"`
 	FeatureFlags FeatureFlagsConfig `json:"feature_flags"`
+	Cache        CacheConfig        `json:"cache"`
+	Stream       StreamConfig       `json:"stream"`
+	FlagdSync    FlagdSyncConfig    `json:"flagd_sync"`
+	Audit        AuditConfig        `json:"audit"`
+	RateLimit    RateLimitConfig    `json:"rate_limit"`
 	Telemetry    TelemetryConfig    `json:"telemetry"`
 }
 
@@ -21,6 +27,28 @@ type PostHogConfig struct {
 	ProjectID string `json:"project_id"`
 	Host      string `json:"host"`
 	Timeout   int    `json:"timeout"` // Timeout in seconds
+
+	Retry          RetryConfig          `json:"retry"`
+	CircuitBreaker CircuitBreakerConfig `json:"circuit_breaker"`
+}
+
+// RetryConfig represents how failed PostHog API calls are retried
+type RetryConfig struct {
+	MaxRetries       int     `json:"max_retries"`
+	InitialBackoff   int     `json:"initial_backoff"`    // Milliseconds before the first retry, doubled for each one after
+	MaxBackoff       int     `json:"max_backoff"`        // Upper bound of the backoff in milliseconds
+	BudgetRatio      float64 `json:"budget_ratio"`       // Retries allowed as a fraction of requests, 0 for no budget
+	BudgetMinRetries int     `json:"budget_min_retries"` // Retries allowed per budget window regardless of traffic
+}
+
+// CircuitBreakerConfig represents when PostHog API calls are failed fast because
+// PostHog is failing
+type CircuitBreakerConfig struct {
+	Enabled     bool    `json:"enabled"`
+	FailureRate float64 `json:"failure_rate"` // Fraction of failed requests in a window that opens the breaker
+	MinRequests int     `json:"min_requests"` // Requests needed in a window before the failure rate counts
+	Window      int     `json:"window"`       // Window length in seconds
+	CoolDown    int     `json:"cool_down"`    // Seconds the breaker stays open before probing PostHog
 }
 
 // ProxyConfig represents proxy server configuration
@@ -33,12 +61,38 @@ type ProxyConfig struct {
 // AuthConfig represents authentication configuration
 type AuthConfig struct {
 	Tokens []AuthToken `json:"tokens"`
+	JWT    JWTConfig   `json:"jwt"`
+}
+
+// JWTConfig represents JWT bearer authentication against an OIDC issuer. It is
+// enabled when Issuer is set; static tokens keep working alongside it.
+type JWTConfig struct {
+	Issuer            string              `json:"issuer"`
+	Audience          string              `json:"audience"`
+	JWKSURL           string              `json:"jwks_url"`
+	JWKSFile          string              `json:"jwks_file"`
+	CapabilitiesClaim string              `json:"capabilities_claim"` // dotted path, e.g. "realm_access.roles"
+	CapabilityMapping map[string][]string `json:"capability_mapping"` // claim value -> capabilities; empty means values are capabilities
+	NameClaim         string              `json:"name_claim"`
+	ScopesClaim       string              `json:"scopes_claim"` // claim holding flag key patterns; empty means unscoped
 }
 
-// AuthToken represents an authentication token with capabilities
+// Enabled reports whether JWT authentication is configured
+func (j JWTConfig) Enabled() bool {
+	return j.Issuer != ""
+}
+
+// AuthToken represents an authentication token with capabilities. Name identifies
+// the client using the token in logs, metrics and audit records. The secret is held
+// either as the plaintext Token or, preferably, as a Hash: "sha256:<hex>" or a bcrypt
+// hash. Scopes restricts the token to flag keys matching one of its glob patterns;
+// a token without scopes may access every flag.
 type AuthToken struct {
-	Token        string   `json:"token"`
+	Name         string   `json:"name"`
+	Token        string   `json:"-"`
+	Hash         string   `json:"-"`
 	Capabilities []string `json:"capabilities"`
+	Scopes       []string `json:"scopes,omitempty"`
 }
 
 // FeatureFlagsConfig represents feature flag-specific configuration
@@ -56,6 +110,44 @@ type TypeCoercionConfig struct {
 	CoerceBooleanStrings bool `json:"coerce_boolean_strings"`
 }
 
+// CacheConfig represents the in-memory feature flag cache configuration
+type CacheConfig struct {
+	Enabled         bool `json:"enabled"`
+	RefreshInterval int  `json:"refresh_interval"` // Refresh interval in seconds
+}
+
+// StreamConfig represents the manifest change stream configuration
+type StreamConfig struct {
+	Enabled      bool `json:"enabled"`
+	PollInterval int  `json:"poll_interval"` // Poll interval in seconds
+	HistorySize  int  `json:"history_size"`  // Events kept for Last-Event-ID resumption
+}
+
+// FlagdSyncConfig represents the flagd sync.v1 gRPC server configuration
+type FlagdSyncConfig struct {
+	Enabled bool `json:"enabled"`
+	Port    int  `json:"port"`
+}
+
+// RateLimitConfig represents the token-bucket budgets callers are throttled by. Read
+// and write budgets apply per caller; the global budget is shared by all callers and
+// is disabled when GlobalRate is zero.
+type RateLimitConfig struct {
+	Enabled     bool    `json:"enabled"`
+	ReadRate    float64 `json:"read_rate"` // Requests per second
+	ReadBurst   int     `json:"read_burst"`
+	WriteRate   float64 `json:"write_rate"`
+	WriteBurst  int     `json:"write_burst"`
+	GlobalRate  float64 `json:"global_rate"`
+	GlobalBurst int     `json:"global_burst"`
+}
+
+// AuditConfig represents where audit events for flag changes are written
+type AuditConfig struct {
+	Sinks    []string `json:"sinks"`     // "file" and/or "otlp"; empty disables auditing
+	FilePath string   `json:"file_path"` // JSON-lines file used by the "file" sink
+}
+
 // TelemetryConfig represents OpenTelemetry configuration
 type TelemetryConfig struct {
 	ServiceName  string `json:"service_name"`
@@ -89,6 +181,65 @@ func Load() (*Config, error) {
 	}
 	cfg.PostHog.Timeout = timeout
 
+	// Retry configuration
+	retryInts := []struct {
+		env    string
+		target *int
+		def    string
+		min    int
+	}{
+		{"POSTHOG_RETRY_MAX_RETRIES", &cfg.PostHog.Retry.MaxRetries, "3", 0},
+		{"POSTHOG_RETRY_INITIAL_BACKOFF_MS", &cfg.PostHog.Retry.InitialBackoff, "1000", 1},
+		{"POSTHOG_RETRY_MAX_BACKOFF_MS", &cfg.PostHog.Retry.MaxBackoff, "10000", 1},
+		{"POSTHOG_RETRY_BUDGET_MIN_RETRIES", &cfg.PostHog.Retry.BudgetMinRetries, "10", 0},
+	}
+	for _, r := range retryInts {
+		value, err := strconv.Atoi(getEnvOrDefault(r.env, r.def))
+		if err != nil || value < r.min {
+			return nil, fmt.Errorf("invalid %s: must be an integer of at least %d", r.env, r.min)
+		}
+		*r.target = value
+	}
+	if cfg.PostHog.Retry.MaxBackoff < cfg.PostHog.Retry.InitialBackoff {
+		return nil, fmt.Errorf("invalid POSTHOG_RETRY_MAX_BACKOFF_MS: must not be less than POSTHOG_RETRY_INITIAL_BACKOFF_MS")
+	}
+
+	budgetRatio, err := strconv.ParseFloat(getEnvOrDefault("POSTHOG_RETRY_BUDGET_RATIO", "0.2"), 64)
+	if err != nil || budgetRatio < 0 {
+		return nil, fmt.Errorf("invalid POSTHOG_RETRY_BUDGET_RATIO: must be a non-negative number")
+	}
+	cfg.PostHog.Retry.BudgetRatio = budgetRatio
+
+	// Circuit breaker configuration
+	breakerEnabled, err := strconv.ParseBool(getEnvOrDefault("POSTHOG_CIRCUIT_BREAKER_ENABLED", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid POSTHOG_CIRCUIT_BREAKER_ENABLED: %w", err)
+	}
+	cfg.PostHog.CircuitBreaker.Enabled = breakerEnabled
+
+	failureRate, err := strconv.ParseFloat(getEnvOrDefault("POSTHOG_CIRCUIT_BREAKER_FAILURE_RATE", "0.5"), 64)
+	if err != nil || failureRate <= 0 || failureRate > 1 {
+		return nil, fmt.Errorf("invalid POSTHOG_CIRCUIT_BREAKER_FAILURE_RATE: must be greater than 0 and at most 1")
+	}
+	cfg.PostHog.CircuitBreaker.FailureRate = failureRate
+
+	breakerInts := []struct {
+		env    string
+		target *int
+		def    string
+	}{
+		{"POSTHOG_CIRCUIT_BREAKER_MIN_REQUESTS", &cfg.PostHog.CircuitBreaker.MinRequests, "5"},
+		{"POSTHOG_CIRCUIT_BREAKER_WINDOW", &cfg.PostHog.CircuitBreaker.Window, "30"},
+		{"POSTHOG_CIRCUIT_BREAKER_COOLDOWN", &cfg.PostHog.CircuitBreaker.CoolDown, "30"},
+	}
+	for _, b := range breakerInts {
+		value, err := strconv.Atoi(getEnvOrDefault(b.env, b.def))
+		if err != nil || value < 1 {
+			return nil, fmt.Errorf("invalid %s: must be a positive integer", b.env)
+		}
+		*b.target = value
+	}
+
 	// Proxy configuration
 	portStr := getEnvOrDefault("PROXY_PORT", "8080")
 	port, err := strconv.Atoi(portStr)
@@ -106,7 +257,17 @@ func Load() (*Config, error) {
 	cfg.Proxy.InsecureMode = insecure
 
 	// Authentication configuration
-	cfg.Proxy.Auth.Tokens = loadAuthTokens()
+	tokens, err := loadAuthTokens()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Proxy.Auth.Tokens = tokens
+
+	jwtConfig, err := loadJWTConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Proxy.Auth.JWT = jwtConfig
 
 	// Feature flags configuration
 	defaultRolloutStr := getEnvOrDefault("DEFAULT_ROLLOUT_PERCENTAGE", "0")
@@ -138,6 +299,113 @@ func Load() (*Config, error) {
 	}
 	cfg.FeatureFlags.TypeCoercion.CoerceBooleanStrings = coerceBoolean
 
+	// Cache configuration
+	cacheEnabledStr := getEnvOrDefault("FLAG_CACHE_ENABLED", "false")
+	cacheEnabled, err := strconv.ParseBool(cacheEnabledStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FLAG_CACHE_ENABLED: %w", err)
+	}
+	cfg.Cache.Enabled = cacheEnabled
+
+	cacheRefreshStr := getEnvOrDefault("FLAG_CACHE_REFRESH_INTERVAL", "30")
+	cacheRefresh, err := strconv.Atoi(cacheRefreshStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FLAG_CACHE_REFRESH_INTERVAL: %w", err)
+	}
+	cfg.Cache.RefreshInterval = cacheRefresh
+
+	// Manifest stream configuration
+	streamEnabledStr := getEnvOrDefault("MANIFEST_STREAM_ENABLED", "false")
+	streamEnabled, err := strconv.ParseBool(streamEnabledStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MANIFEST_STREAM_ENABLED: %w", err)
+	}
+	cfg.Stream.Enabled = streamEnabled
+
+	streamPollStr := getEnvOrDefault("MANIFEST_STREAM_POLL_INTERVAL", "15")
+	streamPoll, err := strconv.Atoi(streamPollStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MANIFEST_STREAM_POLL_INTERVAL: %w", err)
+	}
+	cfg.Stream.PollInterval = streamPoll
+
+	streamHistoryStr := getEnvOrDefault("MANIFEST_STREAM_HISTORY_SIZE", "256")
+	streamHistory, err := strconv.Atoi(streamHistoryStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MANIFEST_STREAM_HISTORY_SIZE: %w", err)
+	}
+	cfg.Stream.HistorySize = streamHistory
+
+	// flagd sync configuration
+	flagdEnabledStr := getEnvOrDefault("FLAGD_SYNC_ENABLED", "false")
+	flagdEnabled, err := strconv.ParseBool(flagdEnabledStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FLAGD_SYNC_ENABLED: %w", err)
+	}
+	cfg.FlagdSync.Enabled = flagdEnabled
+
+	flagdPortStr := getEnvOrDefault("FLAGD_SYNC_PORT", "8015")
+	flagdPort, err := strconv.Atoi(flagdPortStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FLAGD_SYNC_PORT: %w", err)
+	}
+	cfg.FlagdSync.Port = flagdPort
+
+	// Audit log configuration
+	for _, sink := range strings.Split(getEnvOrDefault("AUDIT_SINKS", ""), ",") {
+		sink = strings.ToLower(strings.TrimSpace(sink))
+		switch sink {
+		case "":
+			continue
+		case "file", "otlp":
+			cfg.Audit.Sinks = append(cfg.Audit.Sinks, sink)
+		default:
+			return nil, fmt.Errorf("invalid AUDIT_SINKS: unknown sink %q (expected file or otlp)", sink)
+		}
+	}
+	cfg.Audit.FilePath = getEnvOrDefault("AUDIT_FILE_PATH", "./logs/audit.jsonl")
+
+	// Rate limit configuration
+	rateLimitEnabled, err := strconv.ParseBool(getEnvOrDefault("RATE_LIMIT_ENABLED", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_ENABLED: %w", err)
+	}
+	cfg.RateLimit.Enabled = rateLimitEnabled
+
+	rates := []struct {
+		env    string
+		target *float64
+		def    string
+	}{
+		{"RATE_LIMIT_READ_RPS", &cfg.RateLimit.ReadRate, "20"},
+		{"RATE_LIMIT_WRITE_RPS", &cfg.RateLimit.WriteRate, "2"},
+		{"RATE_LIMIT_GLOBAL_RPS", &cfg.RateLimit.GlobalRate, "0"},
+	}
+	for _, r := range rates {
+		rate, err := strconv.ParseFloat(getEnvOrDefault(r.env, r.def), 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("invalid %s: must be a non-negative number", r.env)
+		}
+		*r.target = rate
+	}
+
+	bursts := []struct {
+		env    string
+		target *int
+		def    string
+	}{
+		{"RATE_LIMIT_READ_BURST", &cfg.RateLimit.ReadBurst, "40"},
+		{"RATE_LIMIT_WRITE_BURST", &cfg.RateLimit.WriteBurst, "10"},
+		{"RATE_LIMIT_GLOBAL_BURST", &cfg.RateLimit.GlobalBurst, "100"},
+	}
+	for _, b := range bursts {
+		burst, err := strconv.Atoi(getEnvOrDefault(b.env, b.def))
+		if err != nil || burst < 1 {
+			return nil, fmt.Errorf("invalid %s: must be a positive integer", b.env)
+		}
+		*b.target = burst
+	}
+
 	// Telemetry configuration
 	cfg.Telemetry.ServiceName = getEnvOrDefault("OTEL_SERVICE_NAME", "openfeature-posthog-proxy")
 	cfg.Telemetry.OTLPEndpoint = getEnvOrDefault("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317")
@@ -164,57 +432,144 @@ func Load() (*Config, error) {
 }
 
 // loadAuthTokens loads authentication tokens from environment variables
-func loadAuthTokens() []AuthToken {
+func loadAuthTokens() ([]AuthToken, error) {
 	var tokens []AuthToken
 
-	// Add predefined tokens if they exist
-	if readToken := os.Getenv("READ_TOKEN"); readToken != "" {
-		tokens = append(tokens, AuthToken{
-			Token:        readToken,
-			Capabilities: []string{"read"},
-		})
-	}
-
-	if writeToken := os.Getenv("WRITE_TOKEN"); writeToken != "" {
-		tokens = append(tokens, AuthToken{
-			Token:        writeToken,
-			Capabilities: []string{"read", "write"},
-		})
+	// Add predefined tokens if they exist, given either in plaintext or as a hash
+	predefined := []struct {
+		name         string
+		env          string
+		capabilities []string
+	}{
+		{name: "read", env: "READ_TOKEN", capabilities: []string{"read"}},
+		{name: "write", env: "WRITE_TOKEN", capabilities: []string{"read", "write"}},
+		{name: "admin", env: "ADMIN_TOKEN", capabilities: []string{"read", "write", "delete"}},
 	}
-
-	if adminToken := os.Getenv("ADMIN_TOKEN"); adminToken != "" {
-		tokens = append(tokens, AuthToken{
-			Token:        adminToken,
-			Capabilities: []string{"read", "write", "delete"},
-		})
+	for _, p := range predefined {
+		for _, env := range []string{p.env, p.env + "_HASH"} {
+			secret := os.Getenv(env)
+			if secret == "" {
+				continue
+			}
+			if env != p.env && !strings.HasPrefix(secret, sha256HashPrefix) && !isBcryptHash(secret) {
+				return nil, fmt.Errorf("invalid %s: expected a sha256:<hex> or bcrypt hash", env)
+			}
+			token, err := parseTokenSecret(p.name, secret, p.capabilities)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", env, err)
+			}
+			tokens = append(tokens, token)
+		}
 	}
 
-	// Load custom tokens from environment
-	// Format: CUSTOM_TOKEN_1=token:capability1,capability2
+	// Load custom tokens from environment, named after the variable suffix
+	// Format: CUSTOM_TOKEN_<NAME>=<token|sha256:hex|bcrypt hash>:capability1,capability2
 	for _, env := range os.Environ() {
 		if strings.HasPrefix(env, "CUSTOM_TOKEN_") {
 			parts := strings.SplitN(env, "=", 2)
 			if len(parts) == 2 {
-				tokenParts := strings.SplitN(parts[1], ":", 2)
-				if len(tokenParts) == 2 {
-					token := tokenParts[0]
-					capabilities := strings.Split(tokenParts[1], ",")
-					
+				// Capabilities never contain ":", while a sha256 secret does
+				sep := strings.LastIndex(parts[1], ":")
+				if sep > 0 {
+					name := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(parts[0], "CUSTOM_TOKEN_"), "_", "-"))
+					capabilities := strings.Split(parts[1][sep+1:], ",")
+
 					// Trim whitespace from capabilities
 					for i, cap := range capabilities {
 						capabilities[i] = strings.TrimSpace(cap)
 					}
 
-					tokens = append(tokens, AuthToken{
-						Token:        token,
-						Capabilities: capabilities,
-					})
+					secret := parts[1][:sep]
+					if secret+":" == sha256HashPrefix {
+						return nil, fmt.Errorf("invalid %s: expected <secret>:<capabilities>", parts[0])
+					}
+
+					token, err := parseTokenSecret(name, secret, capabilities)
+					if err != nil {
+						return nil, fmt.Errorf("invalid %s: %w", parts[0], err)
+					}
+					tokens = append(tokens, token)
+				}
+			}
+		}
+	}
+
+	// Restrict tokens to flag key patterns
+	// Format: TOKEN_SCOPES_<NAME>=checkout-*,payments-*
+	for i := range tokens {
+		env := "TOKEN_SCOPES_" + strings.ToUpper(strings.ReplaceAll(tokens[i].Name, "-", "_"))
+		scopes, err := parseScopes(os.Getenv(env))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", env, err)
+		}
+		tokens[i].Scopes = scopes
+	}
+
+	return tokens, nil
+}
+
+// parseScopes splits a comma-separated list of flag key glob patterns
+func parseScopes(value string) ([]string, error) {
+	var scopes []string
+	for _, pattern := range strings.Split(value, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+		scopes = append(scopes, pattern)
+	}
+	return scopes, nil
+}
+
+// loadJWTConfig loads JWT authentication settings from environment variables
+func loadJWTConfig() (JWTConfig, error) {
+	jwt := JWTConfig{
+		Issuer:            os.Getenv("JWT_ISSUER"),
+		Audience:          os.Getenv("JWT_AUDIENCE"),
+		JWKSURL:           os.Getenv("JWT_JWKS_URL"),
+		JWKSFile:          os.Getenv("JWT_JWKS_FILE"),
+		CapabilitiesClaim: getEnvOrDefault("JWT_CAPABILITIES_CLAIM", "capabilities"),
+		NameClaim:         getEnvOrDefault("JWT_NAME_CLAIM", "sub"),
+		ScopesClaim:       os.Getenv("JWT_SCOPES_CLAIM"),
+	}
+	if !jwt.Enabled() {
+		return jwt, nil
+	}
+
+	if jwt.Audience == "" {
+		return JWTConfig{}, fmt.Errorf("JWT_AUDIENCE is required when JWT_ISSUER is set")
+	}
+	if (jwt.JWKSURL == "") == (jwt.JWKSFile == "") {
+		return JWTConfig{}, fmt.Errorf("exactly one of JWT_JWKS_URL or JWT_JWKS_FILE is required when JWT_ISSUER is set")
+	}
+
+	// Format: JWT_CAPABILITY_MAPPING=flag-admins=read,write,delete;flag-readers=read
+	if mapping := os.Getenv("JWT_CAPABILITY_MAPPING"); mapping != "" {
+		jwt.CapabilityMapping = make(map[string][]string)
+		for _, entry := range strings.Split(mapping, ";") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			sep := strings.LastIndex(entry, "=")
+			if sep <= 0 {
+				return JWTConfig{}, fmt.Errorf("invalid JWT_CAPABILITY_MAPPING: expected <value>=<capabilities>, got %q", entry)
+			}
+			var capabilities []string
+			for _, cap := range strings.Split(entry[sep+1:], ",") {
+				if cap = strings.TrimSpace(cap); cap != "" {
+					capabilities = append(capabilities, cap)
 				}
 			}
+			value := strings.TrimSpace(entry[:sep])
+			jwt.CapabilityMapping[value] = append(jwt.CapabilityMapping[value], capabilities...)
 		}
 	}
 
-	return tokens
+	return jwt, nil
 }
 
 // getEnvOrError returns the environment variable value or an empty string if not set
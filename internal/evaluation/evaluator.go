@@ -0,0 +1,194 @@
+package evaluation
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+// longScale is the largest value representable by the 15 hex characters PostHog
+// takes from the SHA1 digest when bucketing a distinct ID
+const longScale = float64(0xfffffffffffffff)
+
+// Context holds the subject a flag is evaluated for
+type Context struct {
+	// DistinctID is the PostHog distinct ID (the OpenFeature targeting key)
+	DistinctID string
+	// Properties are the person properties used by filter group conditions
+	Properties map[string]interface{}
+}
+
+// Reason explains how a flag value was chosen, using OpenFeature reason codes
+type Reason string
+
+const (
+	// ReasonTargetingMatch means a condition with property filters matched
+	ReasonTargetingMatch Reason = "TARGETING_MATCH"
+	// ReasonSplit means the value was chosen by percentage rollout or variant weights
+	ReasonSplit Reason = "SPLIT"
+	// ReasonStatic means the flag resolves to the same value for everyone
+	ReasonStatic Reason = "STATIC"
+	// ReasonDefault means no condition matched
+	ReasonDefault Reason = "DEFAULT"
+	// ReasonDisabled means the flag is inactive
+	ReasonDisabled Reason = "DISABLED"
+)
+
+// Result is the outcome of evaluating a PostHog flag
+type Result struct {
+	// Match reports whether the flag is enabled for the subject
+	Match bool
+	// Variant is the selected multivariate variant key, empty for boolean flags
+	Variant string
+	// Reason explains how the result was reached
+	Reason Reason
+	// ConditionIndex is the index in Filters.Groups of the matching condition, or -1
+	ConditionIndex int
+	// Explanation is a human readable description of why this result was chosen
+	Explanation string
+}
+
+// Evaluate resolves a PostHog feature flag for the given context the same way
+// PostHog's server-side SDKs do for local evaluation
+func Evaluate(flag models.PostHogFeatureFlag, ctx Context) Result {
+	if flag.Deleted {
+		return Result{Reason: ReasonDisabled, ConditionIndex: -1, Explanation: "flag is deleted"}
+	}
+	if !flag.Active {
+		return Result{Reason: ReasonDisabled, ConditionIndex: -1, Explanation: "flag is inactive"}
+	}
+
+	var skipped []string
+
+	// PostHog evaluates conditions that carry a variant override first
+	for _, index := range orderedConditions(flag.Filters.Groups) {
+		group := flag.Filters.Groups[index]
+
+		if ok, failed := matchesProperties(group.Properties, ctx.Properties); !ok {
+			skipped = append(skipped, fmt.Sprintf("condition %d: %s", index, describeFailure(*failed, ctx.Properties)))
+			continue
+		}
+		if !inRollout(flag.Key, ctx.DistinctID, group.RolloutPercentage) {
+			skipped = append(skipped, fmt.Sprintf("condition %d: outside %d%% rollout", index, *group.RolloutPercentage))
+			continue
+		}
+
+		result := Result{Match: true, ConditionIndex: index, Reason: conditionReason(group)}
+		result.Explanation = describeMatch(index, group)
+
+		if flag.Filters.Multivariate != nil && len(flag.Filters.Multivariate.Variants) > 0 {
+			if group.Variant != nil && hasVariant(flag.Filters.Multivariate, *group.Variant) {
+				result.Variant = *group.Variant
+				result.Explanation += fmt.Sprintf("; variant %q set by condition", result.Variant)
+			} else {
+				result.Variant = matchingVariant(flag.Key, ctx.DistinctID, flag.Filters.Multivariate)
+				result.Explanation += fmt.Sprintf("; variant %q selected by weighted split", result.Variant)
+				if len(group.Properties) == 0 {
+					result.Reason = ReasonSplit
+				}
+			}
+		}
+
+		return result
+	}
+
+	explanation := "flag has no conditions"
+	if len(skipped) > 0 {
+		explanation = "no condition matched (" + strings.Join(skipped, "; ") + ")"
+	}
+	return Result{Reason: ReasonDefault, ConditionIndex: -1, Explanation: explanation}
+}
+
+// Hash returns PostHog's deterministic bucket in [0, 1] for a flag key and distinct ID
+func Hash(key, distinctID, salt string) float64 {
+	sum := sha1.Sum([]byte(key + "." + distinctID + salt))
+	value, err := strconv.ParseUint(hex.EncodeToString(sum[:])[:15], 16, 64)
+	if err != nil {
+		return 0
+	}
+	return float64(value) / longScale
+}
+
+func orderedConditions(groups []models.PostHogFilterGroup) []int {
+	indexes := make([]int, len(groups))
+	for i := range groups {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return groups[indexes[a]].Variant != nil && groups[indexes[b]].Variant == nil
+	})
+	return indexes
+}
+
+func inRollout(key, distinctID string, rolloutPercentage *int) bool {
+	if rolloutPercentage == nil {
+		return true
+	}
+	return Hash(key, distinctID, "") <= float64(*rolloutPercentage)/100
+}
+
+// matchingVariant picks a variant by laying the variant weights out on [0, 1)
+// and finding the range that contains the distinct ID's variant hash
+func matchingVariant(key, distinctID string, multivariate *models.PostHogMultivariate) string {
+	hash := Hash(key, distinctID, "variant")
+
+	lower := 0.0
+	for _, variant := range multivariate.Variants {
+		upper := lower + float64(variant.RolloutFlag)/100
+		if hash >= lower && hash < upper {
+			return variant.Key
+		}
+		lower = upper
+	}
+	return ""
+}
+
+func hasVariant(multivariate *models.PostHogMultivariate, key string) bool {
+	for _, variant := range multivariate.Variants {
+		if variant.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func conditionReason(group models.PostHogFilterGroup) Reason {
+	if len(group.Properties) > 0 {
+		return ReasonTargetingMatch
+	}
+	if group.RolloutPercentage != nil && *group.RolloutPercentage < 100 {
+		return ReasonSplit
+	}
+	return ReasonStatic
+}
+
+func describeMatch(index int, group models.PostHogFilterGroup) string {
+	parts := []string{fmt.Sprintf("condition %d matched", index)}
+	if len(group.Properties) > 0 {
+		parts = append(parts, fmt.Sprintf("%d property filter(s) satisfied", len(group.Properties)))
+	}
+	if group.RolloutPercentage != nil && *group.RolloutPercentage < 100 {
+		parts = append(parts, fmt.Sprintf("inside %d%% rollout", *group.RolloutPercentage))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func describeFailure(filter models.PostHogProperty, properties map[string]interface{}) string {
+	if filter.Type == "cohort" {
+		return fmt.Sprintf("cohort %v cannot be evaluated locally", filter.Value)
+	}
+	operator := filter.Operator
+	if operator == "" {
+		operator = "exact"
+	}
+	value, present := properties[filter.Key]
+	if !present && operator != "is_not_set" {
+		return fmt.Sprintf("property %q is not set", filter.Key)
+	}
+	return fmt.Sprintf("property %q (%s) does not satisfy %s %v", filter.Key, stringify(value), operator, filter.Value)
+}
@@ -0,0 +1,300 @@
+package evaluation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func strPtr(v string) *string {
+	return &v
+}
+
+func TestEvaluate_InactiveFlagIsDisabled(t *testing.T) {
+	flag := models.PostHogFeatureFlag{
+		Key:    "inactive",
+		Active: false,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: intPtr(100)}},
+		},
+	}
+
+	result := Evaluate(flag, Context{DistinctID: "user-1"})
+
+	assert.False(t, result.Match)
+	assert.Equal(t, ReasonDisabled, result.Reason)
+	assert.Equal(t, -1, result.ConditionIndex)
+}
+
+func TestEvaluate_RolloutBoundaries(t *testing.T) {
+	tests := []struct {
+		name    string
+		rollout *int
+		match   bool
+		reason  Reason
+	}{
+		{name: "full rollout", rollout: intPtr(100), match: true, reason: ReasonStatic},
+		{name: "no rollout percentage means everyone", rollout: nil, match: true, reason: ReasonStatic},
+		{name: "zero rollout", rollout: intPtr(0), match: false, reason: ReasonDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flag := models.PostHogFeatureFlag{
+				Key:    "rollout-flag",
+				Active: true,
+				Filters: models.PostHogFilters{
+					Groups: []models.PostHogFilterGroup{{RolloutPercentage: tt.rollout}},
+				},
+			}
+
+			result := Evaluate(flag, Context{DistinctID: "user-1"})
+
+			assert.Equal(t, tt.match, result.Match)
+			assert.Equal(t, tt.reason, result.Reason)
+		})
+	}
+}
+
+func TestEvaluate_PartialRolloutIsDeterministic(t *testing.T) {
+	flag := models.PostHogFeatureFlag{
+		Key:    "partial",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: intPtr(30)}},
+		},
+	}
+
+	matched := 0
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("user-%d", i)
+		first := Evaluate(flag, Context{DistinctID: id})
+		second := Evaluate(flag, Context{DistinctID: id})
+		assert.Equal(t, first, second)
+		if first.Match {
+			matched++
+			assert.Equal(t, ReasonSplit, first.Reason)
+		}
+	}
+
+	// 30% of 1000 subjects, with generous tolerance for hash distribution
+	assert.InDelta(t, 300, matched, 60)
+}
+
+func TestEvaluate_PropertyConditions(t *testing.T) {
+	flag := models.PostHogFeatureFlag{
+		Key:    "beta",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{
+				{
+					Properties: []models.PostHogProperty{
+						{Key: "email", Type: "person", Operator: "icontains", Value: "@example.com"},
+						{Key: "plan", Type: "person", Operator: "exact", Value: []interface{}{"pro", "enterprise"}},
+					},
+					RolloutPercentage: intPtr(100),
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		properties map[string]interface{}
+		match      bool
+	}{
+		{name: "all conditions match", properties: map[string]interface{}{"email": "Jane@Example.com", "plan": "pro"}, match: true},
+		{name: "one condition fails", properties: map[string]interface{}{"email": "jane@other.com", "plan": "pro"}, match: false},
+		{name: "property missing", properties: map[string]interface{}{"email": "jane@example.com"}, match: false},
+		{name: "no properties", properties: nil, match: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Evaluate(flag, Context{DistinctID: "user-1", Properties: tt.properties})
+
+			assert.Equal(t, tt.match, result.Match)
+			if tt.match {
+				assert.Equal(t, ReasonTargetingMatch, result.Reason)
+				assert.Equal(t, 0, result.ConditionIndex)
+			} else {
+				assert.Equal(t, ReasonDefault, result.Reason)
+			}
+		})
+	}
+}
+
+func TestEvaluate_VariantOverrideConditionsWinFirst(t *testing.T) {
+	flag := models.PostHogFeatureFlag{
+		Key:    "checkout",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{
+				{RolloutPercentage: intPtr(100)},
+				{
+					Properties:        []models.PostHogProperty{{Key: "country", Operator: "exact", Value: "NL"}},
+					RolloutPercentage: intPtr(100),
+					Variant:           strPtr("treatment"),
+				},
+			},
+			Multivariate: &models.PostHogMultivariate{
+				Variants: []models.PostHogVariant{
+					{Key: "control", RolloutFlag: 100},
+					{Key: "treatment", RolloutFlag: 0},
+				},
+			},
+		},
+	}
+
+	overridden := Evaluate(flag, Context{DistinctID: "user-1", Properties: map[string]interface{}{"country": "NL"}})
+	assert.True(t, overridden.Match)
+	assert.Equal(t, "treatment", overridden.Variant)
+	assert.Equal(t, 1, overridden.ConditionIndex)
+	assert.Equal(t, ReasonTargetingMatch, overridden.Reason)
+
+	split := Evaluate(flag, Context{DistinctID: "user-1", Properties: map[string]interface{}{"country": "DE"}})
+	assert.True(t, split.Match)
+	assert.Equal(t, "control", split.Variant)
+	assert.Equal(t, 0, split.ConditionIndex)
+	assert.Equal(t, ReasonSplit, split.Reason)
+}
+
+func TestEvaluate_VariantDistributionFollowsWeights(t *testing.T) {
+	flag := models.PostHogFeatureFlag{
+		Key:    "experiment",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: intPtr(100)}},
+			Multivariate: &models.PostHogMultivariate{
+				Variants: []models.PostHogVariant{
+					{Key: "a", RolloutFlag: 20},
+					{Key: "b", RolloutFlag: 80},
+				},
+			},
+		},
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		result := Evaluate(flag, Context{DistinctID: fmt.Sprintf("user-%d", i)})
+		counts[result.Variant]++
+	}
+
+	assert.InDelta(t, 200, counts["a"], 60)
+	assert.InDelta(t, 800, counts["b"], 60)
+	assert.Zero(t, counts[""])
+}
+
+func TestHash_Range(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		value := Hash("flag", fmt.Sprintf("id-%d", i), "")
+		assert.GreaterOrEqual(t, value, 0.0)
+		assert.LessOrEqual(t, value, 1.0)
+	}
+}
+
+// The vectors below come from the consistency tests in PostHog's server-side SDKs,
+// which all SDKs must agree on for the same flag key and distinct ID
+func TestEvaluate_PostHogSimpleFlagConsistency(t *testing.T) {
+	flag := models.PostHogFeatureFlag{
+		Key:    "simple-flag",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: intPtr(45)}},
+		},
+	}
+
+	expected := []bool{
+		false, true, true, false, true, false, false, true, false, true,
+		false, true, true, false, true, false, false, false, true, true,
+	}
+
+	for i, want := range expected {
+		result := Evaluate(flag, Context{DistinctID: fmt.Sprintf("distinct_id_%d", i)})
+		assert.Equal(t, want, result.Match, "distinct_id_%d", i)
+	}
+}
+
+func TestEvaluate_PostHogMultivariateFlagConsistency(t *testing.T) {
+	flag := models.PostHogFeatureFlag{
+		Key:    "multivariate-flag",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: intPtr(55)}},
+			Multivariate: &models.PostHogMultivariate{
+				Variants: []models.PostHogVariant{
+					{Key: "first-variant", RolloutFlag: 50},
+					{Key: "second-variant", RolloutFlag: 20},
+					{Key: "third-variant", RolloutFlag: 20},
+					{Key: "fourth-variant", RolloutFlag: 5},
+					{Key: "fifth-variant", RolloutFlag: 5},
+				},
+			},
+		},
+	}
+
+	// An empty string means the distinct ID falls outside the 55% rollout
+	expected := []string{
+		"second-variant", "second-variant", "first-variant", "", "",
+		"second-variant", "first-variant", "", "", "",
+		"first-variant", "third-variant", "", "first-variant", "second-variant",
+	}
+
+	for i, want := range expected {
+		result := Evaluate(flag, Context{DistinctID: fmt.Sprintf("distinct_id_%d", i)})
+		assert.Equal(t, want != "", result.Match, "distinct_id_%d", i)
+		assert.Equal(t, want, result.Variant, "distinct_id_%d", i)
+	}
+}
+
+func TestHash_KnownValues(t *testing.T) {
+	tests := []struct {
+		key        string
+		distinctID string
+		salt       string
+		want       float64
+	}{
+		{key: "some-key", distinctID: "some-distinct-id", want: 0.4651102481168876},
+		{key: "holdout-flag", distinctID: "distinct_id_0", want: 0.85778019154552},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			assert.InDelta(t, tt.want, Hash(tt.key, tt.distinctID, tt.salt), 1e-12)
+		})
+	}
+}
+
+func TestEvaluate_Explanation(t *testing.T) {
+	flag := models.PostHogFeatureFlag{
+		Key:    "beta",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{
+				{
+					Properties:        []models.PostHogProperty{{Key: "plan", Operator: "exact", Value: "pro"}},
+					RolloutPercentage: intPtr(100),
+				},
+				{
+					Properties: []models.PostHogProperty{{Key: "id", Type: "cohort", Value: 7.0}},
+				},
+			},
+		},
+	}
+
+	matched := Evaluate(flag, Context{DistinctID: "user-1", Properties: map[string]interface{}{"plan": "pro"}})
+	assert.Equal(t, "condition 0 matched, 1 property filter(s) satisfied", matched.Explanation)
+
+	missed := Evaluate(flag, Context{DistinctID: "user-1", Properties: map[string]interface{}{"plan": "free"}})
+	assert.Contains(t, missed.Explanation, `condition 0: property "plan" (free) does not satisfy exact pro`)
+	assert.Contains(t, missed.Explanation, "condition 1: cohort 7 cannot be evaluated locally")
+
+	flag.Active = false
+	assert.Equal(t, "flag is inactive", Evaluate(flag, Context{DistinctID: "user-1"}).Explanation)
+}
@@ -0,0 +1,212 @@
+package evaluation
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+// now is overridden in tests to make relative date filters deterministic
+var now = time.Now
+
+var relativeDatePattern = regexp.MustCompile(`^-?(\d+)([hdwmy])$`)
+
+// matchesProperties reports whether every property filter in a condition matches.
+// When a filter fails it is returned so callers can explain the outcome.
+func matchesProperties(filters []models.PostHogProperty, properties map[string]interface{}) (bool, *models.PostHogProperty) {
+	for i := range filters {
+		if !matchProperty(filters[i], properties) {
+			return false, &filters[i]
+		}
+	}
+	return true, nil
+}
+
+// matchProperty evaluates a single PostHog property filter against person properties
+func matchProperty(filter models.PostHogProperty, properties map[string]interface{}) bool {
+	// Cohort membership is resolved by PostHog and cannot be evaluated from context alone
+	if filter.Type == "cohort" {
+		return false
+	}
+
+	value, present := properties[filter.Key]
+
+	switch filter.Operator {
+	case "is_set":
+		return present
+	case "is_not_set":
+		return !present
+	}
+
+	if !present {
+		return false
+	}
+
+	switch filter.Operator {
+	case "", "exact":
+		return matchesAny(filter.Value, value)
+	case "is_not":
+		return !matchesAny(filter.Value, value)
+	case "icontains":
+		return strings.Contains(strings.ToLower(stringify(value)), strings.ToLower(stringify(filter.Value)))
+	case "not_icontains":
+		return !strings.Contains(strings.ToLower(stringify(value)), strings.ToLower(stringify(filter.Value)))
+	case "regex":
+		return matchesRegex(filter.Value, value)
+	case "not_regex":
+		return !matchesRegex(filter.Value, value)
+	case "gt", "gte", "lt", "lte":
+		return compareOrdered(filter.Operator, value, filter.Value)
+	case "is_date_before", "is_date_after", "is_date_exact":
+		return compareDates(filter.Operator, value, filter.Value)
+	default:
+		return false
+	}
+}
+
+// matchesAny compares case-insensitively against a single expected value or any
+// entry of a list, which is how PostHog stores multi-value exact filters
+func matchesAny(expected, actual interface{}) bool {
+	actualStr := strings.ToLower(stringify(actual))
+
+	if list, ok := expected.([]interface{}); ok {
+		for _, candidate := range list {
+			if strings.ToLower(stringify(candidate)) == actualStr {
+				return true
+			}
+		}
+		return false
+	}
+
+	return strings.ToLower(stringify(expected)) == actualStr
+}
+
+func matchesRegex(pattern, actual interface{}) bool {
+	re, err := regexp.Compile(stringify(pattern))
+	if err != nil {
+		return false
+	}
+	return re.MatchString(stringify(actual))
+}
+
+// compareOrdered compares numerically when both sides are numbers and falls back
+// to lexical comparison otherwise, mirroring PostHog's SDKs
+func compareOrdered(operator string, actual, expected interface{}) bool {
+	actualNum, actualErr := strconv.ParseFloat(stringify(actual), 64)
+	expectedNum, expectedErr := strconv.ParseFloat(stringify(expected), 64)
+
+	var cmp int
+	if actualErr == nil && expectedErr == nil {
+		switch {
+		case actualNum < expectedNum:
+			cmp = -1
+		case actualNum > expectedNum:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(stringify(actual), stringify(expected))
+	}
+
+	switch operator {
+	case "gt":
+		return cmp > 0
+	case "gte":
+		return cmp >= 0
+	case "lt":
+		return cmp < 0
+	case "lte":
+		return cmp <= 0
+	}
+	return false
+}
+
+func compareDates(operator string, actual, expected interface{}) bool {
+	actualDate, ok := parseDate(actual)
+	if !ok {
+		return false
+	}
+	expectedDate, ok := parseFilterDate(expected)
+	if !ok {
+		return false
+	}
+
+	switch operator {
+	case "is_date_before":
+		return actualDate.Before(expectedDate)
+	case "is_date_after":
+		return actualDate.After(expectedDate)
+	case "is_date_exact":
+		y1, m1, d1 := actualDate.UTC().Date()
+		y2, m2, d2 := expectedDate.UTC().Date()
+		return y1 == y2 && m1 == m2 && d1 == d2
+	}
+	return false
+}
+
+// parseFilterDate accepts PostHog's relative dates ("-7d", "-2w", "-1m") as well as absolute dates
+func parseFilterDate(value interface{}) (time.Time, bool) {
+	raw := strings.TrimSpace(stringify(value))
+	if match := relativeDatePattern.FindStringSubmatch(raw); match != nil {
+		amount, err := strconv.Atoi(match[1])
+		if err != nil {
+			return time.Time{}, false
+		}
+		current := now()
+		switch match[2] {
+		case "h":
+			return current.Add(-time.Duration(amount) * time.Hour), true
+		case "d":
+			return current.AddDate(0, 0, -amount), true
+		case "w":
+			return current.AddDate(0, 0, -7*amount), true
+		case "m":
+			return current.AddDate(0, -amount, 0), true
+		case "y":
+			return current.AddDate(-amount, 0, 0), true
+		}
+	}
+	return parseDate(value)
+}
+
+// parseDate reads RFC3339 timestamps, plain dates and unix timestamps in seconds
+func parseDate(value interface{}) (time.Time, bool) {
+	if number, ok := value.(float64); ok {
+		return time.Unix(int64(number), 0), true
+	}
+
+	raw := strings.TrimSpace(stringify(value))
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if parsed, err := time.Parse(layout, raw); err == nil {
+			return parsed, true
+		}
+	}
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(seconds, 0), true
+	}
+	return time.Time{}, false
+}
+
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconvFloat(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// strconvFloat renders whole numbers without a decimal point so that 42 and "42" compare equal
+func strconvFloat(v float64) string {
+	if v == float64(int64(v)) {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return fmt.Sprint(v)
+}
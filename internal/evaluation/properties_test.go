@@ -0,0 +1,71 @@
+package evaluation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchProperty_Operators(t *testing.T) {
+	fixedNow := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return fixedNow }
+	t.Cleanup(func() { now = time.Now })
+
+	tests := []struct {
+		name     string
+		filter   models.PostHogProperty
+		property interface{}
+		match    bool
+	}{
+		{name: "exact scalar", filter: models.PostHogProperty{Operator: "exact", Value: "Pro"}, property: "pro", match: true},
+		{name: "exact list", filter: models.PostHogProperty{Operator: "exact", Value: []interface{}{"a", "b"}}, property: "b", match: true},
+		{name: "exact number", filter: models.PostHogProperty{Operator: "exact", Value: "42"}, property: 42.0, match: true},
+		{name: "empty operator is exact", filter: models.PostHogProperty{Value: "x"}, property: "y", match: false},
+		{name: "is_not", filter: models.PostHogProperty{Operator: "is_not", Value: "free"}, property: "pro", match: true},
+		{name: "icontains", filter: models.PostHogProperty{Operator: "icontains", Value: "EXAMPLE"}, property: "jane@example.com", match: true},
+		{name: "not_icontains", filter: models.PostHogProperty{Operator: "not_icontains", Value: "example"}, property: "jane@example.com", match: false},
+		{name: "regex", filter: models.PostHogProperty{Operator: "regex", Value: `^user-\d+$`}, property: "user-12", match: true},
+		{name: "invalid regex never matches", filter: models.PostHogProperty{Operator: "regex", Value: `(`}, property: "(", match: false},
+		{name: "not_regex", filter: models.PostHogProperty{Operator: "not_regex", Value: `^admin`}, property: "user", match: true},
+		{name: "gt numeric", filter: models.PostHogProperty{Operator: "gt", Value: "9"}, property: 10.0, match: true},
+		{name: "gt numeric not lexical", filter: models.PostHogProperty{Operator: "gt", Value: 9.0}, property: "10", match: true},
+		{name: "gte equal", filter: models.PostHogProperty{Operator: "gte", Value: 5.0}, property: 5.0, match: true},
+		{name: "lt numeric", filter: models.PostHogProperty{Operator: "lt", Value: "5"}, property: 7.0, match: false},
+		{name: "lte string fallback", filter: models.PostHogProperty{Operator: "lte", Value: "beta"}, property: "alpha", match: true},
+		{name: "date before absolute", filter: models.PostHogProperty{Operator: "is_date_before", Value: "2024-01-01"}, property: "2023-12-31T10:00:00Z", match: true},
+		{name: "date after absolute", filter: models.PostHogProperty{Operator: "is_date_after", Value: "2024-01-01"}, property: "2023-12-31", match: false},
+		{name: "date after relative", filter: models.PostHogProperty{Operator: "is_date_after", Value: "-7d"}, property: "2024-06-10T00:00:00Z", match: true},
+		{name: "date before relative month", filter: models.PostHogProperty{Operator: "is_date_before", Value: "-1m"}, property: "2024-05-20", match: false},
+		{name: "date after relative hours", filter: models.PostHogProperty{Operator: "is_date_after", Value: "-2h"}, property: "2024-06-15T11:00:00Z", match: true},
+		{name: "date from unix timestamp", filter: models.PostHogProperty{Operator: "is_date_before", Value: "-1y"}, property: float64(fixedNow.AddDate(-2, 0, 0).Unix()), match: true},
+		{name: "date exact", filter: models.PostHogProperty{Operator: "is_date_exact", Value: "2024-06-15"}, property: "2024-06-15T08:30:00Z", match: true},
+		{name: "unparseable date", filter: models.PostHogProperty{Operator: "is_date_before", Value: "-7d"}, property: "yesterday", match: false},
+		{name: "unknown operator", filter: models.PostHogProperty{Operator: "in_cohort", Value: "x"}, property: "x", match: false},
+		{name: "cohort filter", filter: models.PostHogProperty{Type: "cohort", Value: 12.0}, property: "x", match: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Key = "prop"
+			properties := map[string]interface{}{"prop": tt.property}
+
+			assert.Equal(t, tt.match, matchProperty(tt.filter, properties))
+		})
+	}
+}
+
+func TestMatchProperty_PresenceOperators(t *testing.T) {
+	set := models.PostHogProperty{Key: "email", Operator: "is_set"}
+	notSet := models.PostHogProperty{Key: "email", Operator: "is_not_set"}
+	present := map[string]interface{}{"email": "jane@example.com"}
+
+	assert.True(t, matchProperty(set, present))
+	assert.False(t, matchProperty(set, nil))
+	assert.False(t, matchProperty(notSet, present))
+	assert.True(t, matchProperty(notSet, nil))
+
+	// Value operators never match a missing property
+	assert.False(t, matchProperty(models.PostHogProperty{Key: "email", Operator: "is_not", Value: "x"}, nil))
+}
@@ -0,0 +1,240 @@
+// Package flagd converts PostHog feature flags into flagd flag configurations and
+// serves them to flagd over its sync.v1 gRPC protocol.
+package flagd
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/transformer"
+)
+
+// SchemaURL is the flagd flag definition schema the configuration conforms to
+const SchemaURL = "https://flagd.dev/schema/v0/flags.json"
+
+const (
+	variantOn  = "on"
+	variantOff = "off"
+
+	// rolloutIn and rolloutOut name the buckets of a condition's rollout split
+	rolloutIn  = "in"
+	rolloutOut = "out"
+)
+
+// Configuration is a flagd flag definition document
+type Configuration struct {
+	Schema string          `json:"$schema,omitempty"`
+	Flags  map[string]Flag `json:"flags"`
+}
+
+// Flag is a single flagd flag definition
+type Flag struct {
+	State          models.FlagState       `json:"state"`
+	Variants       map[string]interface{} `json:"variants"`
+	DefaultVariant string                 `json:"defaultVariant"`
+	Targeting      interface{}            `json:"targeting,omitempty"`
+}
+
+// FromPostHog builds a flagd configuration from PostHog flags, skipping deleted ones.
+// Variant values follow the OpenFeature manifest so every API reports the same values.
+func FromPostHog(posthogFlags []models.PostHogFeatureFlag, cfg config.TypeCoercionConfig) Configuration {
+	configuration := Configuration{
+		Schema: SchemaURL,
+		Flags:  make(map[string]Flag, len(posthogFlags)),
+	}
+
+	for _, phFlag := range posthogFlags {
+		if phFlag.Deleted {
+			continue
+		}
+		configuration.Flags[phFlag.Key] = convertFlag(phFlag, transformer.PostHogToOpenFeatureFlag(phFlag, cfg))
+	}
+
+	return configuration
+}
+
+// Marshal encodes the configuration as the JSON document flagd expects
+func Marshal(configuration Configuration) (string, error) {
+	body, err := json.Marshal(configuration)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func convertFlag(phFlag models.PostHogFeatureFlag, manifestFlag models.ManifestFlag) Flag {
+	flag := Flag{State: manifestFlag.State}
+
+	switch {
+	case phFlag.Filters.Multivariate != nil && len(phFlag.Filters.Multivariate.Variants) > 0:
+		// Like the manifest, the default is the tagged or else the first PostHog variant
+		defaultVariant := transformer.DefaultVariant(phFlag)
+		flag.Variants = manifestVariants(manifestFlag)
+		flag.DefaultVariant, flag.Targeting = targeting(phFlag, defaultVariant, multivariateOutcome(phFlag), nil)
+	case manifestFlag.Type != models.FlagTypeBoolean && len(manifestFlag.Variants) > 0:
+		// Payload-backed flags carry a single value; targeting still decides whether
+		// it is a targeting match or the default
+		flag.Variants = manifestVariants(manifestFlag)
+		payloadVariant := sortedVariantKeys(flag.Variants)[0]
+		flag.DefaultVariant, flag.Targeting = targeting(phFlag, payloadVariant, func(models.PostHogFilterGroup) interface{} {
+			return payloadVariant
+		}, nil)
+	default:
+		flag.Variants = map[string]interface{}{variantOn: true, variantOff: false}
+		flag.DefaultVariant, flag.Targeting = targeting(phFlag, variantOff, booleanOutcome, variantOff)
+	}
+
+	return flag
+}
+
+// targeting turns PostHog release conditions into a JSONLogic if/else chain that
+// returns a variant name. Conditions are tried in PostHog's order: a subject matching
+// a condition's properties but outside its rollout falls through to the next one, and
+// the first condition that matches everyone ends the chain. Subjects matching no
+// condition receive fallback, or the default variant when it is nil. A chain that
+// always yields the same variant is folded into the default variant instead.
+func targeting(phFlag models.PostHogFeatureFlag, defaultVariant string, outcome func(models.PostHogFilterGroup) interface{}, fallback interface{}) (string, interface{}) {
+	var branches []interface{}
+	otherwise := fallback
+
+	for _, group := range orderedGroups(phFlag.Filters.Groups) {
+		condition, ok := conditionLogic(group)
+		if !ok {
+			continue
+		}
+		if condition == nil {
+			otherwise = outcome(group)
+			break
+		}
+		branches = append(branches, condition, outcome(group))
+	}
+
+	if len(branches) == 0 {
+		if variant, ok := otherwise.(string); ok {
+			return variant, nil
+		}
+		if otherwise == nil {
+			return defaultVariant, nil
+		}
+		return defaultVariant, otherwise
+	}
+
+	if otherwise != nil {
+		branches = append(branches, otherwise)
+	}
+	return defaultVariant, map[string]interface{}{"if": branches}
+}
+
+// conditionLogic combines a condition's property filters and rollout into the
+// JSONLogic rule a subject must pass to match it. It returns nil for a condition that
+// matches everyone and false for one that can match nobody.
+func conditionLogic(group models.PostHogFilterGroup) (interface{}, bool) {
+	rollout := rolloutPercentage(group)
+	if rollout <= 0 {
+		return nil, false
+	}
+
+	rules := make([]interface{}, 0, len(group.Properties)+1)
+	for _, property := range group.Properties {
+		rules = append(rules, propertyLogic(property))
+	}
+	if rollout < 100 {
+		rules = append(rules, rolloutLogic(rollout))
+	}
+
+	switch len(rules) {
+	case 0:
+		return nil, true
+	case 1:
+		return rules[0], true
+	}
+	return map[string]interface{}{"and": rules}, true
+}
+
+// rolloutLogic places the rollout percentage of subjects in a condition. Every
+// condition buckets on the flag key and targeting key, like PostHog's rollout hash,
+// so a subject inside a 50% rollout is also inside every larger one.
+func rolloutLogic(rollout int) interface{} {
+	bucket := fractional(nil, []interface{}{rolloutIn, rollout}, []interface{}{rolloutOut, 100 - rollout})
+	return map[string]interface{}{"==": []interface{}{bucket, rolloutIn}}
+}
+
+// booleanOutcome serves "on" to subjects matching a condition
+func booleanOutcome(models.PostHogFilterGroup) interface{} {
+	return variantOn
+}
+
+// multivariateOutcome returns the condition's variant override or the weighted split
+func multivariateOutcome(phFlag models.PostHogFeatureFlag) func(models.PostHogFilterGroup) interface{} {
+	return func(group models.PostHogFilterGroup) interface{} {
+		if group.Variant != nil && hasVariant(phFlag.Filters.Multivariate, *group.Variant) {
+			return *group.Variant
+		}
+
+		buckets := make([]interface{}, 0, len(phFlag.Filters.Multivariate.Variants))
+		for _, variant := range phFlag.Filters.Multivariate.Variants {
+			buckets = append(buckets, []interface{}{variant.Key, variant.RolloutFlag})
+		}
+		return fractional(variantBucketing, buckets...)
+	}
+}
+
+// variantBucketing salts the variant split, as PostHog salts its variant hash, so the
+// variant a subject receives does not depend on where it fell in the rollout
+var variantBucketing = map[string]interface{}{
+	"cat": []interface{}{map[string]interface{}{"var": "$flagd.flagKey"}, map[string]interface{}{"var": "targetingKey"}, "variant"},
+}
+
+// fractional builds flagd's deterministic percentage split. Without a bucketing
+// expression flagd buckets on the flag key and targeting key.
+func fractional(bucketing interface{}, buckets ...interface{}) interface{} {
+	if bucketing != nil {
+		buckets = append([]interface{}{bucketing}, buckets...)
+	}
+	return map[string]interface{}{"fractional": buckets}
+}
+
+func rolloutPercentage(group models.PostHogFilterGroup) int {
+	if group.RolloutPercentage == nil {
+		return 100
+	}
+	return *group.RolloutPercentage
+}
+
+// orderedGroups puts conditions with a variant override first, as PostHog evaluates them
+func orderedGroups(groups []models.PostHogFilterGroup) []models.PostHogFilterGroup {
+	ordered := make([]models.PostHogFilterGroup, len(groups))
+	copy(ordered, groups)
+	sort.SliceStable(ordered, func(a, b int) bool {
+		return ordered[a].Variant != nil && ordered[b].Variant == nil
+	})
+	return ordered
+}
+
+func hasVariant(multivariate *models.PostHogMultivariate, key string) bool {
+	for _, variant := range multivariate.Variants {
+		if variant.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func manifestVariants(flag models.ManifestFlag) map[string]interface{} {
+	variants := make(map[string]interface{}, len(flag.Variants))
+	for key, variant := range flag.Variants {
+		variants[key] = variant.Value
+	}
+	return variants
+}
+
+func sortedVariantKeys(variants map[string]interface{}) []string {
+	keys := make([]string, 0, len(variants))
+	for key := range variants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
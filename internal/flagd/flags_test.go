@@ -0,0 +1,348 @@
+package flagd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// roundTrip encodes a value and decodes it back so assertions can compare plain JSON
+func roundTrip(t *testing.T, value interface{}) interface{} {
+	t.Helper()
+	body, err := json.Marshal(value)
+	require.NoError(t, err)
+	var decoded interface{}
+	require.NoError(t, json.Unmarshal(body, &decoded))
+	return decoded
+}
+
+// rolloutRule is the JSONLogic rule placing a percentage of subjects in a condition
+func rolloutRule(percentage float64) interface{} {
+	return map[string]interface{}{"==": []interface{}{
+		map[string]interface{}{"fractional": []interface{}{[]interface{}{"in", percentage}, []interface{}{"out", 100 - percentage}}},
+		"in",
+	}}
+}
+
+// variantSplit is the salted fractional split across weighted variants
+func variantSplit(buckets ...interface{}) interface{} {
+	bucketing := map[string]interface{}{"cat": []interface{}{
+		map[string]interface{}{"var": "$flagd.flagKey"},
+		map[string]interface{}{"var": "targetingKey"},
+		"variant",
+	}}
+	return map[string]interface{}{"fractional": append([]interface{}{bucketing}, buckets...)}
+}
+
+func TestFromPostHog_BooleanFlags(t *testing.T) {
+	configuration := FromPostHog([]models.PostHogFeatureFlag{
+		{
+			Key:    "everyone",
+			Active: true,
+			Filters: models.PostHogFilters{
+				Groups: []models.PostHogFilterGroup{{RolloutPercentage: intPtr(100)}},
+			},
+		},
+		{
+			Key:    "nobody",
+			Active: true,
+			Filters: models.PostHogFilters{
+				Groups: []models.PostHogFilterGroup{{RolloutPercentage: intPtr(0)}},
+			},
+		},
+		{
+			Key:    "half",
+			Active: true,
+			Filters: models.PostHogFilters{
+				Groups: []models.PostHogFilterGroup{{RolloutPercentage: intPtr(50)}},
+			},
+		},
+		{Key: "inactive", Active: false},
+		{Key: "gone", Active: true, Deleted: true},
+	}, config.TypeCoercionConfig{})
+
+	require.Len(t, configuration.Flags, 4)
+	assert.Equal(t, SchemaURL, configuration.Schema)
+
+	everyone := configuration.Flags["everyone"]
+	assert.Equal(t, models.FlagStateEnabled, everyone.State)
+	assert.Equal(t, map[string]interface{}{"on": true, "off": false}, everyone.Variants)
+	assert.Equal(t, "on", everyone.DefaultVariant)
+	assert.Nil(t, everyone.Targeting)
+
+	assert.Equal(t, "off", configuration.Flags["nobody"].DefaultVariant)
+	assert.Nil(t, configuration.Flags["nobody"].Targeting)
+
+	half := configuration.Flags["half"]
+	assert.Equal(t, "off", half.DefaultVariant)
+	assert.Equal(t, map[string]interface{}{
+		"if": []interface{}{rolloutRule(50), "on", "off"},
+	}, roundTrip(t, half.Targeting))
+
+	assert.Equal(t, models.FlagStateDisabled, configuration.Flags["inactive"].State)
+}
+
+func TestFromPostHog_BooleanTargeting(t *testing.T) {
+	configuration := FromPostHog([]models.PostHogFeatureFlag{{
+		Key:    "beta",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{
+				{
+					Properties: []models.PostHogProperty{
+						{Key: "email", Type: "person", Operator: "icontains", Value: "@example.com"},
+						{Key: "plan", Type: "person", Operator: "exact", Value: []interface{}{"pro", "team"}},
+					},
+					RolloutPercentage: intPtr(100),
+				},
+				{
+					Properties:        []models.PostHogProperty{{Key: "beta", Type: "person", Operator: "is_set"}},
+					RolloutPercentage: intPtr(25),
+				},
+				{RolloutPercentage: intPtr(0)},
+			},
+		},
+	}}, config.TypeCoercionConfig{})
+
+	flag := configuration.Flags["beta"]
+	assert.Equal(t, "off", flag.DefaultVariant)
+	assert.Equal(t, map[string]interface{}{
+		"if": []interface{}{
+			map[string]interface{}{"and": []interface{}{
+				false,
+				map[string]interface{}{"in": []interface{}{map[string]interface{}{"var": "plan"}, []interface{}{"pro", "team"}}},
+			}},
+			"on",
+			map[string]interface{}{"and": []interface{}{
+				map[string]interface{}{"!=": []interface{}{map[string]interface{}{"var": "beta"}, nil}},
+				rolloutRule(25),
+			}},
+			"on",
+			"off",
+		},
+	}, roundTrip(t, flag.Targeting))
+}
+
+func TestFromPostHog_MultivariateFlag(t *testing.T) {
+	configuration := FromPostHog([]models.PostHogFeatureFlag{{
+		Key:    "checkout",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{
+				{RolloutPercentage: intPtr(100)},
+				{
+					Properties:        []models.PostHogProperty{{Key: "country", Type: "person", Operator: "exact", Value: "NL"}},
+					RolloutPercentage: intPtr(100),
+					Variant:           stringPtr("treatment"),
+				},
+			},
+			Multivariate: &models.PostHogMultivariate{Variants: []models.PostHogVariant{
+				{Key: "control", RolloutFlag: 70},
+				{Key: "treatment", RolloutFlag: 30},
+			}},
+			Payloads: map[string]string{"treatment": `{"color":"green"}`},
+		},
+	}}, config.TypeCoercionConfig{})
+
+	flag := configuration.Flags["checkout"]
+	assert.Equal(t, "control", flag.DefaultVariant)
+	assert.Equal(t, map[string]interface{}{
+		"control":   "control",
+		"treatment": map[string]interface{}{"color": "green"},
+	}, flag.Variants)
+
+	// The variant override is evaluated before the catch-all condition, as in PostHog
+	assert.Equal(t, map[string]interface{}{
+		"if": []interface{}{
+			map[string]interface{}{"==": []interface{}{map[string]interface{}{"var": "country"}, "NL"}},
+			"treatment",
+			variantSplit([]interface{}{"control", 70.0}, []interface{}{"treatment", 30.0}),
+		},
+	}, roundTrip(t, flag.Targeting))
+}
+
+func TestFromPostHog_TaggedDefaultVariant(t *testing.T) {
+	configuration := FromPostHog([]models.PostHogFeatureFlag{{
+		Key:    "checkout",
+		Active: true,
+		Tags:   []string{"default-variant:treatment"},
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: intPtr(0)}},
+			Multivariate: &models.PostHogMultivariate{Variants: []models.PostHogVariant{
+				{Key: "control", RolloutFlag: 70},
+				{Key: "treatment", RolloutFlag: 30},
+			}},
+		},
+	}}, config.TypeCoercionConfig{})
+
+	assert.Equal(t, "treatment", configuration.Flags["checkout"].DefaultVariant)
+}
+
+func TestFromPostHog_MultivariatePartialRollout(t *testing.T) {
+	configuration := FromPostHog([]models.PostHogFeatureFlag{{
+		Key:    "checkout",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: intPtr(40)}},
+			Multivariate: &models.PostHogMultivariate{Variants: []models.PostHogVariant{
+				{Key: "a", RolloutFlag: 50},
+				{Key: "b", RolloutFlag: 50},
+			}},
+		},
+	}}, config.TypeCoercionConfig{})
+
+	flag := configuration.Flags["checkout"]
+	assert.Equal(t, "a", flag.DefaultVariant)
+	// Subjects outside the rollout match no condition and receive the default variant
+	assert.Equal(t, map[string]interface{}{"if": []interface{}{
+		rolloutRule(40),
+		variantSplit([]interface{}{"a", 50.0}, []interface{}{"b", 50.0}),
+	}}, roundTrip(t, flag.Targeting))
+}
+
+func TestFromPostHog_RolloutFallsThrough(t *testing.T) {
+	configuration := FromPostHog([]models.PostHogFeatureFlag{{
+		Key:    "new-pricing",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{
+				{
+					Properties:        []models.PostHogProperty{{Key: "plan", Type: "person", Operator: "exact", Value: "pro"}},
+					RolloutPercentage: intPtr(50),
+				},
+				{RolloutPercentage: intPtr(20)},
+			},
+		},
+	}}, config.TypeCoercionConfig{})
+
+	// A pro subject outside the 50% rollout still gets the 20% rollout of everyone
+	assert.Equal(t, map[string]interface{}{
+		"if": []interface{}{
+			map[string]interface{}{"and": []interface{}{
+				map[string]interface{}{"==": []interface{}{map[string]interface{}{"var": "plan"}, "pro"}},
+				rolloutRule(50),
+			}},
+			"on",
+			rolloutRule(20),
+			"on",
+			"off",
+		},
+	}, roundTrip(t, configuration.Flags["new-pricing"].Targeting))
+}
+
+func TestFromPostHog_PayloadFlag(t *testing.T) {
+	configuration := FromPostHog([]models.PostHogFeatureFlag{{
+		Key:    "settings",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups:   []models.PostHogFilterGroup{{RolloutPercentage: intPtr(100)}},
+			Payloads: map[string]string{"true": `{"theme":"dark"}`},
+		},
+	}}, config.TypeCoercionConfig{})
+
+	flag := configuration.Flags["settings"]
+	assert.Equal(t, map[string]interface{}{"true": map[string]interface{}{"theme": "dark"}}, flag.Variants)
+	assert.Equal(t, "true", flag.DefaultVariant)
+	assert.Nil(t, flag.Targeting)
+}
+
+func TestFromPostHog_PayloadFlagTargeting(t *testing.T) {
+	configuration := FromPostHog([]models.PostHogFeatureFlag{{
+		Key:    "settings",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{
+				Properties:        []models.PostHogProperty{{Key: "plan", Type: "person", Operator: "exact", Value: "pro"}},
+				RolloutPercentage: intPtr(100),
+			}},
+			Payloads: map[string]string{"true": `{"theme":"dark"}`},
+		},
+	}}, config.TypeCoercionConfig{})
+
+	// Matching subjects are a targeting match; the rest fall back to the default variant
+	flag := configuration.Flags["settings"]
+	assert.Equal(t, "true", flag.DefaultVariant)
+	assert.Equal(t, map[string]interface{}{
+		"if": []interface{}{
+			map[string]interface{}{"==": []interface{}{map[string]interface{}{"var": "plan"}, "pro"}},
+			"true",
+		},
+	}, roundTrip(t, flag.Targeting))
+}
+
+// flagd's "in" would not find "@example.com" in a subject's "Jane@EXAMPLE.com" although
+// PostHog matches it, so the condition is dropped rather than answered differently
+func TestFromPostHog_CaseInsensitiveContains(t *testing.T) {
+	configuration := FromPostHog([]models.PostHogFeatureFlag{{
+		Key:    "staff",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{
+				{
+					Properties:        []models.PostHogProperty{{Key: "email", Type: "person", Operator: "icontains", Value: "@Example.com"}},
+					RolloutPercentage: intPtr(100),
+				},
+				{RolloutPercentage: intPtr(0)},
+			},
+		},
+	}}, config.TypeCoercionConfig{})
+
+	flag := configuration.Flags["staff"]
+	assert.Equal(t, "off", flag.DefaultVariant)
+	assert.Equal(t, map[string]interface{}{"if": []interface{}{false, "on", "off"}}, roundTrip(t, flag.Targeting))
+}
+
+func TestPropertyLogic(t *testing.T) {
+	attribute := map[string]interface{}{"var": "age"}
+
+	tests := []struct {
+		name     string
+		property models.PostHogProperty
+		expected interface{}
+	}{
+		{"exact", models.PostHogProperty{Key: "age", Operator: "exact", Value: 30.0}, map[string]interface{}{"==": []interface{}{attribute, 30.0}}},
+		{"default operator", models.PostHogProperty{Key: "age", Value: 30.0}, map[string]interface{}{"==": []interface{}{attribute, 30.0}}},
+		{"is_not", models.PostHogProperty{Key: "age", Operator: "is_not", Value: 30.0}, map[string]interface{}{"!": []interface{}{map[string]interface{}{"==": []interface{}{attribute, 30.0}}}}},
+		{"gte", models.PostHogProperty{Key: "age", Operator: "gte", Value: 18.0}, map[string]interface{}{">=": []interface{}{attribute, 18.0}}},
+		{"lt", models.PostHogProperty{Key: "age", Operator: "lt", Value: 65.0}, map[string]interface{}{"<": []interface{}{attribute, 65.0}}},
+		{"is_not_set", models.PostHogProperty{Key: "age", Operator: "is_not_set"}, map[string]interface{}{"==": []interface{}{attribute, nil}}},
+		{"regex never matches", models.PostHogProperty{Key: "age", Operator: "regex", Value: "^1"}, false},
+		{"cohort never matches", models.PostHogProperty{Key: "id", Type: "cohort", Value: 4.0}, false},
+		{"icontains never matches", models.PostHogProperty{Key: "email", Operator: "icontains", Value: "@example.com"}, false},
+		{"not_icontains never matches", models.PostHogProperty{Key: "email", Operator: "not_icontains", Value: "@example.com"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, propertyLogic(tt.property))
+		})
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	body, err := Marshal(Configuration{
+		Schema: SchemaURL,
+		Flags: map[string]Flag{"a": {
+			State:          models.FlagStateEnabled,
+			Variants:       map[string]interface{}{"on": true, "off": false},
+			DefaultVariant: "on",
+		}},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://flagd.dev/schema/v0/flags.json",
+		"flags": {"a": {"state": "ENABLED", "variants": {"on": true, "off": false}, "defaultVariant": "on"}}
+	}`, body)
+}
@@ -0,0 +1,58 @@
+package flagd
+
+import (
+	"log/slog"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+// propertyLogic translates a PostHog property filter to JSONLogic. Cohorts, regular
+// expressions, date comparisons and case-insensitive contains have no flagd equivalent
+// and never match, the same way the local evaluator treats cohorts.
+func propertyLogic(property models.PostHogProperty) interface{} {
+	if property.Type == "cohort" {
+		return false
+	}
+
+	attribute := map[string]interface{}{"var": property.Key}
+
+	switch property.Operator {
+	case "", "exact":
+		return equalsLogic(attribute, property.Value)
+	case "is_not":
+		return not(equalsLogic(attribute, property.Value))
+	case "icontains", "not_icontains":
+		// flagd's "in" is case-sensitive and JSONLogic cannot lowercase the context
+		// value, so the rule would give some subjects the opposite answer to PostHog
+		slog.Warn("flagd cannot compare case-insensitively, condition never matches",
+			"property", property.Key, "operator", property.Operator)
+		return false
+	case "gt":
+		return map[string]interface{}{">": []interface{}{attribute, property.Value}}
+	case "gte":
+		return map[string]interface{}{">=": []interface{}{attribute, property.Value}}
+	case "lt":
+		return map[string]interface{}{"<": []interface{}{attribute, property.Value}}
+	case "lte":
+		return map[string]interface{}{"<=": []interface{}{attribute, property.Value}}
+	case "is_set":
+		return map[string]interface{}{"!=": []interface{}{attribute, nil}}
+	case "is_not_set":
+		return map[string]interface{}{"==": []interface{}{attribute, nil}}
+	default:
+		return false
+	}
+}
+
+// equalsLogic matches a single value, or any entry of a list, which is how PostHog
+// stores multi-value exact filters
+func equalsLogic(attribute, value interface{}) interface{} {
+	if list, ok := value.([]interface{}); ok {
+		return map[string]interface{}{"in": []interface{}{attribute, list}}
+	}
+	return map[string]interface{}{"==": []interface{}{attribute, value}}
+}
+
+func not(rule interface{}) interface{} {
+	return map[string]interface{}{"!": []interface{}{rule}}
+}
@@ -0,0 +1,217 @@
+package flagd
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+
+	syncv1grpc "buf.build/gen/go/open-feature/flagd/grpc/go/flagd/sync/v1/syncv1grpc"
+	syncv1 "buf.build/gen/go/open-feature/flagd/protocolbuffers/go/flagd/sync/v1"
+	"github.com/openfeature/posthog-proxy/internal/auth"
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/jwtauth"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/openfeature/posthog-proxy/internal/stream"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements flagd's sync.v1 FlagSyncService on top of the PostHog client
+type Server struct {
+	syncv1grpc.UnimplementedFlagSyncServiceServer
+
+	client   posthog.ClientInterface
+	coercion config.TypeCoercionConfig
+	watcher  *stream.Watcher
+}
+
+// NewServer creates a sync server. The watcher tells SyncFlags streams when the
+// PostHog flags change so they can push a fresh configuration.
+func NewServer(client posthog.ClientInterface, coercion config.TypeCoercionConfig, watcher *stream.Watcher) *Server {
+	return &Server{
+		client:   client,
+		coercion: coercion,
+		watcher:  watcher,
+	}
+}
+
+// NewGRPCServer returns a gRPC server with the sync service registered behind the
+// proxy's bearer token authentication. The verifier, nil when JWT authentication is
+// off, lets flagd authenticate with a JWT as HTTP clients can.
+func NewGRPCServer(server *Server, cfg *config.Config, verifier *jwtauth.Verifier) *grpc.Server {
+	authn := authenticator{config: cfg, chain: auth.NewAuthenticator(cfg.Proxy.Auth, verifier)}
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(authn.unary),
+		grpc.StreamInterceptor(authn.stream),
+	)
+	syncv1grpc.RegisterFlagSyncServiceServer(grpcServer, server)
+	return grpcServer
+}
+
+// FetchAllFlags returns the current flag configuration
+func (s *Server) FetchAllFlags(ctx context.Context, _ *syncv1.FetchAllFlagsRequest) (*syncv1.FetchAllFlagsResponse, error) {
+	configuration, err := s.configuration(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &syncv1.FetchAllFlagsResponse{FlagConfiguration: configuration}, nil
+}
+
+// SyncFlags sends the current flag configuration and then a new one whenever the
+// PostHog flags change, until the client disconnects
+func (s *Server) SyncFlags(_ *syncv1.SyncFlagsRequest, srv grpc.ServerStreamingServer[syncv1.SyncFlagsResponse]) error {
+	ctx := srv.Context()
+
+	sub, err := s.subscribe()
+	if err != nil {
+		return err
+	}
+	defer func() { sub.Close() }()
+
+	var sent string
+	push := func() error {
+		configuration, err := s.configuration(ctx)
+		if err != nil {
+			return err
+		}
+		if configuration == sent {
+			return nil
+		}
+		if err := srv.Send(&syncv1.SyncFlagsResponse{FlagConfiguration: configuration}); err != nil {
+			return err
+		}
+		sent = configuration
+		return nil
+	}
+
+	if err := push(); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-sub.Events:
+			if !ok {
+				// The watcher drops subscribers that fall behind; start over from the current state
+				if sub, err = s.subscribe(); err != nil {
+					return err
+				}
+			}
+			drain(sub.Events)
+			if err := push(); err != nil {
+				slog.WarnContext(ctx, "Failed to push flagd configuration", "error", err)
+			}
+		}
+	}
+}
+
+// configuration fetches the PostHog flags and encodes them as a flagd configuration
+func (s *Server) configuration(ctx context.Context) (string, error) {
+	posthogFlags, err := s.client.GetFeatureFlags(ctx)
+	if err != nil {
+		return "", status.Errorf(codes.Unavailable, "failed to retrieve feature flags from PostHog: %v", err)
+	}
+
+	configuration, err := Marshal(FromPostHog(posthogFlags, s.coercion))
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "failed to encode flag configuration: %v", err)
+	}
+	return configuration, nil
+}
+
+func (s *Server) subscribe() (*stream.Subscription, error) {
+	sub, err := s.watcher.Subscribe("")
+	if errors.Is(err, stream.ErrNotReady) {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return sub, nil
+}
+
+// drain discards queued events; one configuration push covers all of them
+func drain(events <-chan stream.Event) {
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// authenticator checks the bearer token in the gRPC authorization metadata
+type authenticator struct {
+	config *config.Config
+	chain  *auth.Authenticator
+}
+
+func (a authenticator) unary(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a authenticator) stream(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.authorize(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize requires a token with the read capability unless running in insecure mode
+func (a authenticator) authorize(ctx context.Context) error {
+	if a.config.Proxy.InsecureMode {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return status.Error(codes.Unauthenticated, "authorization metadata is required")
+	}
+
+	token, found := strings.CutPrefix(values[0], "Bearer ")
+	if !found || token == "" {
+		return status.Error(codes.Unauthenticated, "invalid authorization metadata format")
+	}
+
+	var client string
+	if p, ok := peer.FromContext(ctx); ok {
+		client = p.Addr.String()
+		if host, _, err := net.SplitHostPort(client); err == nil {
+			client = host
+		}
+	}
+
+	caller, err := a.chain.Authenticate(ctx, token, client)
+	var throttled *auth.ThrottledError
+	if errors.As(err, &throttled) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if err != nil {
+		return status.Error(codes.Unauthenticated, "invalid authorization token: "+err.Error())
+	}
+	// flagd receives the whole flag set, which a token restricted to some flags may not see
+	if len(caller.Scopes) > 0 {
+		return status.Error(codes.PermissionDenied, "scoped tokens cannot sync flags")
+	}
+	for _, capability := range caller.Capabilities {
+		if capability == "read" {
+			return nil
+		}
+	}
+	return status.Error(codes.PermissionDenied, "insufficient permissions")
+}
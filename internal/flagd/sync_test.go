@@ -0,0 +1,240 @@
+package flagd
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	syncv1grpc "buf.build/gen/go/open-feature/flagd/grpc/go/flagd/sync/v1/syncv1grpc"
+	syncv1 "buf.build/gen/go/open-feature/flagd/protocolbuffers/go/flagd/sync/v1"
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/jwtauth"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/openfeature/posthog-proxy/internal/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func flag(key string, rollout int) models.PostHogFeatureFlag {
+	return models.PostHogFeatureFlag{
+		Key:    key,
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: intPtr(rollout)}},
+		},
+	}
+}
+
+// startServer serves the sync service over an in-memory connection
+func startServer(t *testing.T, cfg *config.Config, client posthog.ClientInterface, watcher *stream.Watcher, verifier *jwtauth.Verifier) syncv1grpc.FlagSyncServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := NewGRPCServer(NewServer(client, config.TypeCoercionConfig{}, watcher), cfg, verifier)
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return syncv1grpc.NewFlagSyncServiceClient(conn)
+}
+
+func decodeFlags(t *testing.T, configuration string) map[string]Flag {
+	t.Helper()
+	var decoded Configuration
+	require.NoError(t, json.Unmarshal([]byte(configuration), &decoded))
+	return decoded.Flags
+}
+
+func insecureConfig() *config.Config {
+	return &config.Config{Proxy: config.ProxyConfig{InsecureMode: true}}
+}
+
+func TestServer_FetchAllFlags(t *testing.T) {
+	client := new(posthog.MockClient)
+	client.On("GetFeatureFlags", mock.Anything).Return([]models.PostHogFeatureFlag{flag("a", 100)}, nil)
+
+	syncClient := startServer(t, insecureConfig(), client, nil, nil)
+
+	resp, err := syncClient.FetchAllFlags(context.Background(), &syncv1.FetchAllFlagsRequest{})
+	require.NoError(t, err)
+
+	flags := decodeFlags(t, resp.GetFlagConfiguration())
+	require.Contains(t, flags, "a")
+	assert.Equal(t, "on", flags["a"].DefaultVariant)
+}
+
+func TestServer_FetchAllFlagsPostHogError(t *testing.T) {
+	client := new(posthog.MockClient)
+	client.On("GetFeatureFlags", mock.Anything).Return(nil, errors.New("boom"))
+
+	syncClient := startServer(t, insecureConfig(), client, nil, nil)
+
+	_, err := syncClient.FetchAllFlags(context.Background(), &syncv1.FetchAllFlagsRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestServer_SyncFlagsPushesChanges(t *testing.T) {
+	client := new(posthog.MockClient)
+	client.On("GetFeatureFlags", mock.Anything).Return([]models.PostHogFeatureFlag{flag("a", 100)}, nil).Times(2)
+	client.On("GetFeatureFlags", mock.Anything).Return([]models.PostHogFeatureFlag{flag("a", 0)}, nil)
+
+	watcher := stream.NewWatcher(client, config.TypeCoercionConfig{}, time.Hour, 0)
+	require.NoError(t, watcher.Refresh(context.Background()))
+
+	syncClient := startServer(t, insecureConfig(), client, watcher, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	syncStream, err := syncClient.SyncFlags(ctx, &syncv1.SyncFlagsRequest{})
+	require.NoError(t, err)
+
+	first, err := syncStream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "on", decodeFlags(t, first.GetFlagConfiguration())["a"].DefaultVariant)
+
+	// The next poll sees the flag switched off and the stream pushes a new configuration
+	require.NoError(t, watcher.Refresh(context.Background()))
+
+	second, err := syncStream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "off", decodeFlags(t, second.GetFlagConfiguration())["a"].DefaultVariant)
+}
+
+func TestServer_SyncFlagsBeforeFirstPoll(t *testing.T) {
+	client := new(posthog.MockClient)
+	watcher := stream.NewWatcher(client, config.TypeCoercionConfig{}, time.Hour, 0)
+
+	syncClient := startServer(t, insecureConfig(), client, watcher, nil)
+
+	syncStream, err := syncClient.SyncFlags(context.Background(), &syncv1.SyncFlagsRequest{})
+	require.NoError(t, err)
+	_, err = syncStream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestServer_Authentication(t *testing.T) {
+	client := new(posthog.MockClient)
+	client.On("GetFeatureFlags", mock.Anything).Return([]models.PostHogFeatureFlag{}, nil)
+
+	cfg := &config.Config{Proxy: config.ProxyConfig{Auth: config.AuthConfig{Tokens: []config.AuthToken{
+		{Token: "reader", Capabilities: []string{"read"}},
+		{Token: "deleter", Capabilities: []string{"delete"}},
+	}}}}
+	syncClient := startServer(t, cfg, client, nil, nil)
+
+	tests := []struct {
+		name     string
+		header   string
+		expected codes.Code
+	}{
+		{"missing", "", codes.Unauthenticated},
+		{"malformed", "reader", codes.Unauthenticated},
+		{"unknown", "Bearer nope", codes.Unauthenticated},
+		{"without read", "Bearer deleter", codes.PermissionDenied},
+		{"valid", "Bearer reader", codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.header != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tt.header)
+			}
+			_, err := syncClient.FetchAllFlags(ctx, &syncv1.FetchAllFlagsRequest{})
+			assert.Equal(t, tt.expected, status.Code(err))
+		})
+	}
+}
+
+func TestServer_JWTAuthentication(t *testing.T) {
+	client := new(posthog.MockClient)
+	client.On("GetFeatureFlags", mock.Anything).Return([]models.PostHogFeatureFlag{}, nil)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keySet, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "test",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	require.NoError(t, err)
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksPath, keySet, 0o600))
+
+	cfg := &config.Config{}
+	cfg.Proxy.Auth.JWT = config.JWTConfig{
+		Issuer:            "https://sso.example.com",
+		Audience:          "posthog-proxy",
+		JWKSFile:          jwksPath,
+		CapabilitiesClaim: "capabilities",
+		NameClaim:         "sub",
+		ScopesClaim:       "flags",
+	}
+	verifier, err := jwtauth.NewVerifier(cfg.Proxy.Auth.JWT)
+	require.NoError(t, err)
+	syncClient := startServer(t, cfg, client, nil, verifier)
+
+	sign := func(claims map[string]interface{}) string {
+		encode := func(v interface{}) string {
+			data, err := json.Marshal(v)
+			require.NoError(t, err)
+			return base64.RawURLEncoding.EncodeToString(data)
+		}
+		claims["iss"] = "https://sso.example.com"
+		claims["aud"] = "posthog-proxy"
+		claims["sub"] = "flagd"
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		signed := encode(map[string]string{"alg": "RS256", "kid": "test"}) + "." + encode(claims)
+		digest := sha256.Sum256([]byte(signed))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+		return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		expected codes.Code
+	}{
+		{"read", sign(map[string]interface{}{"capabilities": []string{"read"}}), codes.OK},
+		{"without read", sign(map[string]interface{}{"capabilities": []string{"write"}}), codes.PermissionDenied},
+		{"scoped", sign(map[string]interface{}{"capabilities": []string{"read"}, "flags": []string{"checkout-*"}}), codes.PermissionDenied},
+		{"forged", sign(map[string]interface{}{"capabilities": []string{"read"}}) + "x", codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+tt.token)
+			_, err := syncClient.FetchAllFlags(ctx, &syncv1.FetchAllFlagsRequest{})
+			assert.Equal(t, tt.expected, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/audit"
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+// recordAudit writes an audit event for a write to PostHog. A failing sink is logged
+// but never fails the request, since the change has already been made.
+func (h *Handler) recordAudit(c *gin.Context, action audit.Action, key string, before, after *models.ManifestFlag, err error) {
+	if h.auditSink == nil {
+		return
+	}
+
+	event := audit.Event{
+		Time:      time.Now().UTC(),
+		RequestID: c.GetString("request_id"),
+		Caller:    c.GetString("caller"),
+		Action:    action,
+		FlagKey:   key,
+		Before:    before,
+		After:     after,
+		Result:    audit.ResultSuccess,
+	}
+	if err != nil {
+		event.After = nil
+		event.Result = audit.ResultFailure
+		event.Error = err.Error()
+	}
+
+	if writeErr := h.auditSink.Write(c.Request.Context(), event); writeErr != nil {
+		slog.WarnContext(c.Request.Context(), "Failed to write audit event", "key", key, "action", action, "caller", event.Caller, "error", writeErr)
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/audit"
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink keeps audit events in memory
+type recordingSink struct {
+	events []audit.Event
+}
+
+func (r *recordingSink) Write(_ context.Context, event audit.Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recordingSink) Close() error { return nil }
+
+func newAuditedRouter(mockClient *posthog.MockClient, sink *recordingSink) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Proxy.Auth.Tokens = []config.AuthToken{{Name: "deploy-bot", Token: "admin-secret", Capabilities: []string{"read", "write", "delete"}}}
+	handler := NewHandler(mockClient, cfg, nil, WithAuditSink(sink))
+
+	router := gin.New()
+	router.Use(RequestIDMiddleware())
+	api := router.Group("/openfeature/v0", handler.AuthMiddleware())
+	api.PUT("/manifest/flags/:key", handler.RequireCapability("write"), handler.UpdateFlag)
+	api.DELETE("/manifest/flags/:key", handler.RequireCapability("delete"), handler.DeleteFlag)
+	return router
+}
+
+func TestAudit_UpdateFlag(t *testing.T) {
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlagByKey", mock.Anything, "checkout").Return(&models.PostHogFeatureFlag{
+		ID: 7, Key: "checkout", Name: "Old", Active: true,
+	}, nil)
+	mockClient.On("UpdateFeatureFlag", mock.Anything, 7, mock.Anything).Return(&models.PostHogFeatureFlag{
+		ID: 7, Key: "checkout", Name: "New", Active: true,
+	}, nil)
+	sink := &recordingSink{}
+	router := newAuditedRouter(mockClient, sink)
+
+	req := httptest.NewRequest(http.MethodPut, "/openfeature/v0/manifest/flags/checkout", bytes.NewBufferString(`{"description":"New"}`))
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "req-42")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "req-42", w.Header().Get("X-Request-ID"))
+	require.Len(t, sink.events, 1)
+	event := sink.events[0]
+	assert.Equal(t, audit.ActionUpdate, event.Action)
+	assert.Equal(t, "checkout", event.FlagKey)
+	assert.Equal(t, "req-42", event.RequestID)
+	assert.Equal(t, "deploy-bot", event.Caller)
+	assert.Equal(t, audit.ResultSuccess, event.Result)
+	assert.Equal(t, "Old", event.Before.Description)
+	assert.Equal(t, "New", event.After.Description)
+}
+
+func TestAudit_DeleteFlagFailure(t *testing.T) {
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlagByKey", mock.Anything, "checkout").Return(&models.PostHogFeatureFlag{
+		ID: 7, Key: "checkout", Active: true,
+	}, nil)
+	mockClient.On("DeleteFeatureFlag", mock.Anything, 7).Return(errors.New("PostHog API error (status 500)"))
+	sink := &recordingSink{}
+	router := newAuditedRouter(mockClient, sink)
+
+	req := httptest.NewRequest(http.MethodDelete, "/openfeature/v0/manifest/flags/checkout", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Len(t, sink.events, 1)
+	event := sink.events[0]
+	assert.Equal(t, audit.ActionDelete, event.Action)
+	assert.Equal(t, audit.ResultFailure, event.Result)
+	assert.Contains(t, event.Error, "status 500")
+	assert.NotNil(t, event.Before)
+	assert.Nil(t, event.After)
+	assert.Len(t, event.RequestID, 32, "a request ID is generated when the client sends none")
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/audit"
 	"github.com/openfeature/posthog-proxy/internal/models"
 	"github.com/openfeature/posthog-proxy/internal/transformer"
 )
@@ -21,11 +22,35 @@ func (h *Handler) CreateFlag(c *gin.Context) {
 		return
 	}
 
+	if !keyInScope(callerScope(c), req.Key) {
+		respondOutOfScope(c, req.Key)
+		return
+	}
+
 	// Normalize variant weights to ensure they sum to 100
 	if req.Variants != nil && len(req.Variants) > 0 {
 		req.Variants = NormalizeVariantWeights(req.Variants)
 	}
 
+	req.Type = req.Type.Normalize()
+	if err := ValidateFlagValues(req.Type, req.DefaultValue, req.Variants); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid flag value",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	if err := ValidateTargeting(req.Targeting, req.Variants); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid targeting configuration",
+			Details: err.Error(),
+		})
+		return
+	}
+
 	// Transform OpenFeature request to PostHog format
 	posthogReq := transformer.OpenFeatureToPostHogCreate(req, h.config.FeatureFlags.DefaultRolloutPercentage)
 
@@ -35,6 +60,10 @@ func (h *Handler) CreateFlag(c *gin.Context) {
 		if h.metrics != nil {
 			h.metrics.PostHogAPIErrors.Add(c.Request.Context(), 1)
 		}
+		h.recordAudit(c, audit.ActionCreate, req.Key, nil, nil, err)
+		if respondPostHogUnavailable(c, err) {
+			return
+		}
 		// Check if it's a duplicate key error (PostHog returns 400 with "unique" code)
 		if isPostHogDuplicateError(err) {
 			c.JSON(http.StatusConflict, models.ErrorResponse{
@@ -59,6 +88,7 @@ func (h *Handler) CreateFlag(c *gin.Context) {
 
 	// Transform back to OpenFeature format
 	openFeatureFlag := transformer.PostHogToOpenFeatureFlag(*posthogFlag, h.config.FeatureFlags.TypeCoercion)
+	h.recordAudit(c, audit.ActionCreate, req.Key, nil, &openFeatureFlag, nil)
 
 	// Return ManifestFlagResponse according to spec
 	response := models.ManifestFlagResponse{
@@ -68,6 +98,7 @@ func (h *Handler) CreateFlag(c *gin.Context) {
 
 	// Add X-Manifest-Capabilities header per spec
 	c.Header("X-Manifest-Capabilities", "read,write,delete")
+	c.Header("ETag", flagETag(posthogFlag))
 
 	c.JSON(http.StatusCreated, response)
 }
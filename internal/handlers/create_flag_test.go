@@ -233,6 +233,31 @@ func TestCreateFlag_InvalidRequestBody(t *testing.T) {
 	assert.Equal(t, "Invalid request body", response.Message)
 }
 
+func TestCreateFlag_IntegerRejectsFractionalValue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("Should not reach PostHog API")
+	}))
+	defer server.Close()
+
+	handler := setupTestHandler(t, server)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	body := `{"key": "max-items", "type": "integer", "defaultValue": 2.5}`
+	c.Request = httptest.NewRequest(http.MethodPost, "/openfeature/v0/manifest/flags", bytes.NewBufferString(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.CreateFlag(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response models.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Invalid flag value", response.Message)
+	assert.Contains(t, response.Details, "fractional")
+}
+
 func TestCreateFlag_PostHogError(t *testing.T) {
 	// Create mock PostHog server that returns error
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
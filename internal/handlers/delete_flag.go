@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/audit"
 	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/transformer"
 )
 
 // DeleteFlag handles DELETE /openfeature/v0/manifest/flags/:key
@@ -24,6 +27,9 @@ func (h *Handler) DeleteFlag(c *gin.Context) {
 		if h.metrics != nil {
 			h.metrics.PostHogAPIErrors.Add(c.Request.Context(), 1)
 		}
+		if respondPostHogUnavailable(c, err) {
+			return
+		}
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Code:    http.StatusNotFound,
 			Message: "Feature flag not found",
@@ -32,18 +38,33 @@ func (h *Handler) DeleteFlag(c *gin.Context) {
 		return
 	}
 
+	currentFlag := transformer.PostHogToOpenFeatureFlag(*existingFlag, h.config.FeatureFlags.TypeCoercion)
+
 	// Check if we should archive or hard delete
 	if h.config.FeatureFlags.ArchiveInsteadOfDelete {
-		// Archive flag by setting it to inactive
-		updateReq := models.PostHogUpdateFlagRequest{
-			Active: &[]bool{false}[0],
+		// Archiving an archived flag again would lose the state to restore
+		if archivedAt := transformer.ArchivedAt(*existingFlag); archivedAt != nil {
+			c.Header("X-Manifest-Capabilities", "read,write,delete")
+			c.JSON(http.StatusOK, models.ArchiveResponse{
+				Message:    "Flag \"" + key + "\" is already archived.",
+				ArchivedAt: archivedAt,
+			})
+			return
 		}
 
+		// Archive flag by setting it to inactive and tagging it so it can be restored
+		archivedAt := time.Now().UTC().Truncate(time.Second)
+		updateReq := transformer.ArchiveUpdate(*existingFlag, archivedAt)
+
 		updatedFlag, err := h.posthogClient.UpdateFeatureFlag(c.Request.Context(), existingFlag.ID, updateReq)
 		if err != nil {
 			if h.metrics != nil {
 				h.metrics.PostHogAPIErrors.Add(c.Request.Context(), 1)
 			}
+			h.recordAudit(c, audit.ActionArchive, key, &currentFlag, nil, err)
+			if respondPostHogUnavailable(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 				Code:    http.StatusInternalServerError,
 				Message: "Failed to archive feature flag in PostHog",
@@ -56,23 +77,31 @@ func (h *Handler) DeleteFlag(c *gin.Context) {
 			h.metrics.FlagsDeleted.Add(c.Request.Context(), 1)
 		}
 
-		// Return ArchiveResponse according to spec
+		archivedFlag := transformer.PostHogToOpenFeatureFlag(*updatedFlag, h.config.FeatureFlags.TypeCoercion)
+		h.recordAudit(c, audit.ActionArchive, key, &currentFlag, &archivedFlag, nil)
+
+		// Return ArchiveResponse according to spec. A 204 would drop the body, so the
+		// archive time and restore hint are sent with 200
 		response := models.ArchiveResponse{
-			Message:    "Flag \"" + key + "\" archived. Restore it using your management interface if needed.",
-			ArchivedAt: &updatedFlag.UpdatedAt,
+			Message:    "Flag \"" + key + "\" archived. Restore it with POST /openfeature/v0/manifest/flags/" + key + "/restore.",
+			ArchivedAt: &archivedAt,
 		}
 		
 		// Add X-Manifest-Capabilities header per spec
 		c.Header("X-Manifest-Capabilities", "read,write,delete")
 		
-		c.JSON(http.StatusNoContent, response)
+		c.JSON(http.StatusOK, response)
 	} else {
 		// Hard delete the flag
 		err = h.posthogClient.DeleteFeatureFlag(c.Request.Context(), existingFlag.ID)
+		h.recordAudit(c, audit.ActionDelete, key, &currentFlag, nil, err)
 		if err != nil {
 			if h.metrics != nil {
 				h.metrics.PostHogAPIErrors.Add(c.Request.Context(), 1)
 			}
+			if respondPostHogUnavailable(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 				Code:    http.StatusInternalServerError,
 				Message: "Failed to delete feature flag in PostHog",
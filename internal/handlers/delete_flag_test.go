@@ -5,12 +5,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/openfeature/posthog-proxy/internal/config"
 "github.com/openfeature/posthog-proxy/internal/posthog"
 	"github.com/openfeature/posthog-proxy/internal/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -82,6 +84,11 @@ func TestDeleteFlag_Success_Archive(t *testing.T) {
 			assert.NotNil(t, reqBody.Active)
 			assert.False(t, *reqBody.Active)
 
+			// Verify the archive is recorded so the flag can be restored
+			require.NotNil(t, reqBody.Tags)
+			assert.Contains(t, *reqBody.Tags, "archived-state:ENABLED")
+			assert.Len(t, *reqBody.Tags, 2)
+
 			response := models.PostHogFeatureFlag{
 				ID:     2,
 				Key:    "archive-flag",
@@ -110,7 +117,37 @@ func TestDeleteFlag_Success_Archive(t *testing.T) {
 	handler.DeleteFlag(c)
 
 	// Assert
-	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.ArchiveResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotNil(t, response.ArchivedAt)
+	assert.WithinDuration(t, time.Now(), *response.ArchivedAt, time.Minute)
+	assert.Equal(t, `Flag "archive-flag" archived. Restore it with POST /openfeature/v0/manifest/flags/archive-flag/restore.`, response.Message)
+}
+
+func TestDeleteFlag_AlreadyArchived(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	handler := NewHandler(mockClient, &config.Config{FeatureFlags: config.FeatureFlagsConfig{ArchiveInsteadOfDelete: true}}, nil)
+
+	mockClient.On("GetFeatureFlagByKey", mock.Anything, "archived").Return(&models.PostHogFeatureFlag{
+		ID:   7,
+		Key:  "archived",
+		Tags: []string{"archived-at:2026-03-01T10:00:00Z", "archived-state:ENABLED"},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "key", Value: "archived"}}
+	c.Request = httptest.NewRequest(http.MethodDelete, "/openfeature/v0/manifest/flags/archived", nil)
+
+	handler.DeleteFlag(c)
+
+	// The original archive record is kept and reported
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"message": "Flag \"archived\" is already archived.", "archivedAt": "2026-03-01T10:00:00Z"}`, w.Body.String())
+	mockClient.AssertNotCalled(t, "UpdateFeatureFlag", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestDeleteFlag_MissingKey(t *testing.T) {
@@ -266,16 +303,19 @@ func TestDeleteFlag_ConfigurationToggle(t *testing.T) {
 		name                    string
 		archiveInsteadOfDelete  bool
 		expectedMethod          string
+		expectedStatus          int
 	}{
 		{
 			name:                   "Hard delete when archive disabled",
 			archiveInsteadOfDelete: false,
 			expectedMethod:         http.MethodDelete,
+			expectedStatus:         http.StatusNoContent,
 		},
 		{
 			name:                   "Archive when archive enabled",
 			archiveInsteadOfDelete: true,
 			expectedMethod:         http.MethodPatch,
+			expectedStatus:         http.StatusOK,
 		},
 	}
 
@@ -330,7 +370,7 @@ func TestDeleteFlag_ConfigurationToggle(t *testing.T) {
 
 			handler.DeleteFlag(c)
 
-			assert.Equal(t, http.StatusNoContent, w.Code)
+			assert.Equal(t, tt.expectedStatus, w.Code)
 			assert.Equal(t, tt.expectedMethod, actualMethod)
 		})
 	}
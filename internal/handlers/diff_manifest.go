@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/manifestdiff"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/transformer"
+)
+
+// DiffManifest handles POST /openfeature/v0/manifest/diff. It compares the "to"
+// manifest against the "from" manifest, or against the live PostHog flags when
+// "from" is omitted, without changing anything.
+func (h *Handler) DiffManifest(c *gin.Context) {
+	var req models.ManifestDiffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid request body",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	to, err := normalizeManifestFlags(req.To.Flags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid manifest",
+			Details: "to: " + err.Error(),
+		})
+		return
+	}
+
+	var from models.Manifest
+	if req.From != nil {
+		flags, err := normalizeManifestFlags(req.From.Flags)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid manifest",
+				Details: "from: " + err.Error(),
+			})
+			return
+		}
+		from.Flags = flags
+	} else {
+		// Against the live flags, a scoped token can only compare the flags it may see
+		if outside := manifestFlagsOutOfScope(c, to); len(outside) > 0 {
+			respondOutOfScope(c, outside...)
+			return
+		}
+
+		posthogFlags, err := h.posthogClient.GetFeatureFlags(c.Request.Context())
+		if err != nil {
+			if h.metrics != nil {
+				h.metrics.PostHogAPIErrors.Add(c.Request.Context(), 1)
+			}
+			if respondPostHogUnavailable(c, err) {
+				return
+			}
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Failed to retrieve feature flags from PostHog",
+				Details: err.Error(),
+			})
+			return
+		}
+		from = transformer.PostHogToOpenFeatureManifest(flagsInScope(c, posthogFlags), h.config.FeatureFlags.TypeCoercion)
+	}
+
+	// Add X-Manifest-Capabilities header per spec
+	c.Header("X-Manifest-Capabilities", "read,write,delete")
+
+	c.JSON(http.StatusOK, manifestdiff.Manifests(from, models.Manifest{Flags: to}))
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func performDiff(handler *Handler, body interface{}) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/openfeature/v0/manifest/diff", bytes.NewReader(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	handler.DiffManifest(c)
+	return w
+}
+
+func TestDiffManifest_AgainstPostHog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(importTestFlags(), nil)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	w := performDiff(handler, models.ManifestDiffRequest{To: importTestManifest()})
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var diff models.ManifestDiff
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &diff))
+
+	// "archived" is in PostHog but inactive, so it is reported as removed too
+	assert.Equal(t, models.DiffSummary{Added: 1, Removed: 2, Changed: 1, Unchanged: 1}, diff.Summary)
+	changes := map[string]models.FlagDiff{}
+	for _, flag := range diff.Flags {
+		changes[flag.Key] = flag
+	}
+	assert.Equal(t, models.FlagAdded, changes["new-flag"].Change)
+	assert.Equal(t, models.FlagRemoved, changes["stale"].Change)
+	assert.Equal(t, []models.FieldChange{{Field: "description", From: "Old description", To: "New description"}}, changes["change"].Changes)
+	mockClient.AssertExpectations(t)
+}
+
+func TestDiffManifest_BetweenManifests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	from := models.Manifest{Flags: []models.ManifestFlag{
+		{Key: "limit", Type: models.FlagTypeInteger, DefaultValue: 10},
+	}}
+	to := models.Manifest{Flags: []models.ManifestFlag{
+		{Key: "limit", Type: models.FlagTypeInteger, DefaultValue: 20, State: models.FlagStateDisabled},
+	}}
+
+	w := performDiff(handler, models.ManifestDiffRequest{From: &from, To: to})
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"summary": {"added": 0, "removed": 0, "changed": 1, "unchanged": 0},
+		"flags": [{"key": "limit", "change": "changed", "changes": [
+			{"field": "defaultValue", "from": 10, "to": 20},
+			{"field": "state", "from": "ENABLED", "to": "DISABLED"}
+		]}]
+	}`, w.Body.String())
+	mockClient.AssertNotCalled(t, "GetFeatureFlags", mock.Anything)
+}
+
+func TestDiffManifest_InvalidManifest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(new(posthog.MockClient), &config.Config{}, nil)
+
+	w := performDiff(handler, models.ManifestDiffRequest{To: models.Manifest{Flags: []models.ManifestFlag{
+		{Key: "limit", Type: models.FlagTypeInteger, DefaultValue: "ten"},
+	}}})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response models.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Contains(t, response.Details, `to: flag "limit"`)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+// contentETag returns a strong ETag derived from the SHA-256 of a response body.
+// Callers must pass the canonical serialization (flags sorted by key; encoding/json
+// already writes map keys such as variants and metadata in sorted order) so the
+// same manifest always produces the same tag across runs and replicas.
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match or If-Match header value matches the
+// given ETag. It accepts "*", comma-separated lists and weak validators (W/"...").
+func etagMatches(header, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == want {
+			return true
+		}
+	}
+	return false
+}
+
+// flagETag returns a strong ETag for a single flag derived from PostHog's version
+// counter, which is bumped on every change. Instances that don't report a version
+// fall back to the last modification timestamp.
+func flagETag(flag *models.PostHogFeatureFlag) string {
+	if flag.Version > 0 {
+		return fmt.Sprintf(`"%d-v%d"`, flag.ID, flag.Version)
+	}
+	return fmt.Sprintf(`"%d-t%d"`, flag.ID, flag.UpdatedAt.UnixNano())
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentETag_Deterministic(t *testing.T) {
+	body := []byte(`{"flags":[{"key":"a"}]}`)
+
+	assert.Equal(t, contentETag(body), contentETag(body))
+	assert.NotEqual(t, contentETag(body), contentETag([]byte(`{"flags":[]}`)))
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, contentETag(body))
+}
+
+func TestEtagMatches(t *testing.T) {
+	etag := `"abc123"`
+
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "empty header", header: "", want: false},
+		{name: "exact match", header: `"abc123"`, want: true},
+		{name: "wildcard", header: "*", want: true},
+		{name: "weak validator", header: `W/"abc123"`, want: true},
+		{name: "list containing match", header: `"other", "abc123"`, want: true},
+		{name: "no match", header: `"other"`, want: false},
+		{name: "unquoted value", header: `abc123`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, etagMatches(tt.header, etag))
+		})
+	}
+}
@@ -23,6 +23,9 @@ func (h *Handler) GetFlag(c *gin.Context) {
 	// Get the flag from PostHog by key
 	posthogFlag, err := h.posthogClient.GetFeatureFlagByKey(c.Request.Context(), flagKey)
 	if err != nil {
+		if respondPostHogUnavailable(c, err) {
+			return
+		}
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Code:    http.StatusNotFound,
 			Message: "flag not found",
@@ -31,6 +34,15 @@ func (h *Handler) GetFlag(c *gin.Context) {
 		return
 	}
 
+	if transformer.ArchivedAt(*posthogFlag) != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Code:    http.StatusNotFound,
+			Message: "flag not found",
+			Details: "flag is archived; restore it with POST /openfeature/v0/manifest/flags/" + flagKey + "/restore",
+		})
+		return
+	}
+
 	// Check if flag is active
 	if !posthogFlag.Active {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
@@ -46,6 +58,9 @@ func (h *Handler) GetFlag(c *gin.Context) {
 
 	// Add X-Manifest-Capabilities header per spec
 	c.Header("X-Manifest-Capabilities", "read,write,delete")
+
+	// Expose the flag version so clients can send it back in If-Match when updating
+	c.Header("ETag", flagETag(posthogFlag))
 	
 	// Wrap in ManifestFlagResponse
 	response := models.ManifestFlagResponse{
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/transformer"
+)
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+// GetFlagHistory handles GET /openfeature/v0/manifest/flags/:key/history. It returns
+// a page of the flag's PostHog activity log, newest first, in OpenFeature terms.
+func (h *Handler) GetFlagHistory(c *gin.Context) {
+	key := c.Param("key")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Flag key is required",
+		})
+		return
+	}
+
+	page, limit, err := parseHistoryPagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid pagination parameters",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	posthogFlag, err := h.posthogClient.GetFeatureFlagByKey(c.Request.Context(), key)
+	if err != nil {
+		if h.metrics != nil {
+			h.metrics.PostHogAPIErrors.Add(c.Request.Context(), 1)
+		}
+		if respondPostHogUnavailable(c, err) {
+			return
+		}
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Code:    http.StatusNotFound,
+			Message: "Feature flag not found",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	activity, err := h.posthogClient.GetFeatureFlagActivity(c.Request.Context(), posthogFlag.ID, page, limit)
+	if err != nil {
+		if h.metrics != nil {
+			h.metrics.PostHogAPIErrors.Add(c.Request.Context(), 1)
+		}
+		if respondPostHogUnavailable(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to retrieve flag history from PostHog",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	response := models.FlagHistoryResponse{
+		Key:     key,
+		Entries: transformer.PostHogActivityToHistory(*posthogFlag, activity.Results, h.config.FeatureFlags.TypeCoercion),
+		Page:    page,
+		Limit:   limit,
+		Total:   activity.TotalCount,
+		HasMore: activity.Next != nil && *activity.Next != "",
+	}
+
+	// Add X-Manifest-Capabilities header per spec
+	c.Header("X-Manifest-Capabilities", "read,write,delete")
+
+	c.JSON(http.StatusOK, response)
+}
+
+// parseHistoryPagination reads the page (from 1) and limit query parameters
+func parseHistoryPagination(c *gin.Context) (int, int, error) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, fmt.Errorf("page must be a positive integer")
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultHistoryLimit)))
+	if err != nil || limit < 1 || limit > maxHistoryLimit {
+		return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxHistoryLimit)
+	}
+
+	return page, limit, nil
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func performGetHistory(handler *Handler, key, query string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{gin.Param{Key: "key", Value: key}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/openfeature/v0/manifest/flags/"+key+"/history"+query, nil)
+	handler.GetFlagHistory(c)
+	return w
+}
+
+func TestGetFlagHistory_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	next := "https://app.posthog.com/api/projects/1/feature_flags/7/activity/?page=3&limit=2"
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlagByKey", mock.Anything, "checkout").Return(&models.PostHogFeatureFlag{
+		ID: 7, Key: "checkout", Name: "Checkout", Active: true,
+	}, nil)
+	mockClient.On("GetFeatureFlagActivity", mock.Anything, 7, 2, 2).Return(&models.PostHogActivityResponse{
+		Results: []models.PostHogActivityEntry{{
+			User:     &models.PostHogUser{Email: "ada@example.com"},
+			Activity: "updated",
+			Detail: models.PostHogActivityDetail{Changes: []models.PostHogActivityChange{
+				{Field: "name", Before: json.RawMessage(`"Old checkout"`), After: json.RawMessage(`"Checkout"`)},
+			}},
+			CreatedAt: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+		}},
+		Next:       &next,
+		TotalCount: 5,
+	}, nil)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	w := performGetHistory(handler, "checkout", "?page=2&limit=2")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"key": "checkout",
+		"entries": [{
+			"timestamp": "2026-03-01T10:00:00Z",
+			"action": "updated",
+			"changedBy": "ada@example.com",
+			"changes": [{"field": "description", "from": "Old checkout", "to": "Checkout"}]
+		}],
+		"page": 2,
+		"limit": 2,
+		"total": 5,
+		"hasMore": true
+	}`, w.Body.String())
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetFlagHistory_InvalidPagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(new(posthog.MockClient), &config.Config{}, nil)
+
+	for _, query := range []string{"?page=0", "?page=abc", "?limit=0", "?limit=101"} {
+		t.Run(query, func(t *testing.T) {
+			w := performGetHistory(handler, "checkout", query)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestGetFlagHistory_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("flag not found", func(t *testing.T) {
+		mockClient := new(posthog.MockClient)
+		mockClient.On("GetFeatureFlagByKey", mock.Anything, "missing").Return(nil, errors.New("PostHog API error (status 404): Not found"))
+		handler := NewHandler(mockClient, &config.Config{}, nil)
+
+		w := performGetHistory(handler, "missing", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("activity error", func(t *testing.T) {
+		mockClient := new(posthog.MockClient)
+		mockClient.On("GetFeatureFlagByKey", mock.Anything, "checkout").Return(&models.PostHogFeatureFlag{ID: 7, Key: "checkout"}, nil)
+		mockClient.On("GetFeatureFlagActivity", mock.Anything, 7, 1, defaultHistoryLimit).Return(nil, errors.New("PostHog API error (status 500)"))
+		handler := NewHandler(mockClient, &config.Config{}, nil)
+
+		w := performGetHistory(handler, "checkout", "")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
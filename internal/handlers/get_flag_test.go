@@ -224,3 +224,30 @@ func ptrInt(i int) *int {
 func ptrString(s string) *string {
 	return &s
 }
+
+func TestGetFlag_ReturnsVersionETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	posthogFlag := &models.PostHogFeatureFlag{
+		ID:      42,
+		Key:     "versioned-flag",
+		Active:  true,
+		Version: 7,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: ptrInt(100)}},
+		},
+	}
+	mockClient.On("GetFeatureFlagByKey", mock.Anything, "versioned-flag").Return(posthogFlag, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "key", Value: "versioned-flag"}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/openfeature/v0/manifest/flags/versioned-flag", nil)
+
+	handler.GetFlag(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"42-v7"`, w.Header().Get("ETag"))
+}
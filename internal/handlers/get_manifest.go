@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/openfeature/posthog-proxy/internal/models"
@@ -10,12 +12,53 @@ import (
 
 // GetManifest handles GET /openfeature/v0/manifest
 func (h *Handler) GetManifest(c *gin.Context) {
-	// Get feature flags from PostHog
-	posthogFlags, err := h.posthogClient.GetFeatureFlags(c.Request.Context())
+	// The response depends on the Accept header, so caches must key on it too
+	c.Header("Vary", "Accept")
+	format, err := negotiateManifestFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid manifest format",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	includeArchived, err := strconv.ParseBool(c.DefaultQuery("includeArchived", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid includeArchived parameter",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	query, err := parseManifestQuery(c, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid manifest query",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	// Get feature flags from PostHog, letting it apply the filters it supports. With
+	// the cache on, the cached list is filtered below instead of asking PostHog.
+	var posthogFlags []models.PostHogFeatureFlag
+	if opts := query.listOptions(); opts != nil && !h.config.Cache.Enabled {
+		posthogFlags, err = h.posthogClient.GetFeatureFlagsWithOptions(c.Request.Context(), opts)
+	} else {
+		posthogFlags, err = h.posthogClient.GetFeatureFlags(c.Request.Context())
+	}
 	if err != nil {
 		if h.metrics != nil {
 			h.metrics.PostHogAPIErrors.Add(c.Request.Context(), 1)
 		}
+		if respondPostHogUnavailable(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
 			Message: "Failed to retrieve feature flags from PostHog",
@@ -28,11 +71,47 @@ func (h *Handler) GetManifest(c *gin.Context) {
 		h.metrics.ManifestRequests.Add(c.Request.Context(), 1)
 	}
 
+	// Scoped tokens only see the flags they may access
+	posthogFlags = flagsInScope(c, posthogFlags)
+
+	// Flags archived through the proxy are hidden unless asked for
+	if !includeArchived {
+		posthogFlags = transformer.WithoutArchived(posthogFlags)
+	}
+
+	// The remaining filters apply to the flags as the manifest describes them
+	posthogFlags = transformer.FilterPostHogFlags(posthogFlags, query.filter, h.config.FeatureFlags.TypeCoercion)
+
+	c.Header("X-Total-Count", strconv.Itoa(len(posthogFlags)))
+	posthogFlags, nextCursor := query.page(posthogFlags)
+	if nextCursor != "" {
+		c.Header("X-Next-Cursor", nextCursor)
+	}
+
 	// Transform PostHog flags to OpenFeature manifest
 	manifest := transformer.PostHogToOpenFeatureManifest(posthogFlags, h.config.FeatureFlags.TypeCoercion)
 
+	body, contentType, err := renderManifest(format, posthogFlags, manifest, h.config.FeatureFlags.TypeCoercion)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to serialize manifest",
+			Details: err.Error(),
+		})
+		return
+	}
+
 	// Add X-Manifest-Capabilities header per spec
 	c.Header("X-Manifest-Capabilities", "read,write,delete")
-	
-	c.JSON(http.StatusOK, manifest)
+
+	// The manifest is serialized canonically, so its hash is a stable ETag that lets
+	// pollers skip the download when nothing changed; each format hashes differently
+	etag := contentETag(body)
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.AbortWithStatus(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, body)
 }
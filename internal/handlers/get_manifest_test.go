@@ -4,11 +4,16 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/config"
 	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -366,3 +371,285 @@ func TestGetManifest_LargeFlagSet(t *testing.T) {
 
 	assert.Len(t, response.Flags, 100)
 }
+
+func TestGetManifest_ETag(t *testing.T) {
+	rollout100 := 100
+	flagA := models.PostHogFeatureFlag{
+		ID:     1,
+		Key:    "flag-a",
+		Name:   "Flag A",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: &rollout100}},
+			Multivariate: &models.PostHogMultivariate{
+				Variants: []models.PostHogVariant{
+					{Key: "control", RolloutFlag: 50},
+					{Key: "test", RolloutFlag: 50},
+				},
+			},
+		},
+		Tags: []string{"owner:team-a", "domain:checkout"},
+	}
+	flagB := models.PostHogFeatureFlag{
+		ID:     2,
+		Key:    "flag-b",
+		Name:   "Flag B",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: &rollout100}},
+		},
+	}
+
+	reversed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		results := []models.PostHogFeatureFlag{flagA, flagB}
+		if reversed {
+			results = []models.PostHogFeatureFlag{flagB, flagA}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(models.PostHogFeatureFlagsResponse{Results: results})
+	}))
+	defer server.Close()
+
+	handler := setupTestHandler(t, server)
+	gin.SetMode(gin.TestMode)
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/openfeature/v0/manifest", nil)
+		if ifNoneMatch != "" {
+			c.Request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		handler.GetManifest(c)
+		return w
+	}
+
+	first := get("")
+	assert.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	t.Run("stable regardless of PostHog ordering", func(t *testing.T) {
+		reversed = true
+		defer func() { reversed = false }()
+
+		second := get("")
+		assert.Equal(t, http.StatusOK, second.Code)
+		assert.Equal(t, etag, second.Header().Get("ETag"))
+		assert.Equal(t, first.Body.String(), second.Body.String())
+	})
+
+	t.Run("matching If-None-Match returns 304", func(t *testing.T) {
+		w := get(etag)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("stale If-None-Match returns full manifest", func(t *testing.T) {
+		w := get(`"outdated"`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEmpty(t, w.Body.String())
+	})
+}
+
+func TestGetManifest_IncludeArchived(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		results := []models.PostHogFeatureFlag{
+			{ID: 1, Key: "active-flag", Name: "Active", Active: true},
+			{ID: 2, Key: "disabled-flag", Name: "Disabled", Active: false},
+			{ID: 3, Key: "archived-flag", Name: "Archived", Active: false,
+				Tags: []string{"owner:team-a", "archived-at:2026-03-01T10:00:00Z", "archived-state:ENABLED"}},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(models.PostHogFeatureFlagsResponse{Results: results})
+	}))
+	defer server.Close()
+
+	handler := setupTestHandler(t, server)
+	gin.SetMode(gin.TestMode)
+
+	get := func(target string) models.Manifest {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		handler.GetManifest(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var manifest models.Manifest
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &manifest))
+		return manifest
+	}
+
+	manifest := get("/openfeature/v0/manifest")
+	require.Len(t, manifest.Flags, 2)
+	assert.Equal(t, "active-flag", manifest.Flags[0].Key)
+	assert.Equal(t, "disabled-flag", manifest.Flags[1].Key)
+	assert.Nil(t, manifest.Flags[1].ArchivedAt)
+
+	manifest = get("/openfeature/v0/manifest?includeArchived=true")
+	require.Len(t, manifest.Flags, 3)
+	archived := manifest.Flags[1]
+	assert.Equal(t, "archived-flag", archived.Key)
+	assert.Equal(t, models.FlagStateDisabled, archived.State)
+	require.NotNil(t, archived.ArchivedAt)
+	assert.Equal(t, "2026-03-01T10:00:00Z", archived.ArchivedAt.Format(time.RFC3339))
+	assert.Equal(t, map[string]string{"owner": "team-a"}, archived.Metadata)
+}
+
+func TestGetManifest_Filters(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		results := []models.PostHogFeatureFlag{
+			{ID: 1, Key: "checkout-button", Name: "Checkout button", Active: true,
+				Tags: []string{"owner:payments", "domain:web", "expiry:2026-01-01T00:00:00Z"}},
+			{ID: 2, Key: "checkout-theme", Name: "Checkout theme", Active: true,
+				Tags: []string{"owner:payments", "domain:mobile"},
+				Filters: models.PostHogFilters{Multivariate: &models.PostHogMultivariate{
+					Variants: []models.PostHogVariant{{Key: "dark", RolloutFlag: 50}, {Key: "light", RolloutFlag: 50}},
+				}}},
+			{ID: 3, Key: "new-checkout", Name: "New checkout", Active: true,
+				Tags: []string{"owner:payments", "domain:web"}},
+		}
+		json.NewEncoder(w).Encode(models.PostHogFeatureFlagsResponse{Results: results})
+	}))
+	defer server.Close()
+
+	handler := setupTestHandler(t, server)
+	gin.SetMode(gin.TestMode)
+
+	get := func(target string) []string {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		handler.GetManifest(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var manifest models.Manifest
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &manifest))
+		keys := make([]string, 0, len(manifest.Flags))
+		for _, flag := range manifest.Flags {
+			keys = append(keys, flag.Key)
+		}
+		return keys
+	}
+
+	// PostHog searches the prefix and gets the tags and state; the proxy narrows the
+	// search down to the prefix and requires both tags
+	keys := get("/openfeature/v0/manifest?prefix=checkout-&state=enabled&owner=payments&domain=web")
+	assert.Equal(t, []string{"checkout-button"}, keys)
+	assert.Equal(t, "checkout-", query.Get("search"))
+	assert.Equal(t, "true", query.Get("active"))
+	assert.Equal(t, `["domain:web","owner:payments"]`, query.Get("tags"))
+
+	// Type and expiry only exist in the manifest, so they are left out of the PostHog query
+	keys = get("/openfeature/v0/manifest?type=string")
+	assert.Equal(t, []string{"checkout-theme"}, keys)
+	assert.Empty(t, query)
+
+	keys = get("/openfeature/v0/manifest?expired=true")
+	assert.Equal(t, []string{"checkout-button"}, keys)
+}
+
+func TestGetManifest_FiltersCachedList(t *testing.T) {
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return([]models.PostHogFeatureFlag{
+		{ID: 1, Key: "checkout-button", Active: true, Tags: []string{"owner:payments"}},
+		{ID: 2, Key: "checkout-theme", Active: false, Tags: []string{"owner:payments"}},
+		{ID: 3, Key: "search-ranking", Active: true, Tags: []string{"owner:search"}},
+	}, nil)
+
+	cfg := &config.Config{}
+	cfg.Cache.Enabled = true
+	handler := NewHandler(mockClient, cfg, nil)
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/openfeature/v0/manifest?prefix=checkout-&state=enabled&owner=payments", nil)
+	handler.GetManifest(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var manifest models.Manifest
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &manifest))
+	require.Len(t, manifest.Flags, 1)
+	assert.Equal(t, "checkout-button", manifest.Flags[0].Key)
+
+	// With the cache on, filtered reads are answered from the cached list
+	mockClient.AssertNotCalled(t, "GetFeatureFlagsWithOptions", mock.Anything, mock.Anything)
+}
+
+func TestGetManifest_Pagination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		results := []models.PostHogFeatureFlag{
+			{ID: 1, Key: "flag-d", Active: true},
+			{ID: 2, Key: "flag-a", Active: true},
+			{ID: 3, Key: "flag-c", Active: true},
+			{ID: 4, Key: "flag-b", Active: true},
+			{ID: 5, Key: "flag-e", Active: true},
+		}
+		json.NewEncoder(w).Encode(models.PostHogFeatureFlagsResponse{Results: results})
+	}))
+	defer server.Close()
+
+	handler := setupTestHandler(t, server)
+	gin.SetMode(gin.TestMode)
+
+	get := func(target string) ([]string, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		handler.GetManifest(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var manifest models.Manifest
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &manifest))
+		keys := make([]string, 0, len(manifest.Flags))
+		for _, flag := range manifest.Flags {
+			keys = append(keys, flag.Key)
+		}
+		return keys, w
+	}
+
+	keys, w := get("/openfeature/v0/manifest?limit=2")
+	assert.Equal(t, []string{"flag-a", "flag-b"}, keys)
+	assert.Equal(t, "5", w.Header().Get("X-Total-Count"))
+	cursor := w.Header().Get("X-Next-Cursor")
+	require.NotEmpty(t, cursor)
+
+	keys, w = get("/openfeature/v0/manifest?limit=2&cursor=" + cursor)
+	assert.Equal(t, []string{"flag-c", "flag-d"}, keys)
+
+	keys, w = get("/openfeature/v0/manifest?limit=2&cursor=" + w.Header().Get("X-Next-Cursor"))
+	assert.Equal(t, []string{"flag-e"}, keys)
+	assert.Empty(t, w.Header().Get("X-Next-Cursor"))
+
+	keys, _ = get("/openfeature/v0/manifest?limit=2&offset=3")
+	assert.Equal(t, []string{"flag-d", "flag-e"}, keys)
+
+	keys, _ = get("/openfeature/v0/manifest?offset=10")
+	assert.Empty(t, keys)
+}
+
+func TestGetManifest_InvalidQuery(t *testing.T) {
+	handler := NewHandler(nil, &config.Config{}, nil)
+	gin.SetMode(gin.TestMode)
+
+	for _, target := range []string{
+		"/openfeature/v0/manifest?state=ARCHIVED",
+		"/openfeature/v0/manifest?type=list",
+		"/openfeature/v0/manifest?limit=0",
+		"/openfeature/v0/manifest?offset=-1",
+		"/openfeature/v0/manifest?cursor=!!",
+		"/openfeature/v0/manifest?cursor=ZmxhZy1h&offset=2",
+		"/openfeature/v0/manifest?expired=soon",
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		handler.GetManifest(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
+}
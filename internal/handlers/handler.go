@@ -1,8 +1,13 @@
 package handlers
 
 import (
+	"github.com/openfeature/posthog-proxy/internal/audit"
+	"github.com/openfeature/posthog-proxy/internal/auth"
 	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/jwtauth"
 	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/openfeature/posthog-proxy/internal/ratelimit"
+	"github.com/openfeature/posthog-proxy/internal/stream"
 	"github.com/openfeature/posthog-proxy/internal/telemetry"
 )
 
@@ -11,13 +16,57 @@ type Handler struct {
 	posthogClient posthog.ClientInterface
 	config        *config.Config
 	metrics       *telemetry.Metrics
+	watcher       *stream.Watcher
+	auditSink     audit.Sink
+	jwtVerifier   *jwtauth.Verifier
+	authenticator *auth.Authenticator
+	rateLimiters  map[string]*ratelimit.Limiter
+}
+
+// Option configures optional Handler dependencies
+type Option func(*Handler)
+
+// WithManifestWatcher enables the manifest change stream backed by the given watcher
+func WithManifestWatcher(watcher *stream.Watcher) Option {
+	return func(h *Handler) {
+		h.watcher = watcher
+	}
+}
+
+// WithAuditSink records every flag change made through the handler to the given sink
+func WithAuditSink(sink audit.Sink) Option {
+	return func(h *Handler) {
+		h.auditSink = sink
+	}
+}
+
+// WithJWTVerifier makes AuthMiddleware accept JWTs checked by the given verifier in
+// addition to the configured static tokens
+func WithJWTVerifier(verifier *jwtauth.Verifier) Option {
+	return func(h *Handler) {
+		h.jwtVerifier = verifier
+	}
+}
+
+// WithRateLimiters throttles requests through RateLimit. Per-caller limiters are keyed
+// by budget ("read", "write"); the "global" limiter, when present, is shared by all
+// callers.
+func WithRateLimiters(limiters map[string]*ratelimit.Limiter) Option {
+	return func(h *Handler) {
+		h.rateLimiters = limiters
+	}
 }
 
 // NewHandler creates a new handler instance
-func NewHandler(posthogClient posthog.ClientInterface, cfg *config.Config, metrics *telemetry.Metrics) *Handler {
-	return &Handler{
+func NewHandler(posthogClient posthog.ClientInterface, cfg *config.Config, metrics *telemetry.Metrics, opts ...Option) *Handler {
+	h := &Handler{
 		posthogClient: posthogClient,
 		config:        cfg,
 		metrics:       metrics,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.authenticator = auth.NewAuthenticator(cfg.Proxy.Auth, h.jwtVerifier)
+	return h
 }
@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/audit"
+	"github.com/openfeature/posthog-proxy/internal/manifestdiff"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/transformer"
+)
+
+// importStep is a planned change to a single flag
+type importStep struct {
+	result   models.ImportFlagResult
+	existing *models.PostHogFeatureFlag
+	desired  models.ManifestFlag
+}
+
+// ImportManifest handles PUT /openfeature/v0/manifest. It makes PostHog match the
+// submitted manifest: missing flags are created, differing flags updated and flags
+// absent from the manifest archived. With ?dryRun=true only the plan is returned.
+func (h *Handler) ImportManifest(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid dryRun parameter",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	var manifest models.Manifest
+	if err := c.ShouldBindJSON(&manifest); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid request body",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	desired, err := normalizeManifestFlags(manifest.Flags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid manifest",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	if outside := manifestFlagsOutOfScope(c, desired); len(outside) > 0 {
+		respondOutOfScope(c, outside...)
+		return
+	}
+
+	posthogFlags, err := h.posthogClient.GetFeatureFlags(c.Request.Context())
+	if err != nil {
+		if h.metrics != nil {
+			h.metrics.PostHogAPIErrors.Add(c.Request.Context(), 1)
+		}
+		if respondPostHogUnavailable(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to retrieve feature flags from PostHog",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	// A scoped token's manifest describes only its own flags, so flags outside the
+	// scope are left alone rather than archived
+	plan := h.planImport(flagsInScope(c, posthogFlags), desired)
+	if !dryRun {
+		for i := range plan {
+			h.applyImportStep(c, &plan[i])
+		}
+	}
+
+	response := models.ManifestImportResponse{
+		DryRun: dryRun,
+		Flags:  make([]models.ImportFlagResult, 0, len(plan)),
+	}
+	for _, step := range plan {
+		response.Flags = append(response.Flags, step.result)
+		switch step.result.Action {
+		case models.ImportActionCreate:
+			response.Summary.Created++
+		case models.ImportActionUpdate:
+			response.Summary.Updated++
+		case models.ImportActionArchive:
+			response.Summary.Archived++
+		default:
+			response.Summary.Unchanged++
+		}
+		if step.result.Status == models.ImportStatusFailed {
+			response.Summary.Failed++
+		}
+	}
+
+	// Add X-Manifest-Capabilities header per spec
+	c.Header("X-Manifest-Capabilities", "read,write,delete")
+
+	// Some flags were applied and others weren't; the body says which
+	status := http.StatusOK
+	if response.Summary.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, response)
+}
+
+// normalizeManifestFlags validates submitted flags the same way CreateFlag does and
+// fills in the defaults the manifest reports for PostHog flags
+func normalizeManifestFlags(flags []models.ManifestFlag) ([]models.ManifestFlag, error) {
+	seen := make(map[string]struct{}, len(flags))
+	normalized := make([]models.ManifestFlag, 0, len(flags))
+
+	for _, flag := range flags {
+		if flag.Key == "" {
+			return nil, fmt.Errorf("every flag requires a key")
+		}
+		if _, duplicate := seen[flag.Key]; duplicate {
+			return nil, fmt.Errorf("flag %q appears more than once", flag.Key)
+		}
+		seen[flag.Key] = struct{}{}
+
+		flag.Type = flag.Type.Normalize()
+		// An exported payload flag lists its payload as a variant; it is written from
+		// defaultValue, not as a PostHog variant
+		flag.Variants = transformer.WithoutPayloadVariant(flag.Variants)
+		if len(flag.Variants) > 0 {
+			flag.Variants = NormalizeVariantWeights(flag.Variants)
+		}
+		if err := ValidateFlagValues(flag.Type, flag.DefaultValue, flag.Variants); err != nil {
+			return nil, fmt.Errorf("flag %q: %w", flag.Key, err)
+		}
+		if err := ValidateTargeting(flag.Targeting, flag.Variants); err != nil {
+			return nil, fmt.Errorf("flag %q: %w", flag.Key, err)
+		}
+
+		flag.Name = flag.Key
+		if flag.State == "" {
+			flag.State = models.FlagStateEnabled
+		}
+		normalized = append(normalized, flag)
+	}
+
+	return normalized, nil
+}
+
+// planImport works out the action for every flag in either the manifest or PostHog.
+// Flags the proxy has already archived are left alone unless the manifest lists them,
+// in which case they are brought back by the update.
+func (h *Handler) planImport(posthogFlags []models.PostHogFeatureFlag, desired []models.ManifestFlag) []importStep {
+	existing := make(map[string]*models.PostHogFeatureFlag, len(posthogFlags))
+	for i := range posthogFlags {
+		if !posthogFlags[i].Deleted {
+			existing[posthogFlags[i].Key] = &posthogFlags[i]
+		}
+	}
+
+	plan := make([]importStep, 0, len(desired)+len(existing))
+	wanted := make(map[string]struct{}, len(desired))
+
+	for _, flag := range desired {
+		wanted[flag.Key] = struct{}{}
+		step := importStep{
+			result:  models.ImportFlagResult{Key: flag.Key, Status: models.ImportStatusPlanned},
+			desired: flag,
+		}
+
+		phFlag, ok := existing[flag.Key]
+		if !ok {
+			step.result.Action = models.ImportActionCreate
+			plan = append(plan, step)
+			continue
+		}
+
+		step.existing = phFlag
+		current := transformer.PostHogToOpenFeatureFlag(*phFlag, h.config.FeatureFlags.TypeCoercion)
+		current.Variants = transformer.WithoutPayloadVariant(current.Variants)
+		step.result.Changes = manifestdiff.Flag(current, flag)
+		if archivedAt := transformer.ArchivedAt(*phFlag); archivedAt != nil {
+			restore := models.FieldChange{Field: manifestdiff.FieldArchivedAt, From: archivedAt}
+			step.result.Changes = append([]models.FieldChange{restore}, step.result.Changes...)
+		}
+		if len(step.result.Changes) == 0 {
+			step.result.Action = models.ImportActionUnchanged
+		} else {
+			step.result.Action = models.ImportActionUpdate
+		}
+		plan = append(plan, step)
+	}
+
+	for key, phFlag := range existing {
+		if _, ok := wanted[key]; ok || transformer.ArchivedAt(*phFlag) != nil {
+			continue
+		}
+		// Disabled flags still show in the manifest, so they are archived as well
+		var changes []models.FieldChange
+		if phFlag.Active {
+			changes = []models.FieldChange{{Field: manifestdiff.FieldState, From: models.FlagStateEnabled, To: models.FlagStateDisabled}}
+		}
+		plan = append(plan, importStep{
+			result: models.ImportFlagResult{
+				Key:     key,
+				Action:  models.ImportActionArchive,
+				Status:  models.ImportStatusPlanned,
+				Changes: changes,
+			},
+			existing: phFlag,
+		})
+	}
+
+	sort.SliceStable(plan, func(i, j int) bool {
+		return plan[i].result.Key < plan[j].result.Key
+	})
+	return plan
+}
+
+// applyImportStep carries out a planned action, records the outcome on the step and
+// audits the change
+func (h *Handler) applyImportStep(c *gin.Context, step *importStep) {
+	ctx := c.Request.Context()
+
+	var before *models.ManifestFlag
+	if step.existing != nil {
+		current := transformer.PostHogToOpenFeatureFlag(*step.existing, h.config.FeatureFlags.TypeCoercion)
+		before = &current
+	}
+
+	var (
+		action  audit.Action
+		updated *models.PostHogFeatureFlag
+		err     error
+	)
+	switch step.result.Action {
+	case models.ImportActionCreate:
+		action = audit.ActionCreate
+		posthogReq := transformer.OpenFeatureToPostHogCreate(models.CreateFlagRequest{
+			Key:          step.desired.Key,
+			Name:         step.desired.Name,
+			Description:  step.desired.Description,
+			Type:         step.desired.Type,
+			DefaultValue: step.desired.DefaultValue,
+			Variants:     step.desired.Variants,
+			Expiry:       step.desired.Expiry,
+			Metadata:     step.desired.Metadata,
+			Targeting:    step.desired.Targeting,
+		}, h.config.FeatureFlags.DefaultRolloutPercentage)
+		posthogReq.Active = step.desired.State != models.FlagStateDisabled
+
+		if updated, err = h.posthogClient.CreateFeatureFlag(ctx, posthogReq); err == nil && h.metrics != nil {
+			h.metrics.FlagsCreated.Add(ctx, 1)
+		}
+	case models.ImportActionUpdate:
+		action = audit.ActionUpdate
+		posthogReq := transformer.OpenFeatureToPostHogUpdate(updateRequestForChanges(step.desired, step.result.Changes), step.existing)
+		if transformer.ArchivedAt(*step.existing) != nil {
+			posthogReq = transformer.ClearArchiveTags(posthogReq, *step.existing)
+		}
+		if updated, err = h.posthogClient.UpdateFeatureFlag(ctx, step.existing.ID, posthogReq); err == nil && h.metrics != nil {
+			h.metrics.FlagsUpdated.Add(ctx, 1)
+		}
+	case models.ImportActionArchive:
+		action = audit.ActionArchive
+		posthogReq := transformer.ArchiveUpdate(*step.existing, time.Now().UTC().Truncate(time.Second))
+		if updated, err = h.posthogClient.UpdateFeatureFlag(ctx, step.existing.ID, posthogReq); err == nil && h.metrics != nil {
+			h.metrics.FlagsDeleted.Add(ctx, 1)
+		}
+	default:
+		return
+	}
+
+	if err != nil {
+		if h.metrics != nil {
+			h.metrics.PostHogAPIErrors.Add(ctx, 1)
+		}
+		h.recordAudit(c, action, step.result.Key, before, nil, err)
+		step.result.Status = models.ImportStatusFailed
+		step.result.Error = err.Error()
+		return
+	}
+
+	after := transformer.PostHogToOpenFeatureFlag(*updated, h.config.FeatureFlags.TypeCoercion)
+	h.recordAudit(c, action, step.result.Key, before, &after, nil)
+	step.result.Status = models.ImportStatusApplied
+}
+
+// updateRequestForChanges builds an update that only touches the fields that differ,
+// so PostHog settings the manifest cannot express are left alone
+func updateRequestForChanges(desired models.ManifestFlag, changes []models.FieldChange) models.UpdateFlagRequest {
+	var req models.UpdateFlagRequest
+	for _, change := range changes {
+		switch manifestdiff.Section(change.Field) {
+		case manifestdiff.FieldType:
+			req.Type = &desired.Type
+		case manifestdiff.FieldDescription:
+			req.Description = &desired.Description
+		case manifestdiff.FieldDefaultValue:
+			req.DefaultValue = desired.DefaultValue
+		case manifestdiff.FieldVariants:
+			variants := desired.Variants
+			if variants == nil {
+				variants = map[string]models.Variant{}
+			}
+			req.Variants = &variants
+		case manifestdiff.FieldState:
+			req.State = &desired.State
+		case manifestdiff.FieldExpiry:
+			req.Expiry = &models.NullableTime{Value: desired.Expiry}
+		case manifestdiff.FieldMetadata:
+			metadata := desired.Metadata
+			if metadata == nil {
+				metadata = map[string]string{}
+			}
+			req.Metadata = &metadata
+		case manifestdiff.FieldTargeting:
+			req.Targeting = desired.Targeting
+			if req.Targeting == nil {
+				req.Targeting = &models.Targeting{Rules: []models.TargetingRule{}}
+			}
+		}
+	}
+	return req
+}
@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/openfeature/posthog-proxy/internal/transformer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func importTestFlags() []models.PostHogFeatureFlag {
+	booleanFlag := func(id int, key, name string, active bool) models.PostHogFeatureFlag {
+		return models.PostHogFeatureFlag{
+			ID:     id,
+			Key:    key,
+			Name:   name,
+			Active: active,
+			Filters: models.PostHogFilters{
+				Groups: []models.PostHogFilterGroup{{RolloutPercentage: ptrInt(100)}},
+			},
+		}
+	}
+	archived := booleanFlag(4, "archived", "Archived", false)
+	archived.Tags = []string{"archived-at:2026-03-01T10:00:00Z", "archived-state:ENABLED"}
+	return []models.PostHogFeatureFlag{
+		booleanFlag(1, "keep", "Keep", true),
+		booleanFlag(2, "change", "Old description", true),
+		booleanFlag(3, "stale", "Stale", true),
+		archived,
+	}
+}
+
+func importTestManifest() models.Manifest {
+	return models.Manifest{Flags: []models.ManifestFlag{
+		{Key: "keep", Description: "Keep", Type: models.FlagTypeBoolean, DefaultValue: true},
+		{Key: "change", Description: "New description", Type: models.FlagTypeBoolean, DefaultValue: true},
+		{Key: "new-flag", Description: "Brand new", Type: models.FlagTypeBoolean, DefaultValue: true},
+	}}
+}
+
+func performImport(handler *Handler, query string, body interface{}) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/openfeature/v0/manifest"+query, bytes.NewReader(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	handler.ImportManifest(c)
+	return w
+}
+
+func resultsByKey(t *testing.T, w *httptest.ResponseRecorder) (models.ManifestImportResponse, map[string]models.ImportFlagResult) {
+	t.Helper()
+	var response models.ManifestImportResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	byKey := make(map[string]models.ImportFlagResult, len(response.Flags))
+	for _, result := range response.Flags {
+		byKey[result.Key] = result
+	}
+	return response, byKey
+}
+
+func TestImportManifest_DryRun(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(importTestFlags(), nil)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	w := performImport(handler, "?dryRun=true", importTestManifest())
+
+	require.Equal(t, http.StatusOK, w.Code)
+	response, results := resultsByKey(t, w)
+	assert.True(t, response.DryRun)
+	assert.Equal(t, models.ImportSummary{Created: 1, Updated: 1, Archived: 1, Unchanged: 1}, response.Summary)
+
+	assert.Equal(t, models.ImportActionUnchanged, results["keep"].Action)
+	assert.Equal(t, models.ImportActionCreate, results["new-flag"].Action)
+	assert.Equal(t, models.ImportActionArchive, results["stale"].Action)
+	assert.NotContains(t, results, "archived")
+
+	change := results["change"]
+	assert.Equal(t, models.ImportActionUpdate, change.Action)
+	assert.Equal(t, models.ImportStatusPlanned, change.Status)
+	assert.Equal(t, []models.FieldChange{{Field: "description", From: "Old description", To: "New description"}}, change.Changes)
+
+	// Nothing is written to PostHog
+	mockClient.AssertNotCalled(t, "CreateFeatureFlag", mock.Anything, mock.Anything)
+	mockClient.AssertNotCalled(t, "UpdateFeatureFlag", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestImportManifest_Apply(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(importTestFlags(), nil)
+	mockClient.On("CreateFeatureFlag", mock.Anything, mock.MatchedBy(func(req models.PostHogCreateFlagRequest) bool {
+		return req.Key == "new-flag" && req.Name == "Brand new" && req.Active
+	})).Return(&models.PostHogFeatureFlag{ID: 5, Key: "new-flag"}, nil)
+	mockClient.On("UpdateFeatureFlag", mock.Anything, 2, mock.MatchedBy(func(req models.PostHogUpdateFlagRequest) bool {
+		return req.Name != nil && *req.Name == "New description" && req.Active == nil && req.Filters == nil
+	})).Return(&models.PostHogFeatureFlag{ID: 2, Key: "change"}, nil)
+	mockClient.On("UpdateFeatureFlag", mock.Anything, 3, mock.MatchedBy(func(req models.PostHogUpdateFlagRequest) bool {
+		return req.Active != nil && !*req.Active
+	})).Return(&models.PostHogFeatureFlag{ID: 3, Key: "stale"}, nil)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	w := performImport(handler, "", importTestManifest())
+
+	require.Equal(t, http.StatusOK, w.Code)
+	response, results := resultsByKey(t, w)
+	assert.False(t, response.DryRun)
+	assert.Equal(t, 0, response.Summary.Failed)
+	assert.Equal(t, models.ImportStatusApplied, results["new-flag"].Status)
+	assert.Equal(t, models.ImportStatusApplied, results["change"].Status)
+	assert.Equal(t, models.ImportStatusApplied, results["stale"].Status)
+	assert.Equal(t, models.ImportStatusPlanned, results["keep"].Status)
+	mockClient.AssertExpectations(t)
+}
+
+func TestImportManifest_BooleanDefaultChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(importTestFlags()[:1], nil)
+	var body []byte
+	mockClient.On("UpdateFeatureFlag", mock.Anything, 1, mock.Anything).Run(func(args mock.Arguments) {
+		body, _ = json.Marshal(args.Get(2))
+	}).Return(&models.PostHogFeatureFlag{ID: 1, Key: "keep"}, nil)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	w := performImport(handler, "", models.Manifest{Flags: []models.ManifestFlag{
+		{Key: "keep", Description: "Keep", Type: models.FlagTypeBoolean, DefaultValue: false},
+	}})
+
+	require.Equal(t, http.StatusOK, w.Code)
+	_, results := resultsByKey(t, w)
+	assert.Equal(t, models.ImportActionUpdate, results["keep"].Action)
+	assert.Equal(t, models.ImportStatusApplied, results["keep"].Status)
+	// The default is written as the catch-all rollout rather than dropped
+	assert.JSONEq(t, `{"filters": {"groups": [{"rollout_percentage": 0}]}}`, string(body))
+}
+
+func TestImportManifest_RestoresArchivedFlag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manifest := models.Manifest{Flags: []models.ManifestFlag{
+		{Key: "archived", Description: "Archived", Type: models.FlagTypeBoolean, DefaultValue: true},
+	}}
+
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(importTestFlags()[3:], nil)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	w := performImport(handler, "?dryRun=true", manifest)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	_, results := resultsByKey(t, w)
+	archived := results["archived"]
+	assert.Equal(t, models.ImportActionUpdate, archived.Action)
+	require.NotEmpty(t, archived.Changes)
+	assert.Equal(t, "archivedAt", archived.Changes[0].Field)
+	assert.Contains(t, archived.Changes, models.FieldChange{Field: "state", From: "DISABLED", To: "ENABLED"})
+
+	var update models.PostHogUpdateFlagRequest
+	mockClient.On("UpdateFeatureFlag", mock.Anything, 4, mock.Anything).Run(func(args mock.Arguments) {
+		update = args.Get(2).(models.PostHogUpdateFlagRequest)
+	}).Return(&models.PostHogFeatureFlag{ID: 4, Key: "archived", Active: true}, nil)
+
+	w = performImport(handler, "", manifest)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	_, results = resultsByKey(t, w)
+	assert.Equal(t, models.ImportStatusApplied, results["archived"].Status)
+	require.NotNil(t, update.Active)
+	assert.True(t, *update.Active)
+	// The archive tags are removed so the flag no longer reads as archived
+	require.NotNil(t, update.Tags)
+	assert.Empty(t, *update.Tags)
+}
+
+func TestImportManifest_PartialFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(importTestFlags(), nil)
+	mockClient.On("CreateFeatureFlag", mock.Anything, mock.Anything).Return(nil, errors.New("PostHog API error (status 400): invalid key"))
+	mockClient.On("UpdateFeatureFlag", mock.Anything, mock.Anything, mock.Anything).Return(&models.PostHogFeatureFlag{}, nil)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	w := performImport(handler, "", importTestManifest())
+
+	require.Equal(t, http.StatusMultiStatus, w.Code)
+	response, results := resultsByKey(t, w)
+	assert.Equal(t, 1, response.Summary.Failed)
+	assert.Equal(t, models.ImportStatusFailed, results["new-flag"].Status)
+	assert.Contains(t, results["new-flag"].Error, "invalid key")
+	assert.Equal(t, models.ImportStatusApplied, results["change"].Status)
+}
+
+func TestImportManifest_InvalidManifest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(new(posthog.MockClient), &config.Config{}, nil)
+
+	tests := []struct {
+		name     string
+		manifest models.Manifest
+		details  string
+	}{
+		{
+			name: "duplicate key",
+			manifest: models.Manifest{Flags: []models.ManifestFlag{
+				{Key: "a", Type: models.FlagTypeBoolean, DefaultValue: true},
+				{Key: "a", Type: models.FlagTypeBoolean, DefaultValue: false},
+			}},
+			details: `flag "a" appears more than once`,
+		},
+		{
+			name: "wrong default value type",
+			manifest: models.Manifest{Flags: []models.ManifestFlag{
+				{Key: "a", Type: models.FlagTypeInteger, DefaultValue: "ten"},
+			}},
+			details: `flag "a"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := performImport(handler, "", tt.manifest)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var response models.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Contains(t, response.Details, tt.details)
+		})
+	}
+}
+
+func TestImportManifest_ArchivesDisabledFlags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	flags := importTestFlags()
+	flags[2].Active = false
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(flags, nil)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	w := performImport(handler, "?dryRun=true", importTestManifest())
+
+	require.Equal(t, http.StatusOK, w.Code)
+	_, results := resultsByKey(t, w)
+	// A disabled flag is not archived and still shows in the manifest
+	stale := results["stale"]
+	assert.Equal(t, models.ImportActionArchive, stale.Action)
+	assert.Empty(t, stale.Changes)
+	assert.NotContains(t, results, "archived")
+}
+
+func TestImportManifest_ExportedManifestIsUnchanged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	requests := []models.CreateFlagRequest{
+		{Key: "boolean", Type: models.FlagTypeBoolean, DefaultValue: false},
+		{Key: "string", Type: models.FlagTypeString, DefaultValue: "blue"},
+		{Key: "integer", Type: models.FlagTypeInteger, DefaultValue: 3},
+		{Key: "float", Type: models.FlagTypeFloat, DefaultValue: 0.25},
+		{Key: "object", Type: models.FlagTypeObject, DefaultValue: map[string]interface{}{"limit": 10.0}},
+		{
+			Key:          "colors",
+			Type:         models.FlagTypeString,
+			DefaultValue: "red",
+			Variants: map[string]models.Variant{
+				"a": {Value: "blue", Weight: ptrInt(50)},
+				"b": {Value: "red", Weight: ptrInt(50)},
+			},
+		},
+		{
+			Key:          "themes",
+			Type:         models.FlagTypeObject,
+			DefaultValue: map[string]interface{}{"dark": true},
+			Variants: map[string]models.Variant{
+				"dark":  {Value: map[string]interface{}{"dark": true}, Weight: ptrInt(50)},
+				"light": {Value: map[string]interface{}{"dark": false}, Weight: ptrInt(50)},
+			},
+		},
+	}
+	flags := make([]models.PostHogFeatureFlag, 0, len(requests))
+	for i, req := range requests {
+		created := transformer.OpenFeatureToPostHogCreate(req, 100)
+		flags = append(flags, models.PostHogFeatureFlag{
+			ID:      i + 1,
+			Key:     created.Key,
+			Name:    created.Name,
+			Active:  created.Active,
+			Filters: created.Filters,
+			Tags:    created.Tags,
+		})
+	}
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(flags, nil)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	exported := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(exported)
+	c.Request = httptest.NewRequest(http.MethodGet, "/openfeature/v0/manifest", nil)
+	handler.GetManifest(c)
+	require.Equal(t, http.StatusOK, exported.Code)
+	var manifest models.Manifest
+	require.NoError(t, json.Unmarshal(exported.Body.Bytes(), &manifest))
+
+	w := performImport(handler, "?dryRun=true", manifest)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	response, results := resultsByKey(t, w)
+	assert.Equal(t, models.ImportSummary{Unchanged: len(requests)}, response.Summary)
+	for key, result := range results {
+		assert.Equal(t, models.ImportActionUnchanged, result.Action, key)
+		assert.Empty(t, result.Changes, key)
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/jwtauth"
+	"github.com/openfeature/posthog-proxy/internal/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func signTestJWT(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(map[string]string{"alg": "RS256", "kid": "test"}) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestAuthMiddleware_JWT(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keySet, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "test",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	require.NoError(t, err)
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksPath, keySet, 0o600))
+
+	cfg := &config.Config{}
+	cfg.Proxy.Auth.Tokens = []config.AuthToken{{Name: "ci", Token: "static-secret", Capabilities: []string{"read"}}}
+	cfg.Proxy.Auth.JWT = config.JWTConfig{
+		Issuer:            "https://sso.example.com",
+		Audience:          "posthog-proxy",
+		JWKSFile:          jwksPath,
+		CapabilitiesClaim: "groups",
+		CapabilityMapping: map[string][]string{"flag-admins": {"read", "write", "delete"}},
+		NameClaim:         "email",
+	}
+	verifier, err := jwtauth.NewVerifier(cfg.Proxy.Auth.JWT)
+	require.NoError(t, err)
+	reader := sdkmetric.NewManualReader()
+	requests, err := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).
+		Meter("test").Int64Counter("authenticated_requests_total")
+	require.NoError(t, err)
+	handler := NewHandler(nil, cfg, &telemetry.Metrics{AuthenticatedRequests: requests}, WithJWTVerifier(verifier))
+
+	router := gin.New()
+	router.Use(handler.AuthMiddleware())
+	router.DELETE("/check", handler.RequireCapability("delete"), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("caller"))
+	})
+
+	claims := func(exp time.Time) map[string]interface{} {
+		return map[string]interface{}{
+			"iss":    "https://sso.example.com",
+			"aud":    "posthog-proxy",
+			"email":  "dev@example.com",
+			"groups": []string{"flag-admins"},
+			"exp":    exp.Unix(),
+		}
+	}
+	tests := []struct {
+		name     string
+		token    string
+		wantCode int
+		wantBody string
+	}{
+		{name: "valid JWT", token: signTestJWT(t, key, claims(time.Now().Add(time.Hour))), wantCode: http.StatusOK, wantBody: "dev@example.com"},
+		{name: "expired JWT", token: signTestJWT(t, key, claims(time.Now().Add(-time.Hour))), wantCode: http.StatusUnauthorized},
+		{name: "static token still works", token: "static-secret", wantCode: http.StatusForbidden},
+		{name: "unknown token", token: "nope", wantCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/check", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+
+	// Requests are counted by issuer and token name, never by the JWT's subject
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	counts := map[string]int64{}
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			for _, point := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				caller, _ := point.Attributes.Value("caller")
+				counts[caller.AsString()] += point.Value
+			}
+		}
+	}
+	assert.Equal(t, map[string]int64{"https://sso.example.com": 1, "ci": 1}, counts)
+}
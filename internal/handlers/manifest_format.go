@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/flagd"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// cliManifestSchema is the JSON schema of the OpenFeature CLI's local flag manifest
+const cliManifestSchema = "https://raw.githubusercontent.com/open-feature/cli/main/schema/v0/flag-manifest.json"
+
+// manifestFormat selects how GET /manifest renders the flags
+type manifestFormat string
+
+const (
+	// formatJSON is the sync API's manifest shape
+	formatJSON manifestFormat = "json"
+	// formatYAML is the sync API's manifest shape encoded as YAML
+	formatYAML manifestFormat = "yaml"
+	// formatFlagd is a flagd flag definition file
+	formatFlagd manifestFormat = "flagd"
+	// formatOpenFeatureCLI is the OpenFeature CLI's local flag manifest
+	formatOpenFeatureCLI manifestFormat = "openfeature-cli"
+)
+
+// negotiateManifestFormat reads the format query parameter, falling back to the Accept
+// header so that YAML can also be requested through content negotiation
+func negotiateManifestFormat(c *gin.Context) (manifestFormat, error) {
+	if raw := c.Query("format"); raw != "" {
+		switch format := manifestFormat(strings.ToLower(raw)); format {
+		case formatJSON, formatYAML, formatFlagd, formatOpenFeatureCLI:
+			return format, nil
+		}
+		return "", fmt.Errorf("unsupported format %q: expected one of json, yaml, flagd, openfeature-cli", raw)
+	}
+	return acceptedManifestFormat(c.GetHeader("Accept")), nil
+}
+
+// acceptedManifestFormat picks the format of the media range with the highest q-value
+// in an Accept header, the earliest one on a tie. Wildcards stand for JSON, and JSON is
+// also served when nothing acceptable is listed.
+func acceptedManifestFormat(accept string) manifestFormat {
+	best, bestQ := formatJSON, 0.0
+	for _, accepted := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+
+		var format manifestFormat
+		switch mediaType {
+		case "application/yaml", "application/x-yaml", "text/yaml":
+			format = formatYAML
+		case "application/json", "application/*", "*/*":
+			format = formatJSON
+		default:
+			continue
+		}
+
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+// renderManifest serializes the flags in the requested format and returns the body
+// with its content type. Every format is rendered from the same transformer output.
+func renderManifest(format manifestFormat, posthogFlags []models.PostHogFeatureFlag, manifest models.Manifest, coercion config.TypeCoercionConfig) ([]byte, string, error) {
+	switch format {
+	case formatYAML:
+		body, err := marshalYAML(manifest)
+		return body, "application/yaml; charset=utf-8", err
+	case formatFlagd:
+		body, err := json.Marshal(flagd.FromPostHog(posthogFlags, coercion))
+		return body, "application/json; charset=utf-8", err
+	case formatOpenFeatureCLI:
+		body, err := json.Marshal(cliManifest(manifest))
+		return body, "application/json; charset=utf-8", err
+	default:
+		body, err := json.Marshal(manifest)
+		return body, "application/json; charset=utf-8", err
+	}
+}
+
+// cliManifest converts the manifest to the OpenFeature CLI's local flag manifest
+func cliManifest(manifest models.Manifest) models.CLIManifest {
+	flags := make(map[string]models.CLIManifestFlag, len(manifest.Flags))
+	for _, flag := range manifest.Flags {
+		flags[flag.Key] = models.CLIManifestFlag{
+			FlagType:     flag.Type,
+			DefaultValue: flag.DefaultValue,
+			Description:  flag.Description,
+		}
+	}
+	return models.CLIManifest{Schema: cliManifestSchema, Flags: flags}
+}
+
+// marshalYAML encodes a value as YAML using its JSON field names, so both encodings
+// describe the manifest with the same keys
+func marshalYAML(value interface{}) ([]byte, error) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(body, &generic); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(generic)
+}
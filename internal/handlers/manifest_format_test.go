@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/flagd"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// newFormatTestServer serves a boolean and a multivariate flag
+func newFormatTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rollout := 100
+		response := models.PostHogFeatureFlagsResponse{
+			Results: []models.PostHogFeatureFlag{
+				{
+					ID:     1,
+					Key:    "new-checkout",
+					Name:   "New checkout flow",
+					Active: true,
+					Filters: models.PostHogFilters{
+						Groups: []models.PostHogFilterGroup{{RolloutPercentage: &rollout}},
+					},
+				},
+				{
+					ID:     2,
+					Key:    "button-color",
+					Name:   "Button color",
+					Active: true,
+					Filters: models.PostHogFilters{
+						Groups: []models.PostHogFilterGroup{{RolloutPercentage: &rollout}},
+						Multivariate: &models.PostHogMultivariate{Variants: []models.PostHogVariant{
+							{Key: "blue", RolloutFlag: 50},
+							{Key: "green", RolloutFlag: 50},
+						}},
+					},
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func getManifest(t *testing.T, target string, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := setupTestHandler(t, newFormatTestServer(t))
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		c.Request.Header.Set("Accept", accept)
+	}
+	handler.GetManifest(c)
+	return w
+}
+
+func TestGetManifest_FormatYAML(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		target string
+		accept string
+	}{
+		{"query parameter", "/openfeature/v0/manifest?format=yaml", ""},
+		{"accept header", "/openfeature/v0/manifest", "application/yaml"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := getManifest(t, tt.target, tt.accept)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "application/yaml; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.NotEmpty(t, w.Header().Get("ETag"))
+
+			var manifest map[string][]map[string]interface{}
+			require.NoError(t, yaml.Unmarshal(w.Body.Bytes(), &manifest))
+			assert.Len(t, manifest["flags"], 2)
+			assert.Contains(t, w.Body.String(), "defaultValue: blue")
+			assert.Contains(t, w.Body.String(), "key: new-checkout")
+		})
+	}
+}
+
+func TestAcceptedManifestFormat(t *testing.T) {
+	for _, tt := range []struct {
+		accept string
+		want   manifestFormat
+	}{
+		{"", formatJSON},
+		{"application/yaml", formatYAML},
+		{"application/json, application/yaml", formatJSON},
+		{"application/json;q=0.5, application/yaml", formatYAML},
+		{"application/yaml;q=0.2, application/json;q=0.9", formatJSON},
+		{"text/html, application/yaml;q=0.8, */*;q=0.1", formatYAML},
+		{"application/yaml;q=0, */*", formatJSON},
+		{"text/html", formatJSON},
+	} {
+		assert.Equal(t, tt.want, acceptedManifestFormat(tt.accept), "Accept: %s", tt.accept)
+	}
+}
+
+func TestGetManifest_VaryAccept(t *testing.T) {
+	w := getManifest(t, "/openfeature/v0/manifest", "application/yaml")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+}
+
+func TestGetManifest_FormatFlagd(t *testing.T) {
+	w := getManifest(t, "/openfeature/v0/manifest?format=flagd", "")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	var configuration flagd.Configuration
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &configuration))
+	assert.Equal(t, flagd.SchemaURL, configuration.Schema)
+	require.Len(t, configuration.Flags, 2)
+	assert.Equal(t, "on", configuration.Flags["new-checkout"].DefaultVariant)
+	assert.Equal(t, "blue", configuration.Flags["button-color"].DefaultVariant)
+	assert.Equal(t, map[string]interface{}{"blue": "blue", "green": "green"}, configuration.Flags["button-color"].Variants)
+}
+
+func TestGetManifest_FormatOpenFeatureCLI(t *testing.T) {
+	w := getManifest(t, "/openfeature/v0/manifest?format=openfeature-cli", "")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"$schema": "https://raw.githubusercontent.com/open-feature/cli/main/schema/v0/flag-manifest.json",
+		"flags": {
+			"button-color": {"flagType": "string", "defaultValue": "blue", "description": "Button color"},
+			"new-checkout": {"flagType": "boolean", "defaultValue": true, "description": "New checkout flow"}
+		}
+	}`, w.Body.String())
+}
+
+func TestGetManifest_FormatsHaveDistinctETags(t *testing.T) {
+	jsonETag := getManifest(t, "/openfeature/v0/manifest", "").Header().Get("ETag")
+	flagdETag := getManifest(t, "/openfeature/v0/manifest?format=flagd", "").Header().Get("ETag")
+
+	assert.NotEmpty(t, jsonETag)
+	assert.NotEqual(t, jsonETag, flagdETag)
+}
+
+func TestGetManifest_UnsupportedFormat(t *testing.T) {
+	w := getManifest(t, "/openfeature/v0/manifest?format=toml", "")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response models.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Contains(t, response.Details, "toml")
+}
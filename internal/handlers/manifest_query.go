@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/openfeature/posthog-proxy/internal/transformer"
+)
+
+// manifestMetadataFilters are the metadata keys GET /manifest can filter on
+var manifestMetadataFilters = []string{"owner", "domain"}
+
+// manifestQuery holds the filters and paging requested for GET /manifest
+type manifestQuery struct {
+	filter transformer.ManifestFilter
+	// limit is the page size, 0 for every matching flag
+	limit  int
+	offset int
+	// after is the key the page starts after, decoded from the cursor
+	after string
+}
+
+// parseManifestQuery reads the manifest filters and paging from the query string
+func parseManifestQuery(c *gin.Context, now time.Time) (manifestQuery, error) {
+	var query manifestQuery
+	query.filter.KeyPrefix = c.Query("prefix")
+	query.filter.Search = c.Query("search")
+
+	if state := c.Query("state"); state != "" {
+		query.filter.State = models.FlagState(strings.ToUpper(state))
+		if query.filter.State != models.FlagStateEnabled && query.filter.State != models.FlagStateDisabled {
+			return query, fmt.Errorf("state must be ENABLED or DISABLED, got %q", state)
+		}
+	}
+
+	if flagType := c.Query("type"); flagType != "" {
+		query.filter.Type = models.FlagType(flagType).Normalize()
+		switch query.filter.Type {
+		case models.FlagTypeBoolean, models.FlagTypeString, models.FlagTypeInteger, models.FlagTypeFloat, models.FlagTypeObject:
+		default:
+			return query, fmt.Errorf("unknown flag type %q", flagType)
+		}
+	}
+
+	for _, key := range manifestMetadataFilters {
+		if value := c.Query(key); value != "" {
+			if query.filter.Metadata == nil {
+				query.filter.Metadata = make(map[string]string)
+			}
+			query.filter.Metadata[key] = value
+		}
+	}
+
+	if raw := c.Query("expired"); raw != "" {
+		expired, err := strconv.ParseBool(raw)
+		if err != nil {
+			return query, fmt.Errorf("invalid expired parameter: %w", err)
+		}
+		if expired {
+			query.filter.ExpiredAt = &now
+		}
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return query, fmt.Errorf("limit must be a positive integer, got %q", raw)
+		}
+		query.limit = limit
+	}
+
+	if raw := c.Query("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return query, fmt.Errorf("offset must be a non-negative integer, got %q", raw)
+		}
+		query.offset = offset
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		if query.offset > 0 {
+			return query, fmt.Errorf("cursor and offset can't be combined")
+		}
+		after, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil || len(after) == 0 {
+			return query, fmt.Errorf("invalid cursor %q", cursor)
+		}
+		query.after = string(after)
+	}
+
+	return query, nil
+}
+
+// listOptions returns the filters PostHog can apply itself, or nil when there are none.
+// PostHog searches keys and names, so a key prefix is sent as a search and narrowed
+// down afterwards; metadata tags are matched by PostHog if any is present, so all of
+// them are checked afterwards too.
+func (q manifestQuery) listOptions() *posthog.ListFlagsOptions {
+	var opts posthog.ListFlagsOptions
+	pushed := false
+
+	if search := q.filter.Search; search != "" {
+		opts.Search = search
+		pushed = true
+	} else if prefix := q.filter.KeyPrefix; prefix != "" {
+		opts.Search = prefix
+		pushed = true
+	}
+
+	if q.filter.State != "" {
+		active := q.filter.State == models.FlagStateEnabled
+		opts.Active = &active
+		pushed = true
+	}
+
+	for key, value := range q.filter.Metadata {
+		opts.Tags = append(opts.Tags, transformer.MetadataTag(key, value))
+		pushed = true
+	}
+	sort.Strings(opts.Tags)
+
+	if !pushed {
+		return nil
+	}
+	return &opts
+}
+
+// page orders the flags by key and cuts out the requested page. It returns the cursor
+// of the next page when the page was limited and more flags follow.
+func (q manifestQuery) page(posthogFlags []models.PostHogFeatureFlag) ([]models.PostHogFeatureFlag, string) {
+	sorted := make([]models.PostHogFeatureFlag, len(posthogFlags))
+	copy(sorted, posthogFlags)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Key < sorted[j].Key
+	})
+
+	start := q.offset
+	if q.after != "" {
+		start = sort.Search(len(sorted), func(i int) bool {
+			return sorted[i].Key > q.after
+		})
+	}
+	if start > len(sorted) {
+		start = len(sorted)
+	}
+
+	end := len(sorted)
+	if q.limit > 0 && start+q.limit < end {
+		end = start + q.limit
+	}
+
+	var next string
+	if end < len(sorted) {
+		next = base64.RawURLEncoding.EncodeToString([]byte(sorted[end-1].Key))
+	}
+	return sorted[start:end], next
+}
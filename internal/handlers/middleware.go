@@ -1,10 +1,18 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/auth"
 	"github.com/openfeature/posthog-proxy/internal/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // AuthMiddleware validates the authorization token (optional in insecure mode)
@@ -15,6 +23,9 @@ func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 			// Grant all capabilities in insecure mode
 			c.Set("capabilities", []string{"read", "write", "delete"})
 			c.Set("insecure_mode", true)
+			c.Set("caller", "insecure-mode")
+			c.Set("caller_source", "insecure-mode")
+			h.identifyCaller(c, "insecure-mode", "insecure-mode")
 			c.Next()
 			return
 		}
@@ -40,19 +51,29 @@ func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Validate token and get capabilities
-		capabilities := h.validateToken(token)
-		if capabilities == nil {
+		// Resolve the token to the configured client it belongs to
+		caller, err := h.authenticator.Authenticate(c.Request.Context(), token, c.ClientIP())
+		var throttled *auth.ThrottledError
+		if errors.As(err, &throttled) {
+			h.rejectRateLimited(c, "auth", throttled.RetryAfter, err.Error())
+			return
+		}
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Code:    http.StatusUnauthorized,
 				Message: "Invalid authorization token",
+				Details: err.Error(),
 			})
 			c.Abort()
 			return
 		}
 
-		// Store capabilities in context
-		c.Set("capabilities", capabilities)
+		// Store capabilities, flag scope and the caller's name and source in context
+		c.Set("capabilities", caller.Capabilities)
+		c.Set("scopes", caller.Scopes)
+		c.Set("caller", caller.Name)
+		c.Set("caller_source", caller.Source)
+		h.identifyCaller(c, caller.Name, caller.Source)
 		c.Next()
 	}
 }
@@ -90,10 +111,53 @@ func (h *Handler) RequireCapability(capability string) gin.HandlerFunc {
 			return
 		}
 
+		// Routes naming a flag also require the flag to be within the token's scope
+		if key := c.Param("key"); key != "" && !keyInScope(callerScope(c), key) {
+			respondOutOfScope(c, key)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequestIDMiddleware tags every request with an ID, taken from the X-Request-ID
+// header when the client sent a usable one, and echoes it in the response
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+		c.Set("request_id", requestID)
+		c.Header("X-Request-ID", requestID)
 		c.Next()
 	}
 }
 
+// maxRequestIDLength caps client-supplied request IDs so they can't bloat logs
+const maxRequestIDLength = 128
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// identifyCaller tags the request's span with the name of the client making the
+// request. The per-caller request count is labelled with its source instead, since a
+// JWT names every user of the issuer.
+func (h *Handler) identifyCaller(c *gin.Context, caller, source string) {
+	ctx := c.Request.Context()
+	trace.SpanFromContext(ctx).SetAttributes(semconv.EnduserID(caller))
+	if h.metrics != nil {
+		h.metrics.AuthenticatedRequests.Add(ctx, 1, metric.WithAttributes(attribute.String("caller", source)))
+	}
+}
+
 // extractBearerToken extracts the token from "Bearer <token>" format
 func extractBearerToken(authHeader string) string {
 	if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
@@ -102,16 +166,6 @@ func extractBearerToken(authHeader string) string {
 	return ""
 }
 
-// validateToken validates a token and returns its capabilities
-func (h *Handler) validateToken(token string) []string {
-	for _, authToken := range h.config.Proxy.Auth.Tokens {
-		if authToken.Token == token {
-			return authToken.Capabilities
-		}
-	}
-	return nil
-}
-
 // hasCapability checks if a capability exists in the capabilities list
 func hasCapability(capabilities []string, required string) bool {
 	for _, cap := range capabilities {
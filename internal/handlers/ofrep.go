@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/evaluation"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/transformer"
+)
+
+// EvaluateFlag handles POST /ofrep/v1/evaluate/flags/:key
+func (h *Handler) EvaluateFlag(c *gin.Context) {
+	key := c.Param("key")
+
+	evalCtx, failure := parseOFREPContext(c, key)
+	if failure != nil {
+		c.JSON(http.StatusBadRequest, failure)
+		return
+	}
+
+	posthogFlags, err := h.posthogClient.GetFeatureFlags(c.Request.Context())
+	if err != nil {
+		if h.metrics != nil {
+			h.metrics.PostHogAPIErrors.Add(c.Request.Context(), 1)
+		}
+		if respondOFREPPostHogUnavailable(c, key, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.OFREPEvaluationFailure{
+			Key:          key,
+			ErrorCode:    models.OFREPErrorGeneral,
+			ErrorDetails: "Failed to retrieve feature flags from PostHog: " + err.Error(),
+		})
+		return
+	}
+
+	for _, flag := range posthogFlags {
+		if flag.Key == key && !flag.Deleted {
+			if h.metrics != nil {
+				h.metrics.FlagEvaluations.Add(c.Request.Context(), 1)
+			}
+			c.JSON(http.StatusOK, h.evaluateOFREP(flag, evalCtx))
+			return
+		}
+	}
+
+	c.JSON(http.StatusNotFound, models.OFREPEvaluationFailure{
+		Key:          key,
+		ErrorCode:    models.OFREPErrorFlagNotFound,
+		ErrorDetails: fmt.Sprintf("flag %q was not found", key),
+	})
+}
+
+// EvaluateFlags handles POST /ofrep/v1/evaluate/flags
+func (h *Handler) EvaluateFlags(c *gin.Context) {
+	evalCtx, failure := parseOFREPContext(c, "")
+	if failure != nil {
+		c.JSON(http.StatusBadRequest, failure)
+		return
+	}
+
+	posthogFlags, err := h.posthogClient.GetFeatureFlags(c.Request.Context())
+	if err != nil {
+		if h.metrics != nil {
+			h.metrics.PostHogAPIErrors.Add(c.Request.Context(), 1)
+		}
+		if respondOFREPPostHogUnavailable(c, "", err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.OFREPEvaluationFailure{
+			ErrorCode:    models.OFREPErrorGeneral,
+			ErrorDetails: "Failed to retrieve feature flags from PostHog: " + err.Error(),
+		})
+		return
+	}
+
+	posthogFlags = flagsInScope(c, posthogFlags)
+	sort.SliceStable(posthogFlags, func(i, j int) bool {
+		return posthogFlags[i].Key < posthogFlags[j].Key
+	})
+
+	response := models.OFREPBulkEvaluationResponse{
+		Flags: make([]interface{}, 0, len(posthogFlags)),
+	}
+	for _, flag := range posthogFlags {
+		if flag.Deleted {
+			continue
+		}
+		response.Flags = append(response.Flags, h.evaluateOFREP(flag, evalCtx))
+	}
+
+	if h.metrics != nil {
+		h.metrics.FlagEvaluations.Add(c.Request.Context(), int64(len(response.Flags)))
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.OFREPEvaluationFailure{
+			ErrorCode:    models.OFREPErrorGeneral,
+			ErrorDetails: err.Error(),
+		})
+		return
+	}
+
+	// OFREP providers poll the bulk endpoint and send the last ETag back
+	etag := contentETag(body)
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.AbortWithStatus(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// evaluateOFREP evaluates a PostHog flag and maps the result onto the OpenFeature
+// representation of the flag so variant values match what the manifest reports
+func (h *Handler) evaluateOFREP(flag models.PostHogFeatureFlag, evalCtx evaluation.Context) models.OFREPEvaluationSuccess {
+	manifestFlag := transformer.PostHogToOpenFeatureFlag(flag, h.config.FeatureFlags.TypeCoercion)
+	result := evaluation.Evaluate(flag, evalCtx)
+
+	success := models.OFREPEvaluationSuccess{
+		Key:      flag.Key,
+		Reason:   string(result.Reason),
+		Metadata: ofrepMetadata(manifestFlag, result),
+	}
+
+	switch {
+	case result.Variant != "":
+		success.Variant = result.Variant
+		success.Value = result.Variant
+		if variant, ok := manifestFlag.Variants[result.Variant]; ok {
+			success.Value = variant.Value
+		}
+	case manifestFlag.Type == models.FlagTypeBoolean:
+		success.Value = result.Match
+		success.Variant = strconv.FormatBool(result.Match)
+	default:
+		// Payload-backed flags carry a single value; when the flag doesn't match
+		// the caller receives the manifest default
+		success.Value = manifestFlag.DefaultValue
+	}
+
+	return success
+}
+
+// parseOFREPContext reads the evaluation context from an OFREP request body
+func parseOFREPContext(c *gin.Context, key string) (evaluation.Context, *models.OFREPEvaluationFailure) {
+	var req models.OFREPEvaluationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return evaluation.Context{}, &models.OFREPEvaluationFailure{
+			Key:          key,
+			ErrorCode:    models.OFREPErrorParse,
+			ErrorDetails: err.Error(),
+		}
+	}
+
+	targetingKey, _ := req.Context["targetingKey"].(string)
+	if targetingKey == "" {
+		return evaluation.Context{}, &models.OFREPEvaluationFailure{
+			Key:          key,
+			ErrorCode:    models.OFREPErrorTargetingKeyMissing,
+			ErrorDetails: "context.targetingKey is required to evaluate PostHog flags",
+		}
+	}
+
+	properties := make(map[string]interface{}, len(req.Context))
+	for name, value := range req.Context {
+		if name != "targetingKey" {
+			properties[name] = value
+		}
+	}
+
+	return evaluation.Context{DistinctID: targetingKey, Properties: properties}, nil
+}
+
+// ofrepMetadata exposes the manifest metadata (owner, domain, ...) as OFREP flag
+// metadata, along with reason_detail explaining which condition decided the result
+func ofrepMetadata(flag models.ManifestFlag, result evaluation.Result) map[string]interface{} {
+	metadata := make(map[string]interface{}, len(flag.Metadata)+1)
+	for key, value := range flag.Metadata {
+		metadata[key] = value
+	}
+	if result.Explanation != "" {
+		metadata["reason_detail"] = result.Explanation
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func ofrepTestFlags() []models.PostHogFeatureFlag {
+	return []models.PostHogFeatureFlag{
+		{
+			ID:     1,
+			Key:    "new-checkout",
+			Active: true,
+			Filters: models.PostHogFilters{
+				Groups: []models.PostHogFilterGroup{
+					{
+						Properties:        []models.PostHogProperty{{Key: "plan", Operator: "exact", Value: "pro"}},
+						RolloutPercentage: ptrInt(100),
+					},
+				},
+			},
+			Tags: []string{"owner:payments"},
+		},
+		{
+			ID:     2,
+			Key:    "button-color",
+			Active: true,
+			Filters: models.PostHogFilters{
+				Groups: []models.PostHogFilterGroup{{RolloutPercentage: ptrInt(100)}},
+				Multivariate: &models.PostHogMultivariate{
+					Variants: []models.PostHogVariant{{Key: "blue", RolloutFlag: 100}},
+				},
+				Payloads: map[string]string{"blue": `{"hex": "#0000ff"}`},
+			},
+		},
+		{
+			ID:     3,
+			Key:    "disabled-flag",
+			Active: false,
+			Filters: models.PostHogFilters{
+				Groups: []models.PostHogFilterGroup{{RolloutPercentage: ptrInt(100)}},
+			},
+		},
+	}
+}
+
+func performOFREP(handler gin.HandlerFunc, key string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	if key != "" {
+		c.Params = gin.Params{{Key: "key", Value: key}}
+	}
+	c.Request = httptest.NewRequest(http.MethodPost, "/ofrep/v1/evaluate/flags/"+key, bytes.NewReader(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		c.Request.Header.Set(name, value)
+	}
+	handler(c)
+	return w
+}
+
+func TestEvaluateFlag_BooleanTargetingMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(ofrepTestFlags(), nil)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	w := performOFREP(handler.EvaluateFlag, "new-checkout", map[string]interface{}{
+		"context": map[string]interface{}{"targetingKey": "user-1", "plan": "pro"},
+	}, nil)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response models.OFREPEvaluationSuccess
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "new-checkout", response.Key)
+	assert.Equal(t, true, response.Value)
+	assert.Equal(t, "true", response.Variant)
+	assert.Equal(t, "TARGETING_MATCH", response.Reason)
+	assert.Equal(t, "payments", response.Metadata["owner"])
+	assert.Equal(t, "condition 0 matched, 1 property filter(s) satisfied", response.Metadata["reason_detail"])
+}
+
+func TestEvaluateFlag_BooleanNoMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(ofrepTestFlags(), nil)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	w := performOFREP(handler.EvaluateFlag, "new-checkout", map[string]interface{}{
+		"context": map[string]interface{}{"targetingKey": "user-1", "plan": "free"},
+	}, nil)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response models.OFREPEvaluationSuccess
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, false, response.Value)
+	assert.Equal(t, "DEFAULT", response.Reason)
+	assert.Equal(t, `no condition matched (condition 0: property "plan" (free) does not satisfy exact pro)`, response.Metadata["reason_detail"])
+}
+
+func TestEvaluateFlag_MultivariateReturnsVariantValue(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(ofrepTestFlags(), nil)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	w := performOFREP(handler.EvaluateFlag, "button-color", map[string]interface{}{
+		"context": map[string]interface{}{"targetingKey": "user-1"},
+	}, nil)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response models.OFREPEvaluationSuccess
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "blue", response.Variant)
+	assert.Equal(t, map[string]interface{}{"hex": "#0000ff"}, response.Value)
+	assert.Equal(t, "SPLIT", response.Reason)
+}
+
+func TestEvaluateFlag_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		key       string
+		body      interface{}
+		flagsErr  error
+		status    int
+		errorCode string
+	}{
+		{
+			name:      "missing targeting key",
+			key:       "new-checkout",
+			body:      map[string]interface{}{"context": map[string]interface{}{"plan": "pro"}},
+			status:    http.StatusBadRequest,
+			errorCode: models.OFREPErrorTargetingKeyMissing,
+		},
+		{
+			name:      "invalid body",
+			key:       "new-checkout",
+			body:      "not an object",
+			status:    http.StatusBadRequest,
+			errorCode: models.OFREPErrorParse,
+		},
+		{
+			name:      "unknown flag",
+			key:       "does-not-exist",
+			body:      map[string]interface{}{"context": map[string]interface{}{"targetingKey": "user-1"}},
+			status:    http.StatusNotFound,
+			errorCode: models.OFREPErrorFlagNotFound,
+		},
+		{
+			name:      "PostHog failure",
+			key:       "new-checkout",
+			body:      map[string]interface{}{"context": map[string]interface{}{"targetingKey": "user-1"}},
+			flagsErr:  errors.New("connection refused"),
+			status:    http.StatusInternalServerError,
+			errorCode: models.OFREPErrorGeneral,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(posthog.MockClient)
+			if tt.flagsErr != nil {
+				mockClient.On("GetFeatureFlags", mock.Anything).Return(nil, tt.flagsErr)
+			} else {
+				mockClient.On("GetFeatureFlags", mock.Anything).Return(ofrepTestFlags(), nil)
+			}
+			handler := NewHandler(mockClient, &config.Config{}, nil)
+
+			w := performOFREP(handler.EvaluateFlag, tt.key, tt.body, nil)
+
+			assert.Equal(t, tt.status, w.Code)
+			var response models.OFREPEvaluationFailure
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.key, response.Key)
+			assert.Equal(t, tt.errorCode, response.ErrorCode)
+		})
+	}
+}
+
+func TestEvaluateFlags_Bulk(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(ofrepTestFlags(), nil)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	body := map[string]interface{}{
+		"context": map[string]interface{}{"targetingKey": "user-1", "plan": "pro"},
+	}
+
+	w := performOFREP(handler.EvaluateFlags, "", body, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Flags []models.OFREPEvaluationSuccess `json:"flags"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Flags, 3)
+
+	// Results are ordered by key
+	assert.Equal(t, "button-color", response.Flags[0].Key)
+	assert.Equal(t, "disabled-flag", response.Flags[1].Key)
+	assert.Equal(t, "DISABLED", response.Flags[1].Reason)
+	assert.Equal(t, false, response.Flags[1].Value)
+	assert.Equal(t, "new-checkout", response.Flags[2].Key)
+	assert.Equal(t, true, response.Flags[2].Value)
+
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	notModified := performOFREP(handler.EvaluateFlags, "", body, map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// globalRateLimitKey is the single bucket of the global limiter
+const globalRateLimitKey = "global"
+
+// RateLimit middleware throttles the caller against the given budget ("read" or
+// "write") and then against the global budget shared by every caller. It must run
+// after AuthMiddleware so the caller is known.
+func (h *Handler) RateLimit(budget string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter := h.rateLimiters[budget]; limiter != nil {
+			key := rateLimitKey(c)
+			if ok, wait := limiter.Allow(key); !ok {
+				h.rejectRateLimited(c, budget, wait, fmt.Sprintf("%s budget of %g requests/second exceeded for %s", budget, limiter.Rate(), key))
+				return
+			}
+		}
+
+		if limiter := h.rateLimiters[globalRateLimitKey]; limiter != nil {
+			if ok, wait := limiter.Allow(globalRateLimitKey); !ok {
+				h.rejectRateLimited(c, globalRateLimitKey, wait, "the proxy is receiving more requests than PostHog can be sent")
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitKey identifies whose bucket a request draws from: the authenticated caller,
+// or the client IP when every request shares one identity as in insecure mode
+func rateLimitKey(c *gin.Context) string {
+	if caller := c.GetString("caller"); caller != "" && !c.GetBool("insecure_mode") {
+		return "caller:" + caller
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func (h *Handler) rejectRateLimited(c *gin.Context, budget string, wait time.Duration, details string) {
+	if h.metrics != nil {
+		h.metrics.RateLimited.Add(c.Request.Context(), 1, metric.WithAttributes(
+			attribute.String("budget", budget),
+			attribute.String("caller", c.GetString("caller_source")),
+		))
+	}
+
+	c.Header("Retry-After", retryAfterSeconds(wait))
+	c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+		Code:    http.StatusTooManyRequests,
+		Message: "Rate limit exceeded",
+		Details: details,
+	})
+	c.Abort()
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRateLimitedRouter(limiters map[string]*ratelimit.Limiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Proxy.Auth.Tokens = []config.AuthToken{
+		{Name: "dashboard", Token: "dashboard-secret", Capabilities: []string{"read", "write"}},
+		{Name: "ci", Token: "ci-secret", Capabilities: []string{"read", "write"}},
+	}
+	handler := NewHandler(nil, cfg, nil, WithRateLimiters(limiters))
+
+	router := gin.New()
+	router.Use(handler.AuthMiddleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router.GET("/read", handler.RateLimit("read"), ok)
+	router.PUT("/write", handler.RateLimit("write"), ok)
+	return router
+}
+
+func performRateLimited(router *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit_PerCallerBudgets(t *testing.T) {
+	router := newRateLimitedRouter(map[string]*ratelimit.Limiter{
+		"read":  ratelimit.New(0.5, 2),
+		"write": ratelimit.New(0.5, 1),
+	})
+
+	assert.Equal(t, http.StatusNoContent, performRateLimited(router, http.MethodGet, "/read", "ci-secret").Code)
+	assert.Equal(t, http.StatusNoContent, performRateLimited(router, http.MethodGet, "/read", "ci-secret").Code)
+
+	w := performRateLimited(router, http.MethodGet, "/read", "ci-secret")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "read budget")
+
+	// The write budget and other callers are unaffected
+	assert.Equal(t, http.StatusNoContent, performRateLimited(router, http.MethodPut, "/write", "ci-secret").Code)
+	assert.Equal(t, http.StatusNoContent, performRateLimited(router, http.MethodGet, "/read", "dashboard-secret").Code)
+}
+
+func TestRateLimit_GlobalBudget(t *testing.T) {
+	router := newRateLimitedRouter(map[string]*ratelimit.Limiter{
+		"global": ratelimit.New(1, 2),
+	})
+
+	assert.Equal(t, http.StatusNoContent, performRateLimited(router, http.MethodGet, "/read", "ci-secret").Code)
+	assert.Equal(t, http.StatusNoContent, performRateLimited(router, http.MethodPut, "/write", "dashboard-secret").Code)
+
+	w := performRateLimited(router, http.MethodGet, "/read", "dashboard-secret")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+}
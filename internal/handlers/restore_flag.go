@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/audit"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/transformer"
+)
+
+// RestoreFlag handles POST /openfeature/v0/manifest/flags/:key/restore. It un-archives
+// a flag archived by DeleteFlag, putting back the active state it had before.
+func (h *Handler) RestoreFlag(c *gin.Context) {
+	key := c.Param("key")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Flag key is required",
+		})
+		return
+	}
+
+	existingFlag, err := h.posthogClient.GetFeatureFlagByKey(c.Request.Context(), key)
+	if err != nil {
+		if h.metrics != nil {
+			h.metrics.PostHogAPIErrors.Add(c.Request.Context(), 1)
+		}
+		if respondPostHogUnavailable(c, err) {
+			return
+		}
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Code:    http.StatusNotFound,
+			Message: "Feature flag not found",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	// Only flags archived by the proxy record the state to go back to
+	if transformer.ArchivedAt(*existingFlag) == nil {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Code:    http.StatusConflict,
+			Message: "Feature flag is not archived",
+			Details: "use PUT /openfeature/v0/manifest/flags/" + key + " to change the state of a flag that is not archived",
+		})
+		return
+	}
+
+	archivedFlag := transformer.PostHogToOpenFeatureFlag(*existingFlag, h.config.FeatureFlags.TypeCoercion)
+	restoredFlag, err := h.posthogClient.UpdateFeatureFlag(c.Request.Context(), existingFlag.ID, transformer.RestoreUpdate(*existingFlag))
+	if err != nil {
+		if h.metrics != nil {
+			h.metrics.PostHogAPIErrors.Add(c.Request.Context(), 1)
+		}
+		h.recordAudit(c, audit.ActionRestore, key, &archivedFlag, nil, err)
+		if respondPostHogUnavailable(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to restore feature flag in PostHog",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	if h.metrics != nil {
+		h.metrics.FlagsUpdated.Add(c.Request.Context(), 1)
+	}
+
+	openFeatureFlag := transformer.PostHogToOpenFeatureFlag(*restoredFlag, h.config.FeatureFlags.TypeCoercion)
+	h.recordAudit(c, audit.ActionRestore, key, &archivedFlag, &openFeatureFlag, nil)
+
+	response := models.ManifestFlagResponse{
+		Flag:      openFeatureFlag,
+		UpdatedAt: restoredFlag.UpdatedAt,
+	}
+
+	// Add X-Manifest-Capabilities header per spec
+	c.Header("X-Manifest-Capabilities", "read,write,delete")
+	c.Header("ETag", flagETag(restoredFlag))
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func performRestore(handler *Handler, key string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{gin.Param{Key: "key", Value: key}}
+	c.Request = httptest.NewRequest(http.MethodPost, "/openfeature/v0/manifest/flags/"+key+"/restore", nil)
+	handler.RestoreFlag(c)
+	return w
+}
+
+func TestRestoreFlag_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlagByKey", mock.Anything, "checkout").Return(&models.PostHogFeatureFlag{
+		ID:     7,
+		Key:    "checkout",
+		Active: false,
+		Tags:   []string{"owner:payments", "archived-at:2026-03-01T10:00:00Z", "archived-state:ENABLED"},
+	}, nil)
+	mockClient.On("UpdateFeatureFlag", mock.Anything, 7, mock.MatchedBy(func(req models.PostHogUpdateFlagRequest) bool {
+		return req.Active != nil && *req.Active && req.Tags != nil && len(*req.Tags) == 1 && (*req.Tags)[0] == "owner:payments"
+	})).Return(&models.PostHogFeatureFlag{ID: 7, Key: "checkout", Active: true, Tags: []string{"owner:payments"}}, nil)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	w := performRestore(handler, "checkout")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+	var response models.ManifestFlagResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.FlagStateEnabled, response.Flag.State)
+	assert.Nil(t, response.Flag.ArchivedAt)
+	mockClient.AssertExpectations(t)
+}
+
+func TestRestoreFlag_NotArchived(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlagByKey", mock.Anything, "checkout").Return(&models.PostHogFeatureFlag{
+		ID:     7,
+		Key:    "checkout",
+		Active: false,
+	}, nil)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	w := performRestore(handler, "checkout")
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockClient.AssertNotCalled(t, "UpdateFeatureFlag", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRestoreFlag_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlagByKey", mock.Anything, "missing").Return(nil, errors.New("PostHog API error (status 404): Not found"))
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	w := performRestore(handler, "missing")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/stream"
+)
+
+// A caller's scope is the list of flag key patterns its token is restricted to, stored
+// in the context by AuthMiddleware. An empty scope means every flag.
+
+// callerScope returns the flag key patterns the caller is restricted to
+func callerScope(c *gin.Context) []string {
+	scope, _ := c.Get("scopes")
+	patterns, _ := scope.([]string)
+	return patterns
+}
+
+// keyInScope reports whether a flag key matches one of the patterns. Patterns are
+// globs, so "checkout-*" covers every key with that prefix.
+func keyInScope(scope []string, key string) bool {
+	if len(scope) == 0 {
+		return true
+	}
+	for _, pattern := range scope {
+		if matched, err := path.Match(pattern, key); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// flagsInScope drops the PostHog flags the caller may not see
+func flagsInScope(c *gin.Context, posthogFlags []models.PostHogFeatureFlag) []models.PostHogFeatureFlag {
+	scope := callerScope(c)
+	if len(scope) == 0 {
+		return posthogFlags
+	}
+	filtered := make([]models.PostHogFeatureFlag, 0, len(posthogFlags))
+	for _, phFlag := range posthogFlags {
+		if keyInScope(scope, phFlag.Key) {
+			filtered = append(filtered, phFlag)
+		}
+	}
+	return filtered
+}
+
+// manifestFlagsOutOfScope returns the keys of submitted flags the caller may not touch
+func manifestFlagsOutOfScope(c *gin.Context, flags []models.ManifestFlag) []string {
+	scope := callerScope(c)
+	var outside []string
+	for _, flag := range flags {
+		if !keyInScope(scope, flag.Key) {
+			outside = append(outside, flag.Key)
+		}
+	}
+	return outside
+}
+
+// respondOutOfScope rejects a request for flags outside the caller's scope
+func respondOutOfScope(c *gin.Context, keys ...string) {
+	c.JSON(http.StatusForbidden, models.ErrorResponse{
+		Code:    http.StatusForbidden,
+		Message: "Flag key is outside the token's scope",
+		Details: fmt.Sprintf("token may not access %v", keys),
+	})
+}
+
+// eventInScope narrows a manifest stream event to the caller's scope, reporting false
+// when nothing in it is visible to them
+func eventInScope(scope []string, event stream.Event) (stream.Event, bool) {
+	if len(scope) == 0 {
+		return event, true
+	}
+	switch data := event.Data.(type) {
+	case models.Manifest:
+		flags := make([]models.ManifestFlag, 0, len(data.Flags))
+		for _, flag := range data.Flags {
+			if keyInScope(scope, flag.Key) {
+				flags = append(flags, flag)
+			}
+		}
+		data.Flags = flags
+		event.Data = data
+		return event, true
+	case models.ManifestFlag:
+		return event, keyInScope(scope, data.Key)
+	case stream.FlagRemoved:
+		return event, keyInScope(scope, data.Key)
+	}
+	return event, true
+}
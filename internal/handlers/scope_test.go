@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/openfeature/posthog-proxy/internal/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyInScope(t *testing.T) {
+	tests := []struct {
+		scope []string
+		key   string
+		want  bool
+	}{
+		{scope: nil, key: "anything", want: true},
+		{scope: []string{"checkout-*"}, key: "checkout-button", want: true},
+		{scope: []string{"checkout-*"}, key: "search-ranking", want: false},
+		{scope: []string{"search-*", "checkout-v?"}, key: "checkout-v2", want: true},
+		{scope: []string{"exact-key"}, key: "exact-key", want: true},
+		{scope: []string{"["}, key: "[", want: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, keyInScope(tt.scope, tt.key), "scope %v, key %q", tt.scope, tt.key)
+	}
+}
+
+func TestEventInScope(t *testing.T) {
+	scope := []string{"checkout-*"}
+
+	snapshot, visible := eventInScope(scope, stream.Event{Type: stream.EventSnapshot, Data: models.Manifest{Flags: []models.ManifestFlag{
+		{Key: "checkout-button"}, {Key: "search-ranking"},
+	}}})
+	require.True(t, visible)
+	assert.Equal(t, []models.ManifestFlag{{Key: "checkout-button"}}, snapshot.Data.(models.Manifest).Flags)
+
+	_, visible = eventInScope(scope, stream.Event{Type: stream.EventFlagChanged, Data: models.ManifestFlag{Key: "search-ranking"}})
+	assert.False(t, visible)
+	_, visible = eventInScope(scope, stream.Event{Type: stream.EventFlagRemoved, Data: stream.FlagRemoved{Key: "checkout-button"}})
+	assert.True(t, visible)
+}
+
+func newScopedRouter(mockClient *posthog.MockClient) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Proxy.Auth.Tokens = []config.AuthToken{{
+		Name:         "payments-ci",
+		Token:        "payments-secret",
+		Capabilities: []string{"read", "write", "delete"},
+		Scopes:       []string{"checkout-*"},
+	}}
+	handler := NewHandler(mockClient, cfg, nil)
+
+	router := gin.New()
+	api := router.Group("/openfeature/v0", handler.AuthMiddleware())
+	api.GET("/manifest", handler.RequireCapability("read"), handler.GetManifest)
+	api.PUT("/manifest/flags/:key", handler.RequireCapability("write"), handler.UpdateFlag)
+	api.PUT("/manifest", handler.RequireCapability("write"), handler.RequireCapability("delete"), handler.ImportManifest)
+	return router
+}
+
+func scopeTestFlags() []models.PostHogFeatureFlag {
+	rollout := ptrInt(100)
+	return []models.PostHogFeatureFlag{
+		{ID: 1, Key: "checkout-button", Name: "Checkout", Active: true, Filters: models.PostHogFilters{Groups: []models.PostHogFilterGroup{{RolloutPercentage: rollout}}}},
+		{ID: 2, Key: "search-ranking", Name: "Search", Active: true, Filters: models.PostHogFilters{Groups: []models.PostHogFilterGroup{{RolloutPercentage: rollout}}}},
+	}
+}
+
+func performScoped(router *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer payments-secret")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestScope_GetManifestFiltersFlags(t *testing.T) {
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(scopeTestFlags(), nil)
+
+	w := performScoped(newScopedRouter(mockClient), http.MethodGet, "/openfeature/v0/manifest", nil)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var manifest models.Manifest
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &manifest))
+	require.Len(t, manifest.Flags, 1)
+	assert.Equal(t, "checkout-button", manifest.Flags[0].Key)
+}
+
+func TestScope_UpdateOutsideScopeForbidden(t *testing.T) {
+	mockClient := new(posthog.MockClient)
+
+	w := performScoped(newScopedRouter(mockClient), http.MethodPut, "/openfeature/v0/manifest/flags/search-ranking", map[string]string{"description": "x"})
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "outside the token's scope")
+	mockClient.AssertNotCalled(t, "GetFeatureFlagByKey", mock.Anything, mock.Anything)
+}
+
+func TestScope_ImportOnlyTouchesScopedFlags(t *testing.T) {
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(scopeTestFlags(), nil)
+	router := newScopedRouter(mockClient)
+
+	// search-ranking is missing from the manifest but outside the scope, so it is not archived
+	w := performScoped(router, http.MethodPut, "/openfeature/v0/manifest?dryRun=true", models.Manifest{Flags: []models.ManifestFlag{
+		{Key: "checkout-button", Description: "Checkout", Type: models.FlagTypeBoolean, DefaultValue: true},
+	}})
+	require.Equal(t, http.StatusOK, w.Code)
+	response, results := resultsByKey(t, w)
+	assert.Equal(t, models.ImportSummary{Unchanged: 1}, response.Summary)
+	assert.NotContains(t, results, "search-ranking")
+
+	w = performScoped(router, http.MethodPut, "/openfeature/v0/manifest?dryRun=true", models.Manifest{Flags: []models.ManifestFlag{
+		{Key: "search-ranking", Type: models.FlagTypeBoolean, DefaultValue: true},
+	}})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "search-ranking")
+}
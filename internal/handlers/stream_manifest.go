@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/stream"
+)
+
+const (
+	// streamKeepaliveInterval keeps idle connections from being closed by proxies
+	streamKeepaliveInterval = 15 * time.Second
+	// streamRetryMillis is the reconnect delay suggested to EventSource clients
+	streamRetryMillis = 5000
+)
+
+// StreamManifest handles GET /openfeature/v0/manifest/stream
+func (h *Handler) StreamManifest(c *gin.Context) {
+	if h.watcher == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Code:    http.StatusNotFound,
+			Message: "Manifest streaming is not enabled",
+		})
+		return
+	}
+
+	subscription, err := h.watcher.Subscribe(c.GetHeader("Last-Event-ID"))
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: "Manifest stream is not ready",
+			Details: err.Error(),
+		})
+		return
+	}
+	defer subscription.Close()
+
+	if h.metrics != nil {
+		h.metrics.StreamConnections.Add(c.Request.Context(), 1)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Header("X-Manifest-Capabilities", "read,write,delete")
+	c.Status(http.StatusOK)
+
+	if _, err := fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetryMillis); err != nil {
+		return
+	}
+	scope := callerScope(c)
+	for _, event := range subscription.Initial {
+		event, visible := eventInScope(scope, event)
+		if !visible {
+			continue
+		}
+		if err := writeSSEEvent(c.Writer, event); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	keepalive := time.NewTicker(streamKeepaliveInterval)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// The client fell behind; it will resume from history using Last-Event-ID
+				return
+			}
+			event, visible := eventInScope(scope, event)
+			if !visible {
+				continue
+			}
+			if err := writeSSEEvent(c.Writer, event); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-keepalive.C:
+			if _, err := io.WriteString(c.Writer, ": keepalive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeSSEEvent writes an event in the text/event-stream format
+func writeSSEEvent(w io.Writer, event stream.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/openfeature/posthog-proxy/internal/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// readSSEEvent reads the next event from a text/event-stream, skipping comments and retry hints
+func readSSEEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	t.Helper()
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			if event.event != "" {
+				return event
+			}
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamManifest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rollout := 100
+	initial := []models.PostHogFeatureFlag{
+		{ID: 1, Key: "checkout", Active: true, Filters: models.PostHogFilters{Groups: []models.PostHogFilterGroup{{RolloutPercentage: &rollout}}}},
+	}
+	updated := append(initial, models.PostHogFeatureFlag{
+		ID: 2, Key: "search", Active: true, Filters: models.PostHogFilters{Groups: []models.PostHogFilterGroup{{RolloutPercentage: &rollout}}},
+	})
+
+	mockClient := new(posthog.MockClient)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(initial, nil).Once()
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(updated, nil)
+
+	watcher := stream.NewWatcher(mockClient, config.TypeCoercionConfig{}, time.Hour, 0)
+	require.NoError(t, watcher.Refresh(context.Background()))
+
+	cfg := &config.Config{Proxy: config.ProxyConfig{Auth: config.AuthConfig{Tokens: []config.AuthToken{
+		{Token: "read-token", Capabilities: []string{"read"}},
+		{Token: "write-only", Capabilities: []string{"write"}},
+	}}}}
+	handler := NewHandler(mockClient, cfg, nil, WithManifestWatcher(watcher))
+
+	router := gin.New()
+	router.GET("/manifest/stream", handler.AuthMiddleware(), handler.RequireCapability("read"), handler.StreamManifest)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/manifest/stream", nil)
+	req.Header.Set("Authorization", "Bearer read-token")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	snapshot := readSSEEvent(t, reader)
+	assert.Equal(t, "snapshot", snapshot.event)
+	assert.Contains(t, snapshot.data, `"key":"checkout"`)
+
+	require.NoError(t, watcher.Refresh(context.Background()))
+
+	added := readSSEEvent(t, reader)
+	assert.Equal(t, "flag-added", added.event)
+	assert.Contains(t, added.data, `"key":"search"`)
+	cancel()
+
+	// Reconnecting with the last seen ID resumes without a new snapshot
+	resumeReq, _ := http.NewRequest(http.MethodGet, server.URL+"/manifest/stream", nil)
+	resumeReq.Header.Set("Authorization", "Bearer read-token")
+	resumeReq.Header.Set("Last-Event-ID", snapshot.id)
+	resumeCtx, resumeCancel := context.WithCancel(context.Background())
+	defer resumeCancel()
+	resumed, err := http.DefaultClient.Do(resumeReq.WithContext(resumeCtx))
+	require.NoError(t, err)
+	defer resumed.Body.Close()
+
+	replayed := readSSEEvent(t, bufio.NewReader(resumed.Body))
+	assert.Equal(t, "flag-added", replayed.event)
+	assert.Equal(t, added.id, replayed.id)
+
+	// The stream honours the read capability
+	forbiddenReq, _ := http.NewRequest(http.MethodGet, server.URL+"/manifest/stream", nil)
+	forbiddenReq.Header.Set("Authorization", "Bearer write-only")
+	forbidden, err := http.DefaultClient.Do(forbiddenReq)
+	require.NoError(t, err)
+	forbidden.Body.Close()
+	assert.Equal(t, http.StatusForbidden, forbidden.StatusCode)
+}
+
+func TestStreamManifest_NotEnabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(new(posthog.MockClient), &config.Config{}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/openfeature/v0/manifest/stream", nil)
+
+	handler.StreamManifest(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
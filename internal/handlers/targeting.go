@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+// supportedOperators are the PostHog property operators accepted in targeting conditions
+var supportedOperators = map[string]struct{}{
+	"exact":          {},
+	"is_not":         {},
+	"icontains":      {},
+	"not_icontains":  {},
+	"regex":          {},
+	"not_regex":      {},
+	"gt":             {},
+	"gte":            {},
+	"lt":             {},
+	"lte":            {},
+	"is_set":         {},
+	"is_not_set":     {},
+	"is_date_before": {},
+	"is_date_after":  {},
+	"is_date_exact":  {},
+}
+
+// ValidateTargeting checks that targeting rules can be mapped to PostHog release conditions.
+// variants are the flag's variants after the request is applied; rule variant overrides must reference one of them.
+func ValidateTargeting(targeting *models.Targeting, variants map[string]models.Variant) error {
+	if targeting == nil {
+		return nil
+	}
+
+	for i, rule := range targeting.Rules {
+		if rule.RolloutPercentage != nil && (*rule.RolloutPercentage < 0 || *rule.RolloutPercentage > 100) {
+			return fmt.Errorf("rule %d: rolloutPercentage must be between 0 and 100", i)
+		}
+
+		if rule.Variant != "" {
+			if _, ok := variants[rule.Variant]; !ok {
+				return fmt.Errorf("rule %d: variant %q is not defined on the flag", i, rule.Variant)
+			}
+		}
+
+		for j, condition := range rule.Conditions {
+			if condition.Property == "" {
+				return fmt.Errorf("rule %d, condition %d: property is required", i, j)
+			}
+			if condition.Operator == "" {
+				continue
+			}
+			if _, ok := supportedOperators[condition.Operator]; !ok {
+				return fmt.Errorf("rule %d, condition %d: unsupported operator %q", i, j, condition.Operator)
+			}
+		}
+	}
+
+	return nil
+}
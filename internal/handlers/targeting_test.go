@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTargeting(t *testing.T) {
+	variants := map[string]models.Variant{"control": {}, "treatment": {}}
+
+	tests := []struct {
+		name      string
+		targeting *models.Targeting
+		wantErr   string
+	}{
+		{name: "nil targeting", targeting: nil},
+		{name: "no rules", targeting: &models.Targeting{}},
+		{
+			name: "valid rule",
+			targeting: &models.Targeting{Rules: []models.TargetingRule{{
+				Conditions:        []models.TargetingCondition{{Property: "plan", Operator: "exact", Value: "pro"}, {Property: "email"}},
+				RolloutPercentage: ptrInt(50),
+				Variant:           "treatment",
+			}}},
+		},
+		{
+			name:      "rollout out of range",
+			targeting: &models.Targeting{Rules: []models.TargetingRule{{RolloutPercentage: ptrInt(101)}}},
+			wantErr:   "rule 0: rolloutPercentage must be between 0 and 100",
+		},
+		{
+			name:      "unknown variant",
+			targeting: &models.Targeting{Rules: []models.TargetingRule{{Variant: "missing"}}},
+			wantErr:   `rule 0: variant "missing" is not defined on the flag`,
+		},
+		{
+			name:      "missing property",
+			targeting: &models.Targeting{Rules: []models.TargetingRule{{Conditions: []models.TargetingCondition{{Operator: "exact"}}}}},
+			wantErr:   "rule 0, condition 0: property is required",
+		},
+		{
+			name:      "unsupported operator",
+			targeting: &models.Targeting{Rules: []models.TargetingRule{{Conditions: []models.TargetingCondition{{Property: "plan", Operator: "startswith"}}}}},
+			wantErr:   `rule 0, condition 0: unsupported operator "startswith"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTargeting(tt.targeting, variants)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
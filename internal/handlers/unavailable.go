@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
+)
+
+// respondPostHogUnavailable answers 503 when err comes from the PostHog circuit breaker
+// being open, reporting whether it did. Without it a failing lookup would be reported
+// as the flag not existing.
+func respondPostHogUnavailable(c *gin.Context, err error) bool {
+	var open *posthog.CircuitOpenError
+	if !errors.As(err, &open) {
+		return false
+	}
+	c.Header("Retry-After", retryAfterSeconds(open.RetryAfter))
+	c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+		Code:    http.StatusServiceUnavailable,
+		Message: "PostHog is unavailable",
+		Details: err.Error(),
+	})
+	return true
+}
+
+// respondOFREPPostHogUnavailable is respondPostHogUnavailable for the OFREP endpoints,
+// which answer with evaluation failures
+func respondOFREPPostHogUnavailable(c *gin.Context, key string, err error) bool {
+	var open *posthog.CircuitOpenError
+	if !errors.As(err, &open) {
+		return false
+	}
+	c.Header("Retry-After", retryAfterSeconds(open.RetryAfter))
+	c.JSON(http.StatusServiceUnavailable, models.OFREPEvaluationFailure{
+		Key:          key,
+		ErrorCode:    models.OFREPErrorGeneral,
+		ErrorDetails: err.Error(),
+	})
+	return true
+}
+
+// retryAfterSeconds formats a wait for the Retry-After header, which only takes whole
+// seconds, rounding up so clients don't retry early
+func retryAfterSeconds(wait time.Duration) string {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFlag_CircuitOpenIsServiceUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+
+	openErr := &posthog.CircuitOpenError{RetryAfter: 2500 * time.Millisecond}
+	mockClient.On("GetFeatureFlagByKey", mock.Anything, "checkout-button").Return(nil, openErr)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "key", Value: "checkout-button"}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/openfeature/v0/manifest/flags/checkout-button", nil)
+	handler.GetFlag(c)
+
+	// The flag may well exist, so an open breaker must not be reported as a 404
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "3", w.Header().Get("Retry-After"))
+	var response models.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "PostHog is unavailable", response.Message)
+}
+
+func TestEvaluateFlag_CircuitOpenIsServiceUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient := new(posthog.MockClient)
+	handler := NewHandler(mockClient, &config.Config{}, nil)
+	mockClient.On("GetFeatureFlags", mock.Anything).Return(nil, &posthog.CircuitOpenError{RetryAfter: time.Second})
+
+	w := performOFREP(handler.EvaluateFlag, "new-checkout", map[string]interface{}{"context": map[string]interface{}{"targetingKey": "user-1"}}, nil)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	var failure models.OFREPEvaluationFailure
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &failure))
+	assert.Equal(t, "new-checkout", failure.Key)
+	assert.Equal(t, models.OFREPErrorGeneral, failure.ErrorCode)
+}
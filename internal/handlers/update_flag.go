@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/openfeature/posthog-proxy/internal/audit"
+	"github.com/openfeature/posthog-proxy/internal/manifestdiff"
 	"github.com/openfeature/posthog-proxy/internal/models"
 	"github.com/openfeature/posthog-proxy/internal/transformer"
 )
@@ -51,6 +54,9 @@ func (h *Handler) UpdateFlag(c *gin.Context) {
 		if h.metrics != nil {
 			h.metrics.PostHogAPIErrors.Add(c.Request.Context(), 1)
 		}
+		if respondPostHogUnavailable(c, err) {
+			return
+		}
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Code:    http.StatusNotFound,
 			Message: "Feature flag not found",
@@ -59,6 +65,54 @@ func (h *Handler) UpdateFlag(c *gin.Context) {
 		return
 	}
 
+	// Reject the update if the flag changed since the client read it
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && !etagMatches(ifMatch, flagETag(existingFlag)) {
+		c.Header("ETag", flagETag(existingFlag))
+		c.JSON(http.StatusPreconditionFailed, models.ErrorResponse{
+			Code:    http.StatusPreconditionFailed,
+			Message: "Feature flag was modified since it was last read",
+			Details: "If-Match " + ifMatch + " does not match current version " + flagETag(existingFlag),
+		})
+		return
+	}
+
+	currentFlag := transformer.PostHogToOpenFeatureFlag(*existingFlag, h.config.FeatureFlags.TypeCoercion)
+
+	// Values are checked against the requested type, or the flag's current type when unchanged
+	flagType := currentFlag.Type
+	if req.Type != nil {
+		normalized := req.Type.Normalize()
+		req.Type = &normalized
+		flagType = normalized
+	}
+	var variantsToCheck map[string]models.Variant
+	if req.Variants != nil {
+		variantsToCheck = *req.Variants
+	}
+	if err := ValidateFlagValues(flagType, req.DefaultValue, variantsToCheck); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid flag value",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	if req.Targeting != nil {
+		variants := currentFlag.Variants
+		if req.Variants != nil {
+			variants = *req.Variants
+		}
+		if err := ValidateTargeting(req.Targeting, variants); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Invalid targeting configuration",
+				Details: err.Error(),
+			})
+			return
+		}
+	}
+
 	// Transform OpenFeature update request to PostHog format
 	// Pass existing flag to preserve groups and other settings
 	posthogReq := transformer.OpenFeatureToPostHogUpdate(req, existingFlag)
@@ -69,6 +123,10 @@ func (h *Handler) UpdateFlag(c *gin.Context) {
 		if h.metrics != nil {
 			h.metrics.PostHogAPIErrors.Add(c.Request.Context(), 1)
 		}
+		h.recordAudit(c, audit.ActionUpdate, key, &currentFlag, nil, err)
+		if respondPostHogUnavailable(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
 			Message: "Failed to update feature flag in PostHog",
@@ -84,6 +142,13 @@ func (h *Handler) UpdateFlag(c *gin.Context) {
 	// Transform back to OpenFeature format
 	openFeatureFlag := transformer.PostHogToOpenFeatureFlag(*updatedFlag, h.config.FeatureFlags.TypeCoercion)
 
+	h.recordAudit(c, audit.ActionUpdate, key, &currentFlag, &openFeatureFlag, nil)
+	slog.InfoContext(c.Request.Context(), "Feature flag updated",
+		"key", key,
+		"caller", c.GetString("caller"),
+		"changes", manifestdiff.Flag(currentFlag, openFeatureFlag),
+	)
+
 	// Return ManifestFlagResponse according to spec
 	response := models.ManifestFlagResponse{
 		Flag:      openFeatureFlag,
@@ -92,6 +157,7 @@ func (h *Handler) UpdateFlag(c *gin.Context) {
 
 	// Add X-Manifest-Capabilities header per spec
 	c.Header("X-Manifest-Capabilities", "read,write,delete")
+	c.Header("ETag", flagETag(updatedFlag))
 
 	c.JSON(http.StatusOK, response)
 }
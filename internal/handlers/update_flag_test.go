@@ -610,3 +610,83 @@ func TestUpdateFlag_KeyToIDLookup(t *testing.T) {
 	// Verify we made both requests (GET by key, then PATCH by ID)
 	assert.Equal(t, 2, requestCount, "Should make 2 requests: GET by key, then PATCH by ID")
 }
+
+func TestUpdateFlag_IfMatch(t *testing.T) {
+	newOptimisticServer := func(t *testing.T, patched *bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rollout := 100
+			flag := models.PostHogFeatureFlag{
+				ID:      1,
+				Key:     "test-flag",
+				Name:    "Old Name",
+				Active:  true,
+				Version: 3,
+				Filters: models.PostHogFilters{
+					Groups: []models.PostHogFilterGroup{{RolloutPercentage: &rollout}},
+				},
+			}
+
+			if r.Method == http.MethodPatch {
+				*patched = true
+				var reqBody models.PostHogUpdateFlagRequest
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&reqBody))
+				flag.Name = *reqBody.Name
+				flag.Version = 4
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(flag)
+		}))
+	}
+
+	update := func(handler *Handler, ifMatch string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{"description": "Updated Name"})
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "key", Value: "test-flag"}}
+		c.Request = httptest.NewRequest(http.MethodPut, "/openfeature/v0/manifest/flags/test-flag", bytes.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			c.Request.Header.Set("If-Match", ifMatch)
+		}
+		handler.UpdateFlag(c)
+		return w
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	t.Run("matching version is applied", func(t *testing.T) {
+		patched := false
+		server := newOptimisticServer(t, &patched)
+		defer server.Close()
+
+		w := update(setupTestHandler(t, server), `"1-v3"`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, patched)
+		assert.Equal(t, `"1-v4"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("stale version is rejected", func(t *testing.T) {
+		patched := false
+		server := newOptimisticServer(t, &patched)
+		defer server.Close()
+
+		w := update(setupTestHandler(t, server), `"1-v2"`)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.False(t, patched, "PostHog must not be updated when the precondition fails")
+		assert.Equal(t, `"1-v3"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("missing If-Match keeps last-write-wins behaviour", func(t *testing.T) {
+		patched := false
+		server := newOptimisticServer(t, &patched)
+		defer server.Close()
+
+		w := update(setupTestHandler(t, server), "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, patched)
+	})
+}
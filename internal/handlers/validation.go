@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+// ValidateFlagValues checks that the default value and variant values match the flag type.
+// Variants without a value (weight-only updates) are not checked.
+func ValidateFlagValues(flagType models.FlagType, defaultValue interface{}, variants map[string]models.Variant) error {
+	flagType = flagType.Normalize()
+
+	switch flagType {
+	case models.FlagTypeBoolean, models.FlagTypeString, models.FlagTypeInteger, models.FlagTypeFloat, models.FlagTypeObject:
+	default:
+		return fmt.Errorf("unsupported flag type %q", flagType)
+	}
+
+	if defaultValue != nil {
+		if err := validateValue(flagType, defaultValue); err != nil {
+			return fmt.Errorf("defaultValue: %w", err)
+		}
+	}
+
+	for key, variant := range variants {
+		if variant.Value == nil {
+			continue
+		}
+		if err := validateValue(flagType, variant.Value); err != nil {
+			return fmt.Errorf("variant %q: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+func validateValue(flagType models.FlagType, value interface{}) error {
+	switch flagType {
+	case models.FlagTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("expected a boolean, got %T", value)
+		}
+	case models.FlagTypeString:
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("expected a string, got %T", value)
+		}
+	case models.FlagTypeInteger:
+		number, ok := toFloat(value)
+		if !ok {
+			return fmt.Errorf("expected an integer, got %T", value)
+		}
+		if number != math.Trunc(number) {
+			return fmt.Errorf("integer flags cannot have fractional values (%v)", number)
+		}
+	case models.FlagTypeFloat:
+		if _, ok := toFloat(value); !ok {
+			return fmt.Errorf("expected a number, got %T", value)
+		}
+	case models.FlagTypeObject:
+		if _, ok := value.(map[string]interface{}); !ok {
+			return fmt.Errorf("expected an object, got %T", value)
+		}
+	}
+	return nil
+}
+
+// toFloat accepts the numeric types produced by JSON decoding and by the transformer
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateFlagValues(t *testing.T) {
+	tests := []struct {
+		name         string
+		flagType     models.FlagType
+		defaultValue interface{}
+		variants     map[string]models.Variant
+		wantErr      string
+	}{
+		{name: "boolean", flagType: models.FlagTypeBoolean, defaultValue: true},
+		{name: "string", flagType: models.FlagTypeString, defaultValue: "control"},
+		{name: "integer", flagType: models.FlagTypeInteger, defaultValue: 10.0},
+		{name: "float", flagType: models.FlagTypeFloat, defaultValue: 0.25},
+		{name: "number alias", flagType: models.FlagTypeNumber, defaultValue: 0.25},
+		{name: "object", flagType: models.FlagTypeObject, defaultValue: map[string]interface{}{"a": 1.0}},
+		{name: "weight-only variants", flagType: models.FlagTypeInteger, variants: map[string]models.Variant{"a": {Weight: ptrInt(50)}}},
+		{
+			name:         "integer rejects fractional default",
+			flagType:     models.FlagTypeInteger,
+			defaultValue: 0.25,
+			wantErr:      "defaultValue: integer flags cannot have fractional values (0.25)",
+		},
+		{
+			name:     "integer rejects fractional variant",
+			flagType: models.FlagTypeInteger,
+			variants: map[string]models.Variant{"sample": {Value: 1.5}},
+			wantErr:  `variant "sample": integer flags cannot have fractional values (1.5)`,
+		},
+		{
+			name:         "float rejects strings",
+			flagType:     models.FlagTypeFloat,
+			defaultValue: "0.25",
+			wantErr:      "defaultValue: expected a number, got string",
+		},
+		{
+			name:         "boolean rejects strings",
+			flagType:     models.FlagTypeBoolean,
+			defaultValue: "true",
+			wantErr:      "defaultValue: expected a boolean, got string",
+		},
+		{
+			name:     "object rejects arrays",
+			flagType: models.FlagTypeObject,
+			variants: map[string]models.Variant{"list": {Value: []interface{}{"a"}}},
+			wantErr:  `variant "list": expected an object, got []interface {}`,
+		},
+		{
+			name:     "unknown type",
+			flagType: models.FlagType("decimal"),
+			wantErr:  `unsupported flag type "decimal"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFlagValues(tt.flagType, tt.defaultValue, tt.variants)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
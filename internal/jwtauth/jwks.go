@@ -0,0 +1,260 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// minRefreshInterval limits how often an unknown key ID makes the remote key set be
+// fetched again, so tokens with made-up key IDs can't hammer the issuer, whether or
+// not it is answering
+const minRefreshInterval = 30 * time.Second
+
+// jsonWebKey is the subset of RFC 7517 fields needed for RSA and EC signature keys
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey is a verification key from a key set
+type publicKey struct {
+	id  string
+	alg string // empty when the key set doesn't restrict the algorithm
+	key crypto.PublicKey
+}
+
+// parseKeySet decodes a JWKS document. Keys that aren't RSA or EC signature keys
+// are skipped, since a key set may also publish encryption keys.
+func parseKeySet(data []byte) ([]publicKey, error) {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("decoding JWKS: %w", err)
+	}
+
+	var keys []publicKey
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		var key crypto.PublicKey
+		var err error
+		switch jwk.Kty {
+		case "RSA":
+			key, err = rsaPublicKey(jwk)
+		case "EC":
+			key, err = ecPublicKey(jwk)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", jwk.Kid, err)
+		}
+		keys = append(keys, publicKey{id: jwk.Kid, alg: jwk.Alg, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS contains no RSA or EC signature keys")
+	}
+	return keys, nil
+}
+
+func rsaPublicKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil || len(n) == 0 {
+		return nil, fmt.Errorf("invalid RSA modulus")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, fmt.Errorf("invalid RSA exponent")
+	}
+	exponent := int(new(big.Int).SetBytes(e).Int64())
+	if exponent < 3 {
+		return nil, fmt.Errorf("invalid RSA exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}, nil
+}
+
+func ecPublicKey(jwk jsonWebKey) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	var checker ecdh.Curve
+	switch jwk.Crv {
+	case "P-256":
+		curve, checker = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, checker = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, checker = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported EC curve %q", jwk.Crv)
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+	x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+	y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+	if errX != nil || errY != nil || len(x) != size || len(y) != size {
+		return nil, fmt.Errorf("invalid EC coordinates")
+	}
+
+	// Going through crypto/ecdh rejects points that are not on the curve
+	uncompressed := append(append([]byte{4}, x...), y...)
+	if _, err := checker.NewPublicKey(uncompressed); err != nil {
+		return nil, fmt.Errorf("invalid EC point: %w", err)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
+
+// keySet finds verification keys by key ID
+type keySet interface {
+	lookup(ctx context.Context, kid string) []publicKey
+}
+
+// staticKeySet is a key set loaded once, from a local file
+type staticKeySet []publicKey
+
+func loadKeySetFile(path string) (staticKeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading JWKS file: %w", err)
+	}
+	keys, err := parseKeySet(data)
+	if err != nil {
+		return nil, err
+	}
+	return staticKeySet(keys), nil
+}
+
+func (s staticKeySet) lookup(_ context.Context, kid string) []publicKey {
+	return matchingKeys(s, kid)
+}
+
+// remoteKeySet fetches the key set from the issuer's JWKS URL, fetching it again when
+// a token names a key it doesn't have so signing key rotations are picked up
+type remoteKeySet struct {
+	url        string
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	keys        []publicKey
+	lastAttempt time.Time
+	// inflight is the fetch tokens with an unknown key are waiting for, if any
+	inflight *keyFetch
+}
+
+// keyFetch is a JWKS fetch shared by every lookup that needs it
+type keyFetch struct {
+	done chan struct{}
+}
+
+func newRemoteKeySet(url string, httpClient *http.Client) *remoteKeySet {
+	return &remoteKeySet{url: url, httpClient: httpClient}
+}
+
+// lookup returns the keys for kid, fetching the key set when it has no such key. The
+// fetch runs outside the lock and detached from the caller's context, so concurrent
+// lookups share one fetch and a cancelled request doesn't fail it for the others.
+func (r *remoteKeySet) lookup(ctx context.Context, kid string) []publicKey {
+	r.mu.Lock()
+	if keys := matchingKeys(r.keys, kid); len(keys) > 0 {
+		r.mu.Unlock()
+		return keys
+	}
+	call := r.inflight
+	if call == nil {
+		if time.Since(r.lastAttempt) < minRefreshInterval {
+			r.mu.Unlock()
+			return nil
+		}
+		call = &keyFetch{done: make(chan struct{})}
+		r.inflight = call
+		go r.refresh(context.WithoutCancel(ctx), call)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return matchingKeys(r.keys, kid)
+}
+
+// refresh fetches the key set for call. A failed fetch keeps the previous keys and
+// counts towards the refresh interval like a successful one, so a failing issuer is
+// not asked again for every token with an unknown key.
+func (r *remoteKeySet) refresh(ctx context.Context, call *keyFetch) {
+	keys, err := r.fetch(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to fetch JWKS", "url", r.url, "error", err)
+	}
+
+	r.mu.Lock()
+	r.lastAttempt = time.Now()
+	if err == nil {
+		r.keys = keys
+	}
+	r.inflight = nil
+	r.mu.Unlock()
+	close(call.done)
+}
+
+func (r *remoteKeySet) fetch(ctx context.Context) ([]publicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS endpoint returned status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	return parseKeySet(data)
+}
+
+// matchingKeys returns the key with the given ID, or every key when the token
+// doesn't name one
+func matchingKeys(keys []publicKey, kid string) []publicKey {
+	if kid == "" {
+		return keys
+	}
+	for _, key := range keys {
+		if key.id == kid {
+			return []publicKey{key}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+// Package jwtauth authenticates callers by JWTs issued by an OIDC provider. Tokens
+// are checked against the provider's signing keys, issuer, audience and validity
+// window, and the caller's capabilities are read from one of the token's claims.
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+)
+
+// clockSkew is how far the proxy's clock may disagree with the issuer's when checking
+// a token's validity window
+const clockSkew = 30 * time.Second
+
+// knownCapabilities are the capabilities a claim can grant directly
+var knownCapabilities = map[string]bool{"read": true, "write": true, "delete": true}
+
+var (
+	// ErrMalformed is returned for tokens that aren't a well-formed JWT
+	ErrMalformed = errors.New("malformed token")
+	// ErrSignature is returned when no configured key verifies the token's signature
+	ErrSignature = errors.New("invalid token signature")
+)
+
+// Identity is the caller a verified token belongs to
+type Identity struct {
+	Name string
+	// Source is the configured token's name, or the issuer for a JWT. Unlike the name
+	// of a JWT's subject it takes a handful of values, so it can label metrics.
+	Source       string
+	Capabilities []string
+	// Scopes are the flag key patterns the caller is restricted to; empty means all
+	Scopes []string
+}
+
+// Verifier validates JWT bearer tokens
+type Verifier struct {
+	config config.JWTConfig
+	keys   keySet
+	now    func() time.Time
+}
+
+// NewVerifier creates a verifier for the configured issuer. A JWKS file is loaded
+// immediately; a JWKS URL is fetched when the first token needs it.
+func NewVerifier(cfg config.JWTConfig) (*Verifier, error) {
+	v := &Verifier{config: cfg, now: time.Now}
+	if cfg.JWKSFile != "" {
+		keys, err := loadKeySetFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+	} else {
+		v.keys = newRemoteKeySet(cfg.JWKSURL, &http.Client{Timeout: 10 * time.Second})
+	}
+	return v, nil
+}
+
+// LooksLikeJWT reports whether a bearer token has the three dot-separated parts of a
+// compact JWT, so other tokens can be rejected without verifying them
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks the token's signature and claims and returns the caller's identity
+func (v *Verifier) Verify(ctx context.Context, token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	var hdr header
+	if err := decodeSegment(parts[0], &hdr); err != nil {
+		return nil, ErrMalformed
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range v.keys.lookup(ctx, hdr.Kid) {
+		if key.alg != "" && key.alg != hdr.Alg {
+			continue
+		}
+		if verifySignature(hdr.Alg, key.key, signed, signature) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrSignature
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrMalformed
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	name, _ := lookupClaim(claims, v.config.NameClaim).(string)
+	if name == "" {
+		return nil, fmt.Errorf("token has no %q claim", v.config.NameClaim)
+	}
+	identity := &Identity{
+		Name:         name,
+		Source:       v.config.Issuer,
+		Capabilities: v.capabilities(claims),
+	}
+	if v.config.ScopesClaim != "" {
+		identity.Scopes = claimValues(claims, v.config.ScopesClaim)
+	}
+	return identity, nil
+}
+
+// validateClaims checks the issuer, audience and validity window
+func (v *Verifier) validateClaims(claims map[string]interface{}) error {
+	if issuer, _ := claims["iss"].(string); issuer != v.config.Issuer {
+		return fmt.Errorf("unexpected token issuer %q", issuer)
+	}
+	if !hasAudience(claims["aud"], v.config.Audience) {
+		return fmt.Errorf("token is not issued for audience %q", v.config.Audience)
+	}
+
+	now := v.now()
+	expiry, ok := numericDate(claims["exp"])
+	if !ok {
+		return fmt.Errorf("token has no expiry")
+	}
+	if now.After(expiry.Add(clockSkew)) {
+		return fmt.Errorf("token expired at %s", expiry.UTC().Format(time.RFC3339))
+	}
+	if notBefore, ok := numericDate(claims["nbf"]); ok && now.Before(notBefore.Add(-clockSkew)) {
+		return fmt.Errorf("token is not valid before %s", notBefore.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// capabilities reads the configured claim and maps its values to capabilities
+func (v *Verifier) capabilities(claims map[string]interface{}) []string {
+	values := claimValues(claims, v.config.CapabilitiesClaim)
+	capabilities := []string{}
+	seen := make(map[string]bool)
+	grant := func(capability string) {
+		if knownCapabilities[capability] && !seen[capability] {
+			seen[capability] = true
+			capabilities = append(capabilities, capability)
+		}
+	}
+	for _, value := range values {
+		if len(v.config.CapabilityMapping) == 0 {
+			grant(value)
+			continue
+		}
+		for _, capability := range v.config.CapabilityMapping[value] {
+			grant(capability)
+		}
+	}
+	return capabilities
+}
+
+// claimValues reads a claim that may be a list or a space or comma separated string
+func claimValues(claims map[string]interface{}, path string) []string {
+	var values []string
+	switch claim := lookupClaim(claims, path).(type) {
+	case string:
+		values = strings.FieldsFunc(claim, func(r rune) bool { return r == ' ' || r == ',' })
+	case []interface{}:
+		for _, value := range claim {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+// lookupClaim resolves a dotted claim path such as "realm_access.roles"
+func lookupClaim(claims map[string]interface{}, path string) interface{} {
+	var value interface{} = claims
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return value
+}
+
+func hasAudience(aud interface{}, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, value := range aud {
+			if value == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func numericDate(value interface{}) (time.Time, bool) {
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// verifySignature checks a JWS signature. Only asymmetric algorithms are accepted, so
+// a token can't be signed with "none" or with the public key as an HMAC secret.
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "PS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	digest := hashBytes(hash, signed)
+
+	switch alg[:2] {
+	case "RS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrSignature
+		}
+		return rsa.VerifyPKCS1v15(rsaKey, hash, digest, signature)
+	case "PS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrSignature
+		}
+		return rsa.VerifyPSS(rsaKey, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	default:
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrSignature
+		}
+		// JWS encodes ECDSA signatures as the fixed-size concatenation of r and s
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return ErrSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return ErrSignature
+		}
+		return nil
+	}
+}
+
+func hashBytes(hash crypto.Hash, data []byte) []byte {
+	switch hash {
+	case crypto.SHA384:
+		sum := sha512.Sum384(data)
+		return sum[:]
+	case crypto.SHA512:
+		sum := sha512.Sum512(data)
+		return sum[:]
+	default:
+		sum := sha256.Sum256(data)
+		return sum[:]
+	}
+}
@@ -0,0 +1,312 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIssuer   = "https://sso.example.com"
+	testAudience = "posthog-proxy"
+)
+
+var testNow = time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32)))}
+}
+
+func jwks(t *testing.T, keys ...map[string]string) []byte {
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	require.NoError(t, err)
+	return data
+}
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return b64(data)
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	signed := encodeSegment(t, map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signed + "." + b64(signature)
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	signed := encodeSegment(t, map[string]string{"alg": "ES256", "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	return signed + "." + b64(append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...))
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":          testIssuer,
+		"aud":          []string{"other", testAudience},
+		"sub":          "user-123",
+		"email":        "dev@example.com",
+		"exp":          testNow.Add(time.Hour).Unix(),
+		"capabilities": "read write",
+	}
+}
+
+func newFileVerifier(t *testing.T, cfg config.JWTConfig, keySet []byte) *Verifier {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, keySet, 0o600))
+
+	cfg.Issuer = testIssuer
+	cfg.Audience = testAudience
+	cfg.JWKSFile = path
+	if cfg.CapabilitiesClaim == "" {
+		cfg.CapabilitiesClaim = "capabilities"
+	}
+	if cfg.NameClaim == "" {
+		cfg.NameClaim = "sub"
+	}
+	v, err := NewVerifier(cfg)
+	require.NoError(t, err)
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	v := newFileVerifier(t, config.JWTConfig{}, jwks(t, rsaJWK("rsa-1", &rsaKey.PublicKey), ecJWK("ec-1", &ecKey.PublicKey)))
+
+	t.Run("RS256", func(t *testing.T) {
+		identity, err := v.Verify(context.Background(), signRS256(t, rsaKey, "rsa-1", validClaims()))
+		require.NoError(t, err)
+		assert.Equal(t, "user-123", identity.Name)
+		assert.Equal(t, testIssuer, identity.Source)
+		assert.Equal(t, []string{"read", "write"}, identity.Capabilities)
+	})
+
+	t.Run("ES256", func(t *testing.T) {
+		identity, err := v.Verify(context.Background(), signES256(t, ecKey, "ec-1", validClaims()))
+		require.NoError(t, err)
+		assert.Equal(t, "user-123", identity.Name)
+	})
+
+	rejected := map[string]func() string{
+		"unknown key": func() string { return signRS256(t, otherKey, "rsa-1", validClaims()) },
+		"expired": func() string {
+			claims := validClaims()
+			claims["exp"] = testNow.Add(-time.Minute).Unix()
+			return signRS256(t, rsaKey, "rsa-1", claims)
+		},
+		"not yet valid": func() string {
+			claims := validClaims()
+			claims["nbf"] = testNow.Add(time.Minute).Unix()
+			return signRS256(t, rsaKey, "rsa-1", claims)
+		},
+		"no expiry": func() string {
+			claims := validClaims()
+			delete(claims, "exp")
+			return signRS256(t, rsaKey, "rsa-1", claims)
+		},
+		"wrong audience": func() string {
+			claims := validClaims()
+			claims["aud"] = "someone-else"
+			return signRS256(t, rsaKey, "rsa-1", claims)
+		},
+		"wrong issuer": func() string {
+			claims := validClaims()
+			claims["iss"] = "https://evil.example.com"
+			return signRS256(t, rsaKey, "rsa-1", claims)
+		},
+		"alg none": func() string {
+			return encodeSegment(t, map[string]string{"alg": "none", "kid": "rsa-1"}) + "." + encodeSegment(t, validClaims()) + "."
+		},
+		"tampered claims": func() string {
+			token := signRS256(t, rsaKey, "rsa-1", validClaims())
+			claims := validClaims()
+			claims["capabilities"] = "read write delete"
+			parts := strings.Split(token, ".")
+			return parts[0] + "." + encodeSegment(t, claims) + "." + parts[2]
+		},
+	}
+	for name, token := range rejected {
+		t.Run(name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), token())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestVerify_CapabilityMapping(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	v := newFileVerifier(t, config.JWTConfig{
+		CapabilitiesClaim: "realm_access.roles",
+		NameClaim:         "email",
+		CapabilityMapping: map[string][]string{
+			"flag-editors": {"read", "write"},
+			"flag-admins":  {"read", "write", "delete"},
+		},
+	}, jwks(t, rsaJWK("k1", &key.PublicKey)))
+
+	claims := validClaims()
+	claims["realm_access"] = map[string]interface{}{"roles": []string{"offline_access", "flag-editors"}}
+	identity, err := v.Verify(context.Background(), signRS256(t, key, "k1", claims))
+	require.NoError(t, err)
+	assert.Equal(t, "dev@example.com", identity.Name)
+	assert.Equal(t, []string{"read", "write"}, identity.Capabilities)
+
+	assert.Empty(t, identity.Scopes, "no scopes claim is configured")
+
+	// Values are only capabilities through the mapping once one is configured
+	claims["realm_access"] = map[string]interface{}{"roles": []string{"delete"}}
+	identity, err = v.Verify(context.Background(), signRS256(t, key, "k1", claims))
+	require.NoError(t, err)
+	assert.Empty(t, identity.Capabilities)
+}
+
+func TestVerify_Scopes(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	v := newFileVerifier(t, config.JWTConfig{ScopesClaim: "flag_scopes"}, jwks(t, rsaJWK("k1", &key.PublicKey)))
+
+	claims := validClaims()
+	claims["flag_scopes"] = []string{"checkout-*", "payments-*"}
+	identity, err := v.Verify(context.Background(), signRS256(t, key, "k1", claims))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"checkout-*", "payments-*"}, identity.Scopes)
+}
+
+func TestRemoteKeySet_Rotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var fetches atomic.Int32
+	current := jwks(t, rsaJWK("old", &oldKey.PublicKey))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_, _ = w.Write(current)
+	}))
+	defer server.Close()
+
+	v, err := NewVerifier(config.JWTConfig{Issuer: testIssuer, Audience: testAudience, JWKSURL: server.URL, CapabilitiesClaim: "capabilities", NameClaim: "sub"})
+	require.NoError(t, err)
+	v.now = func() time.Time { return testNow }
+	remote := v.keys.(*remoteKeySet)
+
+	_, err = v.Verify(context.Background(), signRS256(t, oldKey, "old", validClaims()))
+	require.NoError(t, err)
+	_, err = v.Verify(context.Background(), signRS256(t, oldKey, "old", validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), fetches.Load(), "known keys are served from memory")
+
+	// The issuer rotates its signing key; an unknown key ID triggers a refetch
+	current = jwks(t, rsaJWK("old", &oldKey.PublicKey), rsaJWK("new", &newKey.PublicKey))
+	_, err = v.Verify(context.Background(), signRS256(t, newKey, "new", validClaims()))
+	assert.ErrorIs(t, err, ErrSignature, "refetches are rate limited")
+
+	remote.lastAttempt = time.Time{}
+	_, err = v.Verify(context.Background(), signRS256(t, newKey, "new", validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestRemoteKeySet_DetachedFetch(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		if fail {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write(jwks(t, rsaJWK("current", &key.PublicKey)))
+	}))
+	defer server.Close()
+	remote := newRemoteKeySet(server.URL, server.Client())
+
+	// lookupAndCancel starts a lookup whose caller gives up while the fetch is running
+	lookupAndCancel := func(wantFetches int32) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan []publicKey)
+		go func() { done <- remote.lookup(ctx, "current") }()
+		require.Eventually(t, func() bool { return fetches.Load() == wantFetches }, time.Second, time.Millisecond)
+		cancel()
+		assert.Empty(t, <-done)
+		release <- struct{}{}
+		require.Eventually(t, func() bool {
+			remote.mu.Lock()
+			defer remote.mu.Unlock()
+			return remote.inflight == nil
+		}, time.Second, time.Millisecond)
+	}
+
+	// A failed fetch is throttled like a successful one
+	lookupAndCancel(1)
+	assert.False(t, remote.lastAttempt.IsZero())
+	assert.Empty(t, remote.lookup(context.Background(), "current"))
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// The fetch outlives the caller that started it, so later tokens find the key
+	fail = false
+	remote.lastAttempt = time.Time{}
+	lookupAndCancel(2)
+	assert.Len(t, remote.lookup(context.Background(), "current"), 1)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestParseKeySet_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"not json":           `keys`,
+		"no signature keys":  `{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`,
+		"point not on curve": `{"keys":[{"kty":"EC","crv":"P-256","x":"` + b64(make([]byte, 32)) + `","y":"` + b64(make([]byte, 32)) + `"}]}`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseKeySet([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
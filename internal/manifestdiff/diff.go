@@ -0,0 +1,180 @@
+// Package manifestdiff compares OpenFeature manifests field by field, reporting added
+// and removed flags and, for flags in both, every field that changed.
+package manifestdiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+// Top-level flag fields reported in a models.FieldChange
+const (
+	FieldType         = "type"
+	FieldDescription  = "description"
+	FieldDefaultValue = "defaultValue"
+	FieldVariants     = "variants"
+	FieldState        = "state"
+	FieldExpiry       = "expiry"
+	FieldMetadata     = "metadata"
+	FieldTargeting    = "targeting"
+
+	// FieldArchivedAt is not compared by Flag; an import reports it when it brings
+	// back a flag the proxy archived
+	FieldArchivedAt = "archivedAt"
+)
+
+// Manifests compares two manifests. Flags only in to are added, flags only in from
+// are removed and flags in both are changed when any field differs.
+func Manifests(from, to models.Manifest) models.ManifestDiff {
+	before := indexFlags(from.Flags)
+	after := indexFlags(to.Flags)
+
+	keys := make([]string, 0, len(before)+len(after))
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	diff := models.ManifestDiff{Flags: []models.FlagDiff{}}
+	for _, key := range keys {
+		oldFlag, inBefore := before[key]
+		newFlag, inAfter := after[key]
+
+		switch {
+		case !inBefore:
+			diff.Summary.Added++
+			diff.Flags = append(diff.Flags, models.FlagDiff{Key: key, Change: models.FlagAdded, Flag: &newFlag})
+		case !inAfter:
+			diff.Summary.Removed++
+			diff.Flags = append(diff.Flags, models.FlagDiff{Key: key, Change: models.FlagRemoved, Flag: &oldFlag})
+		default:
+			changes := Flag(oldFlag, newFlag)
+			if len(changes) == 0 {
+				diff.Summary.Unchanged++
+				continue
+			}
+			diff.Summary.Changed++
+			diff.Flags = append(diff.Flags, models.FlagDiff{Key: key, Change: models.FlagChanged, Changes: changes})
+		}
+	}
+
+	return diff
+}
+
+// Flag lists the fields that differ between two versions of a flag. Variants and
+// metadata are compared per entry, so a weight change is reported as
+// "variants.<key>.weight" rather than replacing the whole variant set. Values are
+// compared by their JSON encoding so 1 and 1.0, or nil and empty collections, are
+// not reported as changes.
+func Flag(from, to models.ManifestFlag) []models.FieldChange {
+	var changes []models.FieldChange
+	compare := func(field string, before, after interface{}) {
+		if !jsonEqual(before, after) {
+			changes = append(changes, models.FieldChange{Field: field, From: before, To: after})
+		}
+	}
+
+	compare(FieldType, from.Type.Normalize(), to.Type.Normalize())
+	compare(FieldDescription, from.Description, to.Description)
+	compare(FieldDefaultValue, from.DefaultValue, to.DefaultValue)
+
+	for _, key := range unionKeys(from.Variants, to.Variants) {
+		before, inBefore := from.Variants[key]
+		after, inAfter := to.Variants[key]
+		field := FieldVariants + "." + key
+		switch {
+		case !inBefore:
+			changes = append(changes, models.FieldChange{Field: field, To: after})
+		case !inAfter:
+			changes = append(changes, models.FieldChange{Field: field, From: before})
+		default:
+			compare(field+".value", before.Value, after.Value)
+			compare(field+".weight", before.Weight, after.Weight)
+		}
+	}
+
+	compare(FieldState, from.State, to.State)
+	compare(FieldExpiry, utcTime(from.Expiry), utcTime(to.Expiry))
+
+	for _, key := range unionKeys(from.Metadata, to.Metadata) {
+		before, inBefore := from.Metadata[key]
+		after, inAfter := to.Metadata[key]
+		change := models.FieldChange{Field: FieldMetadata + "." + key}
+		if inBefore {
+			change.From = before
+		}
+		if inAfter {
+			change.To = after
+		}
+		if !inBefore || !inAfter || before != after {
+			changes = append(changes, change)
+		}
+	}
+
+	compare(FieldTargeting, emptyTargetingToNil(from.Targeting), emptyTargetingToNil(to.Targeting))
+
+	return changes
+}
+
+// Section returns the top-level flag field a change belongs to, for example
+// "variants" for "variants.blue.weight"
+func Section(field string) string {
+	if i := strings.IndexByte(field, '.'); i >= 0 {
+		return field[:i]
+	}
+	return field
+}
+
+func indexFlags(flags []models.ManifestFlag) map[string]models.ManifestFlag {
+	index := make(map[string]models.ManifestFlag, len(flags))
+	for _, flag := range flags {
+		index[flag.Key] = flag
+	}
+	return index
+}
+
+// unionKeys returns the keys of both maps in sorted order
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func jsonEqual(a, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+func emptyTargetingToNil(targeting *models.Targeting) *models.Targeting {
+	if targeting == nil || len(targeting.Rules) == 0 {
+		return nil
+	}
+	return targeting
+}
+
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
@@ -0,0 +1,121 @@
+package manifestdiff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ptrInt(i int) *int {
+	return &i
+}
+
+func checkoutFlag() models.ManifestFlag {
+	return models.ManifestFlag{
+		Key:          "checkout",
+		Type:         models.FlagTypeString,
+		DefaultValue: "control",
+		Variants: map[string]models.Variant{
+			"control":   {Value: "control", Weight: ptrInt(50)},
+			"treatment": {Value: "treatment", Weight: ptrInt(50)},
+		},
+		State:    models.FlagStateEnabled,
+		Metadata: map[string]string{"owner": "payments"},
+	}
+}
+
+func TestFlag_NoChanges(t *testing.T) {
+	flag := checkoutFlag()
+	assert.Empty(t, Flag(flag, flag))
+}
+
+func TestFlag_IgnoresEquivalentValues(t *testing.T) {
+	from := models.ManifestFlag{Key: "limit", Type: models.FlagTypeNumber, DefaultValue: 1, Metadata: map[string]string{}}
+	to := models.ManifestFlag{Key: "limit", Type: models.FlagTypeFloat, DefaultValue: 1.0, Targeting: &models.Targeting{}}
+
+	assert.Empty(t, Flag(from, to))
+}
+
+func TestFlag_FieldChanges(t *testing.T) {
+	expiry := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	from := checkoutFlag()
+	to := checkoutFlag()
+	to.Type = models.FlagTypeString
+	to.DefaultValue = "treatment"
+	to.Variants = map[string]models.Variant{
+		"control":   {Value: "control", Weight: ptrInt(20)},
+		"treatment": {Value: "treatment-v2", Weight: ptrInt(40)},
+		"holdout":   {Value: "holdout", Weight: ptrInt(40)},
+	}
+	to.State = models.FlagStateDisabled
+	to.Expiry = &expiry
+	to.Metadata = map[string]string{"owner": "growth", "domain": "checkout"}
+
+	assert.Equal(t, []models.FieldChange{
+		{Field: "defaultValue", From: "control", To: "treatment"},
+		{Field: "variants.control.weight", From: ptrInt(50), To: ptrInt(20)},
+		{Field: "variants.holdout", From: nil, To: models.Variant{Value: "holdout", Weight: ptrInt(40)}},
+		{Field: "variants.treatment.value", From: "treatment", To: "treatment-v2"},
+		{Field: "variants.treatment.weight", From: ptrInt(50), To: ptrInt(40)},
+		{Field: "state", From: models.FlagStateEnabled, To: models.FlagStateDisabled},
+		{Field: "expiry", From: (*time.Time)(nil), To: &expiry},
+		{Field: "metadata.domain", From: nil, To: "checkout"},
+		{Field: "metadata.owner", From: "payments", To: "growth"},
+	}, Flag(from, to))
+}
+
+func TestFlag_RemovedVariantAndMetadata(t *testing.T) {
+	from := checkoutFlag()
+	to := checkoutFlag()
+	delete(to.Variants, "treatment")
+	to.Metadata = nil
+
+	changes := Flag(from, to)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "variants.treatment", changes[0].Field)
+	assert.Nil(t, changes[0].To)
+	assert.Equal(t, models.FieldChange{Field: "metadata.owner", From: "payments"}, changes[1])
+}
+
+func TestManifests(t *testing.T) {
+	changed := checkoutFlag()
+	changed.Description = "Checkout experiment"
+
+	from := models.Manifest{Flags: []models.ManifestFlag{
+		checkoutFlag(),
+		{Key: "legacy", Type: models.FlagTypeBoolean, DefaultValue: true, State: models.FlagStateEnabled},
+		{Key: "stable", Type: models.FlagTypeBoolean, DefaultValue: false, State: models.FlagStateEnabled},
+	}}
+	to := models.Manifest{Flags: []models.ManifestFlag{
+		{Key: "stable", Type: models.FlagTypeBoolean, DefaultValue: false, State: models.FlagStateEnabled},
+		{Key: "beta", Type: models.FlagTypeBoolean, DefaultValue: true, State: models.FlagStateEnabled},
+		changed,
+	}}
+
+	diff := Manifests(from, to)
+
+	assert.Equal(t, models.DiffSummary{Added: 1, Removed: 1, Changed: 1, Unchanged: 1}, diff.Summary)
+	require.Len(t, diff.Flags, 3)
+
+	assert.Equal(t, "beta", diff.Flags[0].Key)
+	assert.Equal(t, models.FlagAdded, diff.Flags[0].Change)
+	require.NotNil(t, diff.Flags[0].Flag)
+	assert.Equal(t, true, diff.Flags[0].Flag.DefaultValue)
+
+	assert.Equal(t, "checkout", diff.Flags[1].Key)
+	assert.Equal(t, models.FlagChanged, diff.Flags[1].Change)
+	assert.Equal(t, []models.FieldChange{{Field: "description", From: "", To: "Checkout experiment"}}, diff.Flags[1].Changes)
+
+	assert.Equal(t, "legacy", diff.Flags[2].Key)
+	assert.Equal(t, models.FlagRemoved, diff.Flags[2].Change)
+}
+
+func TestSection(t *testing.T) {
+	assert.Equal(t, FieldVariants, Section("variants.blue.weight"))
+	assert.Equal(t, FieldMetadata, Section("metadata.owner"))
+	assert.Equal(t, FieldState, Section("state"))
+}
@@ -0,0 +1,50 @@
+package models
+
+// Manifest diff models
+
+// FieldChange is a single field that differs between two versions of a flag. Nested
+// fields use dotted paths such as "variants.blue.weight" or "metadata.owner".
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// FlagChangeKind describes how a flag differs between two manifests
+type FlagChangeKind string
+
+const (
+	FlagAdded   FlagChangeKind = "added"
+	FlagRemoved FlagChangeKind = "removed"
+	FlagChanged FlagChangeKind = "changed"
+)
+
+// FlagDiff describes one flag that differs between two manifests. Added and removed
+// flags carry the flag itself; changed flags list their field changes.
+type FlagDiff struct {
+	Key     string         `json:"key"`
+	Change  FlagChangeKind `json:"change"`
+	Flag    *ManifestFlag  `json:"flag,omitempty"`
+	Changes []FieldChange  `json:"changes,omitempty"`
+}
+
+// DiffSummary counts the flags per kind of change
+type DiffSummary struct {
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+}
+
+// ManifestDiff is the difference between two manifests, ordered by flag key
+type ManifestDiff struct {
+	Summary DiffSummary `json:"summary"`
+	Flags   []FlagDiff  `json:"flags"`
+}
+
+// ManifestDiffRequest is the request body of POST /openfeature/v0/manifest/diff.
+// When From is omitted, To is compared against the live PostHog flags.
+type ManifestDiffRequest struct {
+	From *Manifest `json:"from,omitempty"`
+	To   Manifest  `json:"to"`
+}
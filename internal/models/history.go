@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Flag history models
+
+// FlagHistoryEntry is one change to a flag, described in OpenFeature terms
+type FlagHistoryEntry struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Action    string        `json:"action"`
+	ChangedBy string        `json:"changedBy,omitempty"`
+	Changes   []FieldChange `json:"changes,omitempty"`
+}
+
+// FlagHistoryResponse is the response of GET /openfeature/v0/manifest/flags/:key/history.
+// Entries are ordered newest first.
+type FlagHistoryResponse struct {
+	Key     string             `json:"key"`
+	Entries []FlagHistoryEntry `json:"entries"`
+	Page    int                `json:"page"`
+	Limit   int                `json:"limit"`
+	Total   int                `json:"total"`
+	HasMore bool               `json:"hasMore"`
+}
@@ -0,0 +1,47 @@
+package models
+
+// Bulk manifest import models
+
+// ImportAction is what an import does to a single flag
+type ImportAction string
+
+const (
+	ImportActionCreate    ImportAction = "create"
+	ImportActionUpdate    ImportAction = "update"
+	ImportActionArchive   ImportAction = "archive"
+	ImportActionUnchanged ImportAction = "unchanged"
+)
+
+// ImportStatus reports whether a planned action was carried out
+type ImportStatus string
+
+const (
+	ImportStatusPlanned ImportStatus = "planned"
+	ImportStatusApplied ImportStatus = "applied"
+	ImportStatusFailed  ImportStatus = "failed"
+)
+
+// ImportFlagResult is the plan, and outcome when applied, for one flag
+type ImportFlagResult struct {
+	Key     string        `json:"key"`
+	Action  ImportAction  `json:"action"`
+	Status  ImportStatus  `json:"status"`
+	Changes []FieldChange `json:"changes,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// ImportSummary counts the flags per action, plus those that failed to apply
+type ImportSummary struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Archived  int `json:"archived"`
+	Unchanged int `json:"unchanged"`
+	Failed    int `json:"failed"`
+}
+
+// ManifestImportResponse is the response of PUT /openfeature/v0/manifest
+type ManifestImportResponse struct {
+	DryRun  bool               `json:"dryRun"`
+	Summary ImportSummary      `json:"summary"`
+	Flags   []ImportFlagResult `json:"flags"`
+}
@@ -0,0 +1,40 @@
+package models
+
+// OpenFeature Remote Evaluation Protocol (OFREP) models
+// https://github.com/open-feature/protocol
+
+// OFREPEvaluationRequest is the body of single and bulk OFREP evaluation requests
+type OFREPEvaluationRequest struct {
+	Context map[string]interface{} `json:"context"`
+}
+
+// OFREPEvaluationSuccess is a successfully evaluated flag
+type OFREPEvaluationSuccess struct {
+	Key      string                 `json:"key"`
+	Value    interface{}            `json:"value"`
+	Reason   string                 `json:"reason"`
+	Variant  string                 `json:"variant,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// OFREPEvaluationFailure describes why a flag could not be evaluated
+type OFREPEvaluationFailure struct {
+	Key          string `json:"key"`
+	ErrorCode    string `json:"errorCode"`
+	ErrorDetails string `json:"errorDetails,omitempty"`
+}
+
+// OFREPBulkEvaluationResponse is the response of the bulk evaluation endpoint.
+// Each entry is either an OFREPEvaluationSuccess or an OFREPEvaluationFailure.
+type OFREPBulkEvaluationResponse struct {
+	Flags []interface{} `json:"flags"`
+}
+
+// OFREP error codes
+const (
+	OFREPErrorParse               = "PARSE_ERROR"
+	OFREPErrorTargetingKeyMissing = "TARGETING_KEY_MISSING"
+	OFREPErrorInvalidContext      = "INVALID_CONTEXT"
+	OFREPErrorFlagNotFound        = "FLAG_NOT_FOUND"
+	OFREPErrorGeneral             = "GENERAL"
+)
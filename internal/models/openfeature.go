@@ -24,6 +24,21 @@ type ManifestFlag struct {
 	State        FlagState          `json:"state"`
 	Expiry       *time.Time         `json:"expiry,omitempty"`
 	Metadata     map[string]string  `json:"metadata,omitempty"`
+	Targeting    *Targeting         `json:"targeting,omitempty"`
+	ArchivedAt   *time.Time         `json:"archivedAt,omitempty"`
+}
+
+// CLIManifest is the OpenFeature CLI's local flag manifest, with flags keyed by name
+type CLIManifest struct {
+	Schema string                     `json:"$schema"`
+	Flags  map[string]CLIManifestFlag `json:"flags"`
+}
+
+// CLIManifestFlag is a flag entry in the OpenFeature CLI's local flag manifest
+type CLIManifestFlag struct {
+	FlagType     FlagType    `json:"flagType"`
+	DefaultValue interface{} `json:"defaultValue"`
+	Description  string      `json:"description,omitempty"`
 }
 
 // FlagType represents the type of a feature flag
@@ -33,9 +48,21 @@ const (
 	FlagTypeBoolean FlagType = "boolean"
 	FlagTypeString  FlagType = "string"
 	FlagTypeInteger FlagType = "integer"
+	FlagTypeFloat   FlagType = "float"
 	FlagTypeObject  FlagType = "object"
+
+	// FlagTypeNumber is accepted on input as an alias for FlagTypeFloat
+	FlagTypeNumber FlagType = "number"
 )
 
+// Normalize resolves type aliases so that "number" and "float" are treated the same
+func (t FlagType) Normalize() FlagType {
+	if t == FlagTypeNumber {
+		return FlagTypeFloat
+	}
+	return t
+}
+
 // FlagState represents the state of a feature flag
 type FlagState string
 
@@ -50,6 +77,27 @@ type Variant struct {
 	Weight *int        `json:"weight,omitempty"`
 }
 
+// Targeting describes who receives a flag. Rules are evaluated in order and map
+// to PostHog release conditions; subjects matching no rule get the default value.
+type Targeting struct {
+	Rules []TargetingRule `json:"rules"`
+}
+
+// TargetingRule is a single release condition
+type TargetingRule struct {
+	Conditions        []TargetingCondition `json:"conditions,omitempty"`
+	RolloutPercentage *int                 `json:"rolloutPercentage,omitempty"`
+	Variant           string               `json:"variant,omitempty"`
+}
+
+// TargetingCondition matches a property of the evaluation context using a PostHog operator
+type TargetingCondition struct {
+	Property string      `json:"property"`
+	Operator string      `json:"operator,omitempty"`
+	Value    interface{} `json:"value,omitempty"`
+	Type     string      `json:"type,omitempty"`
+}
+
 // CreateFlagRequest represents a request to create a feature flag
 type CreateFlagRequest struct {
 	Key          string             `json:"key" binding:"required"`
@@ -60,6 +108,7 @@ type CreateFlagRequest struct {
 	Variants     map[string]Variant `json:"variants,omitempty"`
 	Expiry       *time.Time         `json:"expiry,omitempty"`
 	Metadata     map[string]string  `json:"metadata,omitempty"`
+	Targeting    *Targeting         `json:"targeting,omitempty"`
 }
 
 // UpdateFlagRequest represents a request to update a feature flag
@@ -72,6 +121,7 @@ type UpdateFlagRequest struct {
 	State        *FlagState          `json:"state,omitempty"`
 	Expiry       *NullableTime       `json:"expiry,omitempty"`
 	Metadata     *map[string]string  `json:"metadata,omitempty"`
+	Targeting    *Targeting          `json:"targeting,omitempty"`
 }
 
 // UnmarshalJSON allows distinguishing between missing and explicit null expiry values.
@@ -84,6 +134,7 @@ func (r *UpdateFlagRequest) UnmarshalJSON(data []byte) error {
 		Variants     *map[string]Variant `json:"variants,omitempty"`
 		State        *FlagState          `json:"state,omitempty"`
 		Metadata     *map[string]string  `json:"metadata,omitempty"`
+		Targeting    *Targeting          `json:"targeting,omitempty"`
 	}
 
 	var aux struct {
@@ -102,6 +153,7 @@ func (r *UpdateFlagRequest) UnmarshalJSON(data []byte) error {
 	r.Variants = aux.Variants
 	r.State = aux.State
 	r.Metadata = aux.Metadata
+	r.Targeting = aux.Targeting
 
 	if aux.Expiry != nil {
 		if string(aux.Expiry) == "null" {
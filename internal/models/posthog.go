@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // PostHog API models based on the PostHog Feature Flags API
 
@@ -99,6 +102,42 @@ type PostHogFeatureFlagsResponse struct {
 	Results  []PostHogFeatureFlag `json:"results"`
 }
 
+// PostHogActivityResponse represents a page of a feature flag's activity log
+type PostHogActivityResponse struct {
+	Results    []PostHogActivityEntry `json:"results"`
+	Next       *string                `json:"next"`
+	Previous   *string                `json:"previous"`
+	TotalCount int                    `json:"total_count"`
+}
+
+// PostHogActivityEntry represents a single activity log entry
+type PostHogActivityEntry struct {
+	User      *PostHogUser          `json:"user"`
+	Activity  string                `json:"activity"`
+	Scope     string                `json:"scope"`
+	ItemID    string                `json:"item_id"`
+	Detail    PostHogActivityDetail `json:"detail"`
+	CreatedAt time.Time             `json:"created_at"`
+	IsSystem  bool                  `json:"is_system"`
+}
+
+// PostHogActivityDetail describes what an activity log entry changed
+type PostHogActivityDetail struct {
+	Name    string                  `json:"name"`
+	ShortID *string                 `json:"short_id"`
+	Changes []PostHogActivityChange `json:"changes"`
+}
+
+// PostHogActivityChange is a single field change in an activity log entry. Before and
+// After hold the raw value of the flag field named by Field.
+type PostHogActivityChange struct {
+	Type   string          `json:"type"`
+	Action string          `json:"action"`
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
 // PostHogCreateFlagRequest represents a request to create a PostHog feature flag
 type PostHogCreateFlagRequest struct {
 	Name                       string         `json:"name"`
@@ -0,0 +1,178 @@
+package posthog
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+)
+
+// BreakerState is the state of the circuit breaker around PostHog API calls
+type BreakerState int
+
+const (
+	// BreakerClosed lets requests through while counting their failures
+	BreakerClosed BreakerState = iota
+	// BreakerHalfOpen lets a single probe request through after the cool-down
+	BreakerHalfOpen
+	// BreakerOpen fails requests immediately until the cool-down has passed
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerHalfOpen:
+		return "half-open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitOpenError is returned without contacting PostHog while the breaker is open
+type CircuitOpenError struct {
+	// RetryAfter is how long until the breaker lets a probe request through
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("PostHog API unavailable: circuit breaker is open, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// callOutcome is how a single PostHog request counts towards the breaker
+type callOutcome int
+
+const (
+	outcomeSuccess callOutcome = iota
+	outcomeFailure
+	// outcomeIgnored is for requests that say nothing about PostHog's health, such as
+	// those cancelled by the caller
+	outcomeIgnored
+)
+
+// CircuitBreaker stops sending requests to PostHog once too many of them fail. While
+// closed it counts requests in fixed windows; when at least MinRequests were made in
+// a window and the failure rate reaches FailureRate it opens. After the cool-down one
+// probe request is let through: its success closes the breaker, its failure opens it
+// again.
+type CircuitBreaker struct {
+	failureRate float64
+	minRequests int
+	window      time.Duration
+	coolDown    time.Duration
+	now         func() time.Time
+
+	mu          sync.Mutex
+	state       BreakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	rejections  int64
+}
+
+// NewCircuitBreaker creates a closed breaker
+func NewCircuitBreaker(cfg config.CircuitBreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		failureRate: cfg.FailureRate,
+		minRequests: cfg.MinRequests,
+		window:      time.Duration(cfg.Window) * time.Second,
+		coolDown:    time.Duration(cfg.CoolDown) * time.Second,
+		now:         time.Now,
+	}
+}
+
+// allow reports whether a request may be sent, returning a CircuitOpenError if not
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case BreakerOpen:
+		if remaining := b.openedAt.Add(b.coolDown).Sub(now); remaining > 0 {
+			b.rejections++
+			return &CircuitOpenError{RetryAfter: remaining}
+		}
+		b.transition(BreakerHalfOpen)
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			b.rejections++
+			return &CircuitOpenError{RetryAfter: time.Second}
+		}
+		b.probing = true
+		return nil
+	default:
+		if now.Sub(b.windowStart) >= b.window {
+			b.windowStart = now
+			b.requests = 0
+			b.failures = 0
+		}
+		return nil
+	}
+}
+
+// record counts the outcome of a request let through by allow
+func (b *CircuitBreaker) record(outcome callOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+		switch outcome {
+		case outcomeSuccess:
+			b.windowStart = b.now()
+			b.requests = 0
+			b.failures = 0
+			b.transition(BreakerClosed)
+		case outcomeFailure:
+			b.openedAt = b.now()
+			b.transition(BreakerOpen)
+		}
+		return
+	}
+	if b.state != BreakerClosed || outcome == outcomeIgnored {
+		return
+	}
+
+	b.requests++
+	if outcome == outcomeFailure {
+		b.failures++
+	}
+	if b.requests >= b.minRequests && float64(b.failures)/float64(b.requests) >= b.failureRate {
+		b.openedAt = b.now()
+		b.transition(BreakerOpen)
+	}
+}
+
+func (b *CircuitBreaker) transition(to BreakerState) {
+	if b.state == to {
+		return
+	}
+	slog.Warn("PostHog circuit breaker state changed", "from", b.state.String(), "to", to.String())
+	b.state = to
+}
+
+// State returns the breaker's current state. An open breaker whose cool-down has
+// passed is reported as half-open, since the next request will probe PostHog.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && !b.now().Before(b.openedAt.Add(b.coolDown)) {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Rejections returns the number of requests failed fast since the breaker was created
+func (b *CircuitBreaker) Rejections() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rejections
+}
@@ -0,0 +1,135 @@
+package posthog
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBreaker(now *time.Time) *CircuitBreaker {
+	b := NewCircuitBreaker(config.CircuitBreakerConfig{
+		Enabled:     true,
+		FailureRate: 0.5,
+		MinRequests: 4,
+		Window:      30,
+		CoolDown:    10,
+	})
+	b.now = func() time.Time { return *now }
+	return b
+}
+
+func TestCircuitBreaker_OpensOnFailureRate(t *testing.T) {
+	now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	b := newTestBreaker(&now)
+
+	// Failures below the minimum number of requests don't open the breaker
+	for _, outcome := range []callOutcome{outcomeFailure, outcomeSuccess, outcomeFailure} {
+		require.NoError(t, b.allow())
+		b.record(outcome)
+	}
+	assert.Equal(t, BreakerClosed, b.State())
+
+	require.NoError(t, b.allow())
+	b.record(outcomeFailure)
+	assert.Equal(t, BreakerOpen, b.State())
+
+	err := b.allow()
+	var open *CircuitOpenError
+	require.True(t, errors.As(err, &open))
+	assert.Equal(t, 10*time.Second, open.RetryAfter)
+	assert.Equal(t, int64(1), b.Rejections())
+}
+
+func TestCircuitBreaker_WindowResetsCounts(t *testing.T) {
+	now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	b := newTestBreaker(&now)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, b.allow())
+		b.record(outcomeFailure)
+	}
+
+	// The failures of a past window don't count towards the next one
+	now = now.Add(31 * time.Second)
+	require.NoError(t, b.allow())
+	b.record(outcomeFailure)
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+func TestCircuitBreaker_IgnoredOutcomesDontCount(t *testing.T) {
+	now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	b := newTestBreaker(&now)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, b.allow())
+		b.record(outcomeIgnored)
+	}
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	b := newTestBreaker(&now)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, b.allow())
+		b.record(outcomeFailure)
+	}
+	require.Equal(t, BreakerOpen, b.State())
+
+	// After the cool-down a single probe is let through
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	require.NoError(t, b.allow())
+	assert.Error(t, b.allow())
+
+	// A failed probe opens the breaker for another cool-down
+	b.record(outcomeFailure)
+	assert.Equal(t, BreakerOpen, b.State())
+	assert.Error(t, b.allow())
+
+	// A successful probe closes it
+	now = now.Add(10 * time.Second)
+	require.NoError(t, b.allow())
+	b.record(outcomeSuccess)
+	assert.Equal(t, BreakerClosed, b.State())
+	assert.NoError(t, b.allow())
+}
+
+func TestDoWithRetry_FailsFastWhenBreakerOpen(t *testing.T) {
+	mockTransport := new(MockRoundTripper)
+	mockTransport.On("RoundTrip", mock.Anything).Return(nil, errors.New("connection refused"))
+
+	client := NewClient(config.PostHogConfig{
+		Host:      "http://localhost",
+		ProjectID: "123",
+		CircuitBreaker: config.CircuitBreakerConfig{
+			Enabled:     true,
+			FailureRate: 1,
+			MinRequests: 2,
+			Window:      30,
+			CoolDown:    30,
+		},
+	}, false)
+	client.httpClient.Transport = mockTransport
+	client.retryConfig = RetryConfig{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	// The breaker opens after the second failed attempt and stops the retries
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/api", nil)
+	_, err := client.doWithRetry(context.Background(), req)
+	var open *CircuitOpenError
+	require.True(t, errors.As(err, &open))
+	mockTransport.AssertNumberOfCalls(t, "RoundTrip", 2)
+
+	// Later requests don't reach PostHog at all
+	_, err = client.GetFeatureFlags(context.Background())
+	require.True(t, errors.As(err, &open))
+	mockTransport.AssertNumberOfCalls(t, "RoundTrip", 2)
+	assert.Equal(t, BreakerOpen, client.Breaker().State())
+}
@@ -0,0 +1,61 @@
+package posthog
+
+import (
+	"sync"
+	"time"
+)
+
+// retryBudgetWindow is how long the retry budget counts requests and retries before
+// starting over
+const retryBudgetWindow = 10 * time.Second
+
+// retryBudget caps retries at a fraction of the requests made, so that retries can't
+// multiply the load on PostHog while it is struggling. Like the circuit breaker it
+// counts in fixed windows; minRetries retries per window are always allowed so that
+// quiet periods can still retry.
+type retryBudget struct {
+	ratio      float64
+	minRetries int
+	now        func() time.Time
+
+	mu          sync.Mutex
+	windowStart time.Time
+	requests    int
+	retries     int
+}
+
+func newRetryBudget(ratio float64, minRetries int) *retryBudget {
+	return &retryBudget{
+		ratio:      ratio,
+		minRetries: minRetries,
+		now:        time.Now,
+	}
+}
+
+// request counts the first attempt of a request
+func (b *retryBudget) request() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll()
+	b.requests++
+}
+
+// allowRetry reports whether the budget has room for another retry, taking it if so
+func (b *retryBudget) allowRetry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll()
+	if float64(b.retries) >= float64(b.minRetries)+b.ratio*float64(b.requests) {
+		return false
+	}
+	b.retries++
+	return true
+}
+
+func (b *retryBudget) roll() {
+	if now := b.now(); now.Sub(b.windowStart) >= retryBudgetWindow {
+		b.windowStart = now
+		b.requests = 0
+		b.retries = 0
+	}
+}
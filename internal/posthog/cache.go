@@ -0,0 +1,331 @@
+package posthog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/telemetry"
+)
+
+const defaultCacheRefreshInterval = 30 * time.Second
+
+// CachedClient is a ClientInterface decorator that keeps the PostHog flag list in memory.
+// The list is refreshed in the background on a fixed interval and kept in sync with
+// writes made through the proxy. When PostHog is unavailable the last known list is
+// served instead of failing the request.
+type CachedClient struct {
+	client   ClientInterface
+	interval time.Duration
+	metrics  *telemetry.Metrics
+	now      func() time.Time
+
+	mu          sync.RWMutex
+	flags       []models.PostHogFeatureFlag
+	loaded      bool
+	fetchedAt   time.Time
+	nextRefresh time.Time
+	// generation counts writes and invalidations, so a fetch can tell that the list it
+	// got from PostHog may predate one of them
+	generation uint64
+
+	// refreshMu ensures only one refresh against PostHog is in flight at a time
+	refreshMu sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewCachedClient wraps a PostHog client with an in-memory flag list cache
+func NewCachedClient(client ClientInterface, interval time.Duration, metrics *telemetry.Metrics) *CachedClient {
+	if interval <= 0 {
+		interval = defaultCacheRefreshInterval
+	}
+
+	cached := &CachedClient{
+		client:   client,
+		interval: interval,
+		metrics:  metrics,
+		now:      time.Now,
+	}
+
+	if metrics != nil {
+		if err := metrics.ObserveCacheAge(cached.age); err != nil {
+			slog.Warn("Failed to register cache age gauge", "error", err)
+		}
+	}
+
+	return cached
+}
+
+// Start launches the background refresh loop. It performs an initial load so the
+// first manifest request does not have to wait for PostHog.
+func (c *CachedClient) Start(ctx context.Context) {
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+
+	if _, err := c.load(ctx); err != nil {
+		slog.WarnContext(ctx, "Initial flag cache load failed", "error", err)
+	}
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := c.load(ctx); err != nil {
+					slog.WarnContext(ctx, "Background flag cache refresh failed, serving stale data", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop terminates the background refresh loop started by Start
+func (c *CachedClient) Stop() {
+	if c.stop == nil {
+		return
+	}
+	close(c.stop)
+	<-c.done
+	c.stop = nil
+}
+
+// GetFeatureFlags returns the cached flag list, loading or refreshing it from PostHog when needed
+func (c *CachedClient) GetFeatureFlags(ctx context.Context) ([]models.PostHogFeatureFlag, error) {
+	c.mu.RLock()
+	fresh := c.loaded && c.now().Before(c.nextRefresh)
+	c.mu.RUnlock()
+
+	if fresh {
+		if c.metrics != nil {
+			c.metrics.CacheHits.Add(ctx, 1)
+		}
+		return c.snapshot(), nil
+	}
+
+	if c.metrics != nil {
+		c.metrics.CacheMisses.Add(ctx, 1)
+	}
+
+	flags, err := c.refresh(ctx)
+	if err != nil {
+		if snapshot, ok := c.staleSnapshot(); ok {
+			slog.WarnContext(ctx, "PostHog unavailable, serving stale feature flags", "error", err, "age_seconds", c.age())
+			if c.metrics != nil {
+				c.metrics.CacheStaleServes.Add(ctx, 1)
+			}
+			return snapshot, nil
+		}
+		return nil, err
+	}
+
+	return flags, nil
+}
+
+// GetFeatureFlagsWithOptions asks PostHog for the flags matching the options. Filtered
+// lists are never cached, so callers that can filter should filter GetFeatureFlags
+// instead.
+func (c *CachedClient) GetFeatureFlagsWithOptions(ctx context.Context, opts *ListFlagsOptions) ([]models.PostHogFeatureFlag, error) {
+	return c.client.GetFeatureFlagsWithOptions(ctx, opts)
+}
+
+// GetFeatureFlag fetches a single flag from PostHog and refreshes its cache entry
+func (c *CachedClient) GetFeatureFlag(ctx context.Context, id int) (*models.PostHogFeatureFlag, error) {
+	flag, err := c.client.GetFeatureFlag(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.upsert(*flag)
+	return flag, nil
+}
+
+// GetFeatureFlagByKey fetches a single flag from PostHog and refreshes its cache entry.
+// Single flag reads are never served from the cache because they precede writes.
+func (c *CachedClient) GetFeatureFlagByKey(ctx context.Context, key string) (*models.PostHogFeatureFlag, error) {
+	flag, err := c.client.GetFeatureFlagByKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	c.upsert(*flag)
+	return flag, nil
+}
+
+// CreateFeatureFlag creates a flag in PostHog and adds it to the cache
+func (c *CachedClient) CreateFeatureFlag(ctx context.Context, req models.PostHogCreateFlagRequest) (*models.PostHogFeatureFlag, error) {
+	flag, err := c.client.CreateFeatureFlag(ctx, req)
+	if err != nil {
+		c.invalidate()
+		return nil, err
+	}
+	c.upsert(*flag)
+	return flag, nil
+}
+
+// UpdateFeatureFlag updates a flag in PostHog and replaces its cache entry
+func (c *CachedClient) UpdateFeatureFlag(ctx context.Context, id int, req models.PostHogUpdateFlagRequest) (*models.PostHogFeatureFlag, error) {
+	flag, err := c.client.UpdateFeatureFlag(ctx, id, req)
+	if err != nil {
+		c.invalidate()
+		return nil, err
+	}
+	c.upsert(*flag)
+	return flag, nil
+}
+
+// DeleteFeatureFlag deletes a flag in PostHog and removes it from the cache
+func (c *CachedClient) DeleteFeatureFlag(ctx context.Context, id int) error {
+	if err := c.client.DeleteFeatureFlag(ctx, id); err != nil {
+		c.invalidate()
+		return err
+	}
+	c.remove(id)
+	return nil
+}
+
+// GetFeatureFlagActivity reads a flag's activity log from PostHog; it is never cached
+func (c *CachedClient) GetFeatureFlagActivity(ctx context.Context, id int, page, limit int) (*models.PostHogActivityResponse, error) {
+	return c.client.GetFeatureFlagActivity(ctx, id, page, limit)
+}
+
+// refresh reloads the flag list from PostHog for a read that found it stale, unless
+// another caller refreshed it while this one was waiting
+func (c *CachedClient) refresh(ctx context.Context) ([]models.PostHogFeatureFlag, error) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	c.mu.RLock()
+	if c.loaded && c.now().Before(c.nextRefresh) {
+		c.mu.RUnlock()
+		return c.snapshot(), nil
+	}
+	c.mu.RUnlock()
+
+	return c.fetch(ctx)
+}
+
+// load reloads the flag list from PostHog unconditionally. The background loop uses it
+// so a tick is never skipped because the list still looks fresh.
+func (c *CachedClient) load(ctx context.Context) ([]models.PostHogFeatureFlag, error) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	return c.fetch(ctx)
+}
+
+// fetch must be called with refreshMu held. The next refresh is scheduled from the
+// start of the fetch, in step with the background ticker, and even when the fetch
+// fails so an outage does not turn every read into a PostHog call.
+//
+// A write or invalidation made while the fetch was in flight may be missing from the
+// fetched list, so the list then only fills an empty cache, never replaces the entries
+// the write updated, and the next read goes to PostHog again.
+func (c *CachedClient) fetch(ctx context.Context) ([]models.PostHogFeatureFlag, error) {
+	c.mu.RLock()
+	generation := c.generation
+	c.mu.RUnlock()
+
+	started := c.now()
+	flags, err := c.client.GetFeatureFlags(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		c.nextRefresh = time.Time{}
+		if err != nil {
+			return nil, err
+		}
+		if !c.loaded {
+			c.flags = flags
+			c.loaded = true
+			c.fetchedAt = started
+		}
+		return copyFlags(c.flags), nil
+	}
+
+	c.nextRefresh = started.Add(c.interval)
+	if err != nil {
+		return nil, err
+	}
+
+	c.flags = flags
+	c.loaded = true
+	c.fetchedAt = started
+
+	return copyFlags(c.flags), nil
+}
+
+func (c *CachedClient) snapshot() []models.PostHogFeatureFlag {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return copyFlags(c.flags)
+}
+
+func (c *CachedClient) staleSnapshot() ([]models.PostHogFeatureFlag, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.loaded {
+		return nil, false
+	}
+	return copyFlags(c.flags), true
+}
+
+func (c *CachedClient) upsert(flag models.PostHogFeatureFlag) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	if !c.loaded {
+		return
+	}
+
+	for i := range c.flags {
+		if c.flags[i].ID == flag.ID {
+			c.flags[i] = cloneFlag(flag)
+			return
+		}
+	}
+	c.flags = append(c.flags, cloneFlag(flag))
+}
+
+func (c *CachedClient) remove(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for i := range c.flags {
+		if c.flags[i].ID == id {
+			c.flags = append(c.flags[:i:i], c.flags[i+1:]...)
+			return
+		}
+	}
+}
+
+// invalidate forces the next read to go to PostHog, used when a write failed
+// and its outcome is unknown
+func (c *CachedClient) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.nextRefresh = time.Time{}
+}
+
+func (c *CachedClient) age() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.loaded {
+		return 0
+	}
+	return c.now().Sub(c.fetchedAt).Seconds()
+}
@@ -0,0 +1,319 @@
+package posthog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock lets tests move the cache's notion of time forward deterministically
+type fakeClock struct {
+	current time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.current
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.current = f.current.Add(d)
+}
+
+func newTestCachedClient(inner ClientInterface) (*CachedClient, *fakeClock) {
+	clock := &fakeClock{current: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cached := NewCachedClient(inner, time.Minute, nil)
+	cached.now = clock.Now
+	return cached, clock
+}
+
+func TestCachedClient_ServesFromCacheWithinInterval(t *testing.T) {
+	inner := new(MockClient)
+	flags := []models.PostHogFeatureFlag{{ID: 1, Key: "flag-a"}}
+	inner.On("GetFeatureFlags", mock.Anything).Return(flags, nil).Once()
+
+	cached, clock := newTestCachedClient(inner)
+
+	first, err := cached.GetFeatureFlags(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, flags, first)
+
+	clock.Advance(30 * time.Second)
+
+	second, err := cached.GetFeatureFlags(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, flags, second)
+
+	inner.AssertNumberOfCalls(t, "GetFeatureFlags", 1)
+}
+
+func TestCachedClient_RefreshesAfterInterval(t *testing.T) {
+	inner := new(MockClient)
+	inner.On("GetFeatureFlags", mock.Anything).Return([]models.PostHogFeatureFlag{{ID: 1, Key: "flag-a"}}, nil).Once()
+	inner.On("GetFeatureFlags", mock.Anything).Return([]models.PostHogFeatureFlag{{ID: 1, Key: "flag-a"}, {ID: 2, Key: "flag-b"}}, nil).Once()
+
+	cached, clock := newTestCachedClient(inner)
+
+	_, err := cached.GetFeatureFlags(context.Background())
+	require.NoError(t, err)
+
+	clock.Advance(2 * time.Minute)
+
+	flags, err := cached.GetFeatureFlags(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, flags, 2)
+	inner.AssertNumberOfCalls(t, "GetFeatureFlags", 2)
+}
+
+func TestCachedClient_ServesStaleDataWhenPostHogIsDown(t *testing.T) {
+	inner := new(MockClient)
+	flags := []models.PostHogFeatureFlag{{ID: 1, Key: "flag-a"}}
+	inner.On("GetFeatureFlags", mock.Anything).Return(flags, nil).Once()
+	inner.On("GetFeatureFlags", mock.Anything).Return(nil, errors.New("posthog unavailable"))
+
+	cached, clock := newTestCachedClient(inner)
+
+	_, err := cached.GetFeatureFlags(context.Background())
+	require.NoError(t, err)
+
+	clock.Advance(2 * time.Minute)
+
+	stale, err := cached.GetFeatureFlags(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, flags, stale)
+
+	// The failed refresh schedules the next attempt, so PostHog isn't called again immediately
+	_, err = cached.GetFeatureFlags(context.Background())
+	require.NoError(t, err)
+	inner.AssertNumberOfCalls(t, "GetFeatureFlags", 2)
+}
+
+func TestCachedClient_ReturnsErrorWhenNothingCached(t *testing.T) {
+	inner := new(MockClient)
+	inner.On("GetFeatureFlags", mock.Anything).Return(nil, errors.New("posthog unavailable"))
+
+	cached, _ := newTestCachedClient(inner)
+
+	flags, err := cached.GetFeatureFlags(context.Background())
+	assert.Error(t, err)
+	assert.Nil(t, flags)
+}
+
+func TestCachedClient_WritesUpdateCache(t *testing.T) {
+	inner := new(MockClient)
+	inner.On("GetFeatureFlags", mock.Anything).Return([]models.PostHogFeatureFlag{
+		{ID: 1, Key: "flag-a", Name: "Flag A"},
+		{ID: 2, Key: "flag-b", Name: "Flag B"},
+	}, nil).Once()
+
+	createReq := models.PostHogCreateFlagRequest{Key: "flag-c", Name: "Flag C"}
+	inner.On("CreateFeatureFlag", mock.Anything, createReq).Return(&models.PostHogFeatureFlag{ID: 3, Key: "flag-c", Name: "Flag C"}, nil)
+
+	newName := "Renamed A"
+	updateReq := models.PostHogUpdateFlagRequest{Name: &newName}
+	inner.On("UpdateFeatureFlag", mock.Anything, 1, updateReq).Return(&models.PostHogFeatureFlag{ID: 1, Key: "flag-a", Name: newName}, nil)
+
+	inner.On("DeleteFeatureFlag", mock.Anything, 2).Return(nil)
+
+	cached, _ := newTestCachedClient(inner)
+	ctx := context.Background()
+
+	_, err := cached.GetFeatureFlags(ctx)
+	require.NoError(t, err)
+
+	_, err = cached.CreateFeatureFlag(ctx, createReq)
+	require.NoError(t, err)
+	_, err = cached.UpdateFeatureFlag(ctx, 1, updateReq)
+	require.NoError(t, err)
+	require.NoError(t, cached.DeleteFeatureFlag(ctx, 2))
+
+	flags, err := cached.GetFeatureFlags(ctx)
+	require.NoError(t, err)
+
+	names := map[string]string{}
+	for _, flag := range flags {
+		names[flag.Key] = flag.Name
+	}
+	assert.Equal(t, map[string]string{"flag-a": "Renamed A", "flag-c": "Flag C"}, names)
+	inner.AssertNumberOfCalls(t, "GetFeatureFlags", 1)
+}
+
+func TestCachedClient_FailedWriteInvalidatesCache(t *testing.T) {
+	inner := new(MockClient)
+	inner.On("GetFeatureFlags", mock.Anything).Return([]models.PostHogFeatureFlag{{ID: 1, Key: "flag-a"}}, nil)
+	inner.On("DeleteFeatureFlag", mock.Anything, 1).Return(errors.New("timeout"))
+
+	cached, _ := newTestCachedClient(inner)
+	ctx := context.Background()
+
+	_, err := cached.GetFeatureFlags(ctx)
+	require.NoError(t, err)
+
+	assert.Error(t, cached.DeleteFeatureFlag(ctx, 1))
+
+	_, err = cached.GetFeatureFlags(ctx)
+	require.NoError(t, err)
+	inner.AssertNumberOfCalls(t, "GetFeatureFlags", 2)
+}
+
+func TestCachedClient_SnapshotsAreIsolated(t *testing.T) {
+	inner := new(MockClient)
+	inner.On("GetFeatureFlags", mock.Anything).Return([]models.PostHogFeatureFlag{{ID: 1, Key: "flag-a"}}, nil).Once()
+
+	cached, _ := newTestCachedClient(inner)
+
+	first, err := cached.GetFeatureFlags(context.Background())
+	require.NoError(t, err)
+	first[0].Key = "mutated"
+
+	second, err := cached.GetFeatureFlags(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "flag-a", second[0].Key)
+}
+
+func TestCachedClient_BackgroundRefreshKeepsReadsWarm(t *testing.T) {
+	inner := new(MockClient)
+	cached, clock := newTestCachedClient(inner)
+	// Each fetch takes five seconds
+	inner.On("GetFeatureFlags", mock.Anything).Run(func(mock.Arguments) {
+		clock.Advance(5 * time.Second)
+	}).Return([]models.PostHogFeatureFlag{{ID: 1, Key: "flag-a"}}, nil)
+
+	_, err := cached.load(context.Background())
+	require.NoError(t, err)
+
+	// The next tick comes one interval after the previous one started, while the
+	// list was fetched less than an interval ago; it must refresh all the same
+	clock.Advance(55 * time.Second)
+	_, err = cached.load(context.Background())
+	require.NoError(t, err)
+	inner.AssertNumberOfCalls(t, "GetFeatureFlags", 2)
+
+	// Reads until the following tick are served from the cache
+	clock.Advance(50 * time.Second)
+	_, err = cached.GetFeatureFlags(context.Background())
+	require.NoError(t, err)
+	inner.AssertNumberOfCalls(t, "GetFeatureFlags", 2)
+}
+
+func TestCachedClient_SnapshotsDoNotShareNestedData(t *testing.T) {
+	inner := new(MockClient)
+	rollout := 50
+	inner.On("GetFeatureFlags", mock.Anything).Return([]models.PostHogFeatureFlag{{
+		ID:   1,
+		Key:  "flag-a",
+		Tags: []string{"owner:team-a"},
+		Filters: models.PostHogFilters{
+			Groups:       []models.PostHogFilterGroup{{Properties: []models.PostHogProperty{{Key: "plan", Value: []interface{}{"pro"}}}, RolloutPercentage: &rollout}},
+			Multivariate: &models.PostHogMultivariate{Variants: []models.PostHogVariant{{Key: "a", RolloutFlag: 100}}},
+			Payloads:     map[string]string{"a": `"x"`},
+		},
+	}}, nil).Once()
+
+	cached, _ := newTestCachedClient(inner)
+
+	first, err := cached.GetFeatureFlags(context.Background())
+	require.NoError(t, err)
+	first[0].Tags[0] = "mutated"
+	*first[0].Filters.Groups[0].RolloutPercentage = 0
+	first[0].Filters.Groups[0].Properties[0].Value.([]interface{})[0] = "mutated"
+	first[0].Filters.Multivariate.Variants[0].Key = "mutated"
+	first[0].Filters.Payloads["a"] = "mutated"
+
+	second, err := cached.GetFeatureFlags(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "owner:team-a", second[0].Tags[0])
+	assert.Equal(t, 50, *second[0].Filters.Groups[0].RolloutPercentage)
+	assert.Equal(t, []interface{}{"pro"}, second[0].Filters.Groups[0].Properties[0].Value)
+	assert.Equal(t, "a", second[0].Filters.Multivariate.Variants[0].Key)
+	assert.Equal(t, `"x"`, second[0].Filters.Payloads["a"])
+}
+
+// blockingListFetch makes the next GetFeatureFlags call return list once release is
+// closed, and closes the returned channel when the call has started
+func blockingListFetch(inner *MockClient, list []models.PostHogFeatureFlag, release <-chan struct{}) <-chan struct{} {
+	started := make(chan struct{})
+	inner.On("GetFeatureFlags", mock.Anything).Run(func(mock.Arguments) {
+		close(started)
+		<-release
+	}).Return(list, nil).Once()
+	return started
+}
+
+func TestCachedClient_WriteDuringFetchIsKept(t *testing.T) {
+	inner := new(MockClient)
+	stale := []models.PostHogFeatureFlag{{ID: 1, Key: "flag-a"}, {ID: 2, Key: "flag-b"}}
+	inner.On("GetFeatureFlags", mock.Anything).Return(stale, nil).Once()
+	createReq := models.PostHogCreateFlagRequest{Key: "flag-c"}
+	inner.On("CreateFeatureFlag", mock.Anything, createReq).Return(&models.PostHogFeatureFlag{ID: 3, Key: "flag-c"}, nil)
+	inner.On("DeleteFeatureFlag", mock.Anything, 2).Return(nil)
+
+	cached, _ := newTestCachedClient(inner)
+	ctx := context.Background()
+	_, err := cached.GetFeatureFlags(ctx)
+	require.NoError(t, err)
+
+	// A background refresh fetches the list from before the writes below
+	release := make(chan struct{})
+	started := blockingListFetch(inner, stale, release)
+	loaded := make(chan error, 1)
+	go func() {
+		_, err := cached.load(ctx)
+		loaded <- err
+	}()
+	<-started
+
+	_, err = cached.CreateFeatureFlag(ctx, createReq)
+	require.NoError(t, err)
+	require.NoError(t, cached.DeleteFeatureFlag(ctx, 2))
+	close(release)
+	require.NoError(t, <-loaded)
+
+	// The writes survive the refresh, and the next read goes back to PostHog
+	assert.Equal(t, []models.PostHogFeatureFlag{{ID: 1, Key: "flag-a"}, {ID: 3, Key: "flag-c"}}, cached.snapshot())
+
+	current := []models.PostHogFeatureFlag{{ID: 1, Key: "flag-a"}, {ID: 3, Key: "flag-c"}}
+	inner.On("GetFeatureFlags", mock.Anything).Return(current, nil).Once()
+	flags, err := cached.GetFeatureFlags(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, current, flags)
+	inner.AssertNumberOfCalls(t, "GetFeatureFlags", 3)
+}
+
+func TestCachedClient_InvalidateDuringFetchIsKept(t *testing.T) {
+	inner := new(MockClient)
+	list := []models.PostHogFeatureFlag{{ID: 1, Key: "flag-a"}}
+	inner.On("GetFeatureFlags", mock.Anything).Return(list, nil).Once()
+	inner.On("DeleteFeatureFlag", mock.Anything, 1).Return(errors.New("timeout"))
+
+	cached, _ := newTestCachedClient(inner)
+	ctx := context.Background()
+	_, err := cached.GetFeatureFlags(ctx)
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	started := blockingListFetch(inner, list, release)
+	loaded := make(chan error, 1)
+	go func() {
+		_, err := cached.load(ctx)
+		loaded <- err
+	}()
+	<-started
+
+	// The outcome of the failed delete is unknown, so the fetch must not mark the
+	// list it started before the delete as fresh
+	assert.Error(t, cached.DeleteFeatureFlag(ctx, 1))
+	close(release)
+	require.NoError(t, <-loaded)
+
+	inner.On("GetFeatureFlags", mock.Anything).Return([]models.PostHogFeatureFlag{}, nil).Once()
+	flags, err := cached.GetFeatureFlags(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, flags)
+	inner.AssertNumberOfCalls(t, "GetFeatureFlags", 3)
+}
@@ -4,6 +4,7 @@ import (
 "bytes"
 "context"
 "encoding/json"
+"errors"
 "fmt"
 "io"
 "log/slog"
@@ -23,6 +24,8 @@ httpClient *http.Client
 baseURL    string
 insecure   bool
 retryConfig RetryConfig
+retryBudget *retryBudget
+breaker    *CircuitBreaker
 }
 
 // NewClient creates a new PostHog client
@@ -32,6 +35,16 @@ func NewClient(cfg config.PostHogConfig, insecureMode bool) *Client {
 		timeout = time.Duration(cfg.Timeout) * time.Second
 	}
 
+	var breaker *CircuitBreaker
+	if cfg.CircuitBreaker.Enabled {
+		breaker = NewCircuitBreaker(cfg.CircuitBreaker)
+	}
+
+	var budget *retryBudget
+	if cfg.Retry.BudgetRatio > 0 {
+		budget = newRetryBudget(cfg.Retry.BudgetRatio, cfg.Retry.BudgetMinRetries)
+	}
+
 	return &Client{
 		config: cfg,
 		httpClient: &http.Client{
@@ -40,56 +53,23 @@ func NewClient(cfg config.PostHogConfig, insecureMode bool) *Client {
 		},
 		baseURL:  fmt.Sprintf("%s/api/projects/%s", cfg.Host, cfg.ProjectID),
 		insecure: insecureMode,
-retryConfig: DefaultRetryConfig(),
+retryConfig: newRetryConfig(cfg.Retry),
+		retryBudget: budget,
+		breaker:  breaker,
 	}
 }
 
-// GetFeatureFlags retrieves all feature flags from PostHog, traversing pagination when necessary.
-func (c *Client) GetFeatureFlags(ctx context.Context) ([]models.PostHogFeatureFlag, error) {
-nextURL := fmt.Sprintf("%s/feature_flags/", c.baseURL)
-var allFlags []models.PostHogFeatureFlag
-
-for nextURL != "" {
-req, err := c.newRequest(ctx, http.MethodGet, nextURL, nil)
-if err != nil {
-slog.ErrorContext(ctx, "GetFeatureFlags - creating request", "error", err)
-return nil, fmt.Errorf("creating request: %w", err)
+// Breaker returns the client's circuit breaker, or nil when it is disabled
+func (c *Client) Breaker() *CircuitBreaker {
+	return c.breaker
 }
 
-c.logRequest(ctx, req)
-
-resp, err := c.doWithRetry(ctx, req)
+// GetFeatureFlags retrieves all feature flags from PostHog, traversing pagination when necessary.
+func (c *Client) GetFeatureFlags(ctx context.Context) ([]models.PostHogFeatureFlag, error) {
+allFlags, err := c.listFeatureFlags(ctx, "GetFeatureFlags", fmt.Sprintf("%s/feature_flags/", c.baseURL))
 if err != nil {
-slog.ErrorContext(ctx, "GetFeatureFlags - HTTP request", "error", err)
-return nil, fmt.Errorf("making request: %w", err)
-}
-
-if err := func() error {
-defer resp.Body.Close()
-c.logResponse(ctx, resp)
-
-if resp.StatusCode != http.StatusOK {
-return c.parseErrorResponse(resp)
-}
-
-var page models.PostHogFeatureFlagsResponse
-if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
-slog.ErrorContext(ctx, "GetFeatureFlags - decoding response", "error", err)
-return fmt.Errorf("decoding response: %w", err)
-}
-
-allFlags = append(allFlags, page.Results...)
-if page.Next != nil && *page.Next != "" {
-nextURL = c.resolveURL(*page.Next)
-} else {
-nextURL = ""
-}
-
-return nil
-}(); err != nil {
 return nil, err
 }
-}
 
 slog.InfoContext(ctx, "GetFeatureFlags - Successfully retrieved flags", "count", len(allFlags))
 return allFlags, nil
@@ -106,34 +86,14 @@ func (c *Client) GetFeatureFlagByKey(ctx context.Context, key string) (*models.P
 	return c.fetchFeatureFlag(ctx, key, fmt.Sprintf("key %s", key))
 }
 
+// fetchFeatureFlag retrieves a flag by ID or key. isNotFound reports the error when
+// PostHog has no such flag.
 func (c *Client) fetchFeatureFlag(ctx context.Context, identifier, label string) (*models.PostHogFeatureFlag, error) {
 url := fmt.Sprintf("%s/feature_flags/%s/", c.baseURL, identifier)
 
-req, err := c.newRequest(ctx, http.MethodGet, url, nil)
-if err != nil {
-slog.ErrorContext(ctx, "GetFeatureFlag - creating request", "error", err)
-return nil, fmt.Errorf("creating request: %w", err)
-}
-
-c.logRequest(ctx, req)
-
-resp, err := c.doWithRetry(ctx, req)
-if err != nil {
-slog.ErrorContext(ctx, "GetFeatureFlag - HTTP request", "error", err)
-return nil, fmt.Errorf("making request: %w", err)
-}
-defer resp.Body.Close()
-
-c.logResponse(ctx, resp)
-
-if resp.StatusCode != http.StatusOK {
-return nil, c.parseErrorResponse(resp)
-}
-
 var result models.PostHogFeatureFlag
-if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-slog.ErrorContext(ctx, "GetFeatureFlag - decoding response", "error", err)
-return nil, fmt.Errorf("decoding response: %w", err)
+if err := c.getJSON(ctx, "GetFeatureFlag", url, &result); err != nil {
+return nil, err
 }
 
 slog.InfoContext(ctx, "GetFeatureFlag - Successfully retrieved flag", "label", label)
@@ -158,7 +118,27 @@ return nil, fmt.Errorf("creating request: %w", err)
 
 c.logRequest(ctx, httpReq)
 
-resp, err := c.doWithRetry(ctx, httpReq)
+// A create whose connection failed may still have reached PostHog, so look the key up
+// before retrying it. A deleted flag with the same key is not the one being created.
+var landed *models.PostHogFeatureFlag
+policy := policyFor(http.MethodPost)
+policy.landed = func(ctx context.Context) (bool, error) {
+flag, err := c.fetchFeatureFlag(ctx, req.Key, fmt.Sprintf("key %s", req.Key))
+if isNotFound(err) {
+return false, nil
+}
+if err != nil || flag.Deleted {
+return false, err
+}
+landed = flag
+return true, nil
+}
+
+resp, err := c.doWithPolicy(ctx, httpReq, policy)
+if errors.Is(err, errRequestLanded) {
+slog.WarnContext(ctx, "CreateFeatureFlag - failed attempt had created the flag", "key", landed.Key)
+return landed, nil
+}
 if err != nil {
 slog.ErrorContext(ctx, "CreateFeatureFlag - HTTP request", "error", err)
 return nil, fmt.Errorf("making request: %w", err)
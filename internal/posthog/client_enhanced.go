@@ -8,114 +8,108 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
 
 	"github.com/openfeature/posthog-proxy/internal/models"
 )
 
-// GetFeatureFlagsWithOptions retrieves feature flags with filtering options
+// GetFeatureFlagsWithOptions retrieves the feature flags matching the options, leaving
+// the filtering to PostHog. Deleted flags are left out.
 func (c *Client) GetFeatureFlagsWithOptions(ctx context.Context, opts *ListFlagsOptions) ([]models.PostHogFeatureFlag, error) {
-	baseURL := fmt.Sprintf("%s/feature_flags/", c.baseURL)
-	
-	// Add query parameters if options provided
+	listURL := fmt.Sprintf("%s/feature_flags/", c.baseURL)
 	if opts != nil {
-		params := opts.ToQueryParams()
-		if len(params) > 0 {
-			query := url.Values{}
-			for k, v := range params {
-				query.Add(k, v)
-			}
-			baseURL = fmt.Sprintf("%s?%s", baseURL, query.Encode())
+		if query := opts.ToQueryParams(); len(query) > 0 {
+			listURL += "?" + query.Encode()
 		}
 	}
 
-	nextURL := baseURL
-	var allFlags []models.PostHogFeatureFlag
+	flags, err := c.listFeatureFlags(ctx, "GetFeatureFlagsWithOptions", listURL)
+	if err != nil {
+		return nil, err
+	}
 
-	for nextURL != "" {
-		req, err := c.newRequest(ctx, http.MethodGet, nextURL, nil)
-		if err != nil {
-			slog.ErrorContext(ctx, "GetFeatureFlagsWithOptions - creating request", "error", err)
-			return nil, fmt.Errorf("creating request: %w", err)
+	var allFlags []models.PostHogFeatureFlag
+	for _, flag := range flags {
+		if !flag.Deleted {
+			allFlags = append(allFlags, flag)
 		}
+	}
 
-		c.logRequest(ctx, req)
+	slog.InfoContext(ctx, "GetFeatureFlagsWithOptions - Successfully retrieved flags", "count", len(allFlags))
+	return allFlags, nil
+}
 
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			slog.ErrorContext(ctx, "GetFeatureFlagsWithOptions - HTTP request", "error", err)
-			return nil, fmt.Errorf("making request: %w", err)
-		}
+// GetFeatureFlagActivity retrieves one page of the activity log for a feature flag,
+// newest first. Pages are numbered from 1.
+func (c *Client) GetFeatureFlagActivity(ctx context.Context, id int, page, limit int) (*models.PostHogActivityResponse, error) {
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("limit", strconv.Itoa(limit))
+	activityURL := fmt.Sprintf("%s/feature_flags/%d/activity/?%s", c.baseURL, id, query.Encode())
+
+	var activity models.PostHogActivityResponse
+	if err := c.getJSON(ctx, "GetFeatureFlagActivity", activityURL, &activity); err != nil {
+		return nil, err
+	}
 
-		if err := func() error {
-			defer resp.Body.Close()
-			c.logResponse(ctx, resp)
-
-			if resp.StatusCode != http.StatusOK {
-				return c.parseErrorResponse(resp)
-			}
-
-			var page models.PostHogFeatureFlagsResponse
-			if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
-				slog.ErrorContext(ctx, "GetFeatureFlagsWithOptions - decoding response", "error", err)
-				return fmt.Errorf("decoding response: %w", err)
-			}
-
-			// Filter out deleted flags
-			for _, flag := range page.Results {
-				if !flag.Deleted {
-					allFlags = append(allFlags, flag)
-				}
-			}
-			
-			if page.Next != nil && *page.Next != "" {
-				nextURL = c.resolveURL(*page.Next)
-			} else {
-				nextURL = ""
-			}
-
-			return nil
-		}(); err != nil {
+	slog.InfoContext(ctx, "GetFeatureFlagActivity - Successfully retrieved activity", "id", id, "count", len(activity.Results))
+	return &activity, nil
+}
+
+// listFeatureFlags collects the flags of every page of a flag list, following PostHog's
+// pagination from firstURL. op names the calling method in logs.
+func (c *Client) listFeatureFlags(ctx context.Context, op, firstURL string) ([]models.PostHogFeatureFlag, error) {
+	var allFlags []models.PostHogFeatureFlag
+
+	for nextURL := firstURL; nextURL != ""; {
+		var page models.PostHogFeatureFlagsResponse
+		if err := c.getJSON(ctx, op, nextURL, &page); err != nil {
 			return nil, err
 		}
+
+		allFlags = append(allFlags, page.Results...)
+		nextURL = ""
+		if page.Next != nil && *page.Next != "" {
+			nextURL = c.resolveURL(*page.Next)
+		}
 	}
 
-	slog.InfoContext(ctx, "GetFeatureFlagsWithOptions - Successfully retrieved flags", "count", len(allFlags))
 	return allFlags, nil
 }
 
-// GetFeatureFlagActivity retrieves the audit log for a feature flag
-func (c *Client) GetFeatureFlagActivity(ctx context.Context, id int) ([]map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/feature_flags/%d/activity/", c.baseURL, id)
-
+// getJSON sends a GET request with retries and decodes the 200 response into out. Any
+// other status is returned as a parsed PostHog error, which isNotFound reports for a 404.
+// op names the calling method in logs.
+func (c *Client) getJSON(ctx context.Context, op, url string, out interface{}) error {
 	req, err := c.newRequest(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		slog.ErrorContext(ctx, "GetFeatureFlagActivity - creating request", "error", err)
-		return nil, fmt.Errorf("creating request: %w", err)
+		slog.ErrorContext(ctx, op+" - creating request", "error", err)
+		return fmt.Errorf("creating request: %w", err)
 	}
 
 	c.logRequest(ctx, req)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doWithRetry(ctx, req)
 	if err != nil {
-		slog.ErrorContext(ctx, "GetFeatureFlagActivity - HTTP request", "error", err)
-		return nil, fmt.Errorf("making request: %w", err)
+		slog.ErrorContext(ctx, op+" - HTTP request", "error", err)
+		return fmt.Errorf("making request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	c.logResponse(ctx, resp)
 
+	if resp.StatusCode == http.StatusNotFound {
+		return &notFoundError{err: c.parseErrorResponse(resp)}
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, c.parseErrorResponse(resp)
+		return c.parseErrorResponse(resp)
 	}
 
-	var activity []map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&activity); err != nil {
-		slog.ErrorContext(ctx, "GetFeatureFlagActivity - decoding response", "error", err)
-		return nil, fmt.Errorf("decoding response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		slog.ErrorContext(ctx, op+" - decoding response", "error", err)
+		return fmt.Errorf("decoding response: %w", err)
 	}
-
-	slog.InfoContext(ctx, "GetFeatureFlagActivity - Successfully retrieved activity", "id", id)
-	return activity, nil
+	return nil
 }
 
 // parseErrorResponse attempts to parse a structured API error response
@@ -429,3 +429,123 @@ func TestClient_ErrorHandling(t *testing.T) {
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestGetFeatureFlagActivity_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/projects/123/feature_flags/456/activity/", r.URL.Path)
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+		assert.Equal(t, "5", r.URL.Query().Get("limit"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"results": [{
+				"user": {"first_name": "Ada", "email": "ada@example.com"},
+				"activity": "updated",
+				"scope": "FeatureFlag",
+				"item_id": "456",
+				"detail": {
+					"name": "my-feature-flag",
+					"changes": [{"type": "FeatureFlag", "action": "changed", "field": "active", "before": true, "after": false}]
+				},
+				"created_at": "2026-03-01T10:00:00Z",
+				"is_system": false
+			}],
+			"next": "https://app.posthog.com/api/projects/123/feature_flags/456/activity/?page=3&limit=5",
+			"previous": null,
+			"total_count": 11
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(config.PostHogConfig{
+		APIKey:    "test-key",
+		Host:      server.URL,
+		ProjectID: "123",
+	}, false)
+
+	activity, err := client.GetFeatureFlagActivity(context.Background(), 456, 2, 5)
+
+	require.NoError(t, err)
+	assert.Equal(t, 11, activity.TotalCount)
+	require.NotNil(t, activity.Next)
+	require.Len(t, activity.Results, 1)
+	entry := activity.Results[0]
+	assert.Equal(t, "updated", entry.Activity)
+	assert.Equal(t, "ada@example.com", entry.User.Email)
+	require.Len(t, entry.Detail.Changes, 1)
+	assert.Equal(t, "active", entry.Detail.Changes[0].Field)
+	assert.JSONEq(t, "false", string(entry.Detail.Changes[0].After))
+}
+
+func TestGetFeatureFlagActivity_RetriesServerErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"results": [], "total_count": 0}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(config.PostHogConfig{Host: server.URL, ProjectID: "123"}, false)
+	client.retryConfig = RetryConfig{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	_, err := client.GetFeatureFlagActivity(context.Background(), 456, 1, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestGetFeatureFlagsWithOptions_PushesFiltersToPostHog(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		query := r.URL.Query()
+		assert.Equal(t, "true", query.Get("active"))
+		assert.Equal(t, "server", query.Get("evaluation_runtime"))
+		assert.Equal(t, "checkout", query.Get("search"))
+		assert.Equal(t, `["payments","web"]`, query.Get("tags"))
+		assert.Equal(t, "50", query.Get("limit"))
+
+		json.NewEncoder(w).Encode(models.PostHogFeatureFlagsResponse{
+			Results: []models.PostHogFeatureFlag{
+				{ID: 1, Key: "checkout-button", Active: true},
+				{ID: 2, Key: "checkout-legacy", Active: true, Deleted: true},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(config.PostHogConfig{Host: server.URL, ProjectID: "123"}, false)
+	client.retryConfig = RetryConfig{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	active := true
+	flags, err := client.GetFeatureFlagsWithOptions(context.Background(), &ListFlagsOptions{
+		Active:            &active,
+		EvaluationRuntime: stringPtr("server"),
+		Search:            "checkout",
+		Tags:              []string{"payments", "web"},
+		Limit:             50,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, flags, 1)
+	assert.Equal(t, "checkout-button", flags[0].Key)
+	assert.Equal(t, 2, calls)
+}
+
+func TestListFlagsOptions_ToQueryParams(t *testing.T) {
+	creator := 1234
+	inactive := false
+	params := (&ListFlagsOptions{Active: &inactive, CreatedByID: &creator, Limit: 100, Offset: 200}).ToQueryParams()
+
+	assert.Equal(t, "active=false&created_by_id=1234&limit=100&offset=200", params.Encode())
+	assert.Empty(t, (&ListFlagsOptions{}).ToQueryParams())
+}
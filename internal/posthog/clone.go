@@ -0,0 +1,163 @@
+package posthog
+
+import (
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+// copyFlags deep-copies a flag list so callers sharing a cached or coalesced list
+// can't change each other's flags
+func copyFlags(flags []models.PostHogFeatureFlag) []models.PostHogFeatureFlag {
+	if flags == nil {
+		return nil
+	}
+	copied := make([]models.PostHogFeatureFlag, len(flags))
+	for i := range flags {
+		copied[i] = cloneFlag(flags[i])
+	}
+	return copied
+}
+
+// copyFlag deep-copies a single shared flag
+func copyFlag(flag *models.PostHogFeatureFlag) *models.PostHogFeatureFlag {
+	if flag == nil {
+		return nil
+	}
+	copied := cloneFlag(*flag)
+	return &copied
+}
+
+// copyActivity deep-copies a shared page of a flag's activity log
+func copyActivity(activity *models.PostHogActivityResponse) *models.PostHogActivityResponse {
+	if activity == nil {
+		return nil
+	}
+	copied := *activity
+	copied.Next = clonePtr(activity.Next)
+	copied.Previous = clonePtr(activity.Previous)
+	if activity.Results != nil {
+		copied.Results = make([]models.PostHogActivityEntry, len(activity.Results))
+		for i, entry := range activity.Results {
+			entry.User = cloneUser(entry.User)
+			entry.Detail.ShortID = clonePtr(entry.Detail.ShortID)
+			if entry.Detail.Changes != nil {
+				changes := make([]models.PostHogActivityChange, len(entry.Detail.Changes))
+				for j, change := range entry.Detail.Changes {
+					change.Before = cloneSlice(change.Before)
+					change.After = cloneSlice(change.After)
+					changes[j] = change
+				}
+				entry.Detail.Changes = changes
+			}
+			copied.Results[i] = entry
+		}
+	}
+	return &copied
+}
+
+func cloneFlag(flag models.PostHogFeatureFlag) models.PostHogFeatureFlag {
+	flag.Filters = cloneFilters(flag.Filters)
+	flag.RolloutPercentage = clonePtr(flag.RolloutPercentage)
+	flag.Tags = cloneSlice(flag.Tags)
+	flag.EvaluationTags = cloneSlice(flag.EvaluationTags)
+	flag.UsageDashboard = clonePtr(flag.UsageDashboard)
+	flag.AnalyticsDashboards = cloneSlice(flag.AnalyticsDashboards)
+	flag.LastCalledAt = clonePtr(flag.LastCalledAt)
+	flag.CreatedBy = cloneUser(flag.CreatedBy)
+	flag.LastModifiedBy = cloneUser(flag.LastModifiedBy)
+	flag.ExperimentSet = cloneValues(flag.ExperimentSet)
+	flag.Surveys = cloneValues(flag.Surveys)
+	flag.Features = cloneValues(flag.Features)
+	flag.RollbackConditions = cloneValues(flag.RollbackConditions)
+	return flag
+}
+
+func cloneFilters(filters models.PostHogFilters) models.PostHogFilters {
+	if filters.Groups != nil {
+		groups := make([]models.PostHogFilterGroup, len(filters.Groups))
+		for i, group := range filters.Groups {
+			group.RolloutPercentage = clonePtr(group.RolloutPercentage)
+			group.Variant = clonePtr(group.Variant)
+			if group.Properties != nil {
+				properties := make([]models.PostHogProperty, len(group.Properties))
+				for j, property := range group.Properties {
+					property.Value = cloneValue(property.Value)
+					properties[j] = property
+				}
+				group.Properties = properties
+			}
+			groups[i] = group
+		}
+		filters.Groups = groups
+	}
+
+	if filters.Multivariate != nil {
+		filters.Multivariate = &models.PostHogMultivariate{Variants: cloneSlice(filters.Multivariate.Variants)}
+	}
+
+	if filters.Payloads != nil {
+		payloads := make(map[string]string, len(filters.Payloads))
+		for key, payload := range filters.Payloads {
+			payloads[key] = payload
+		}
+		filters.Payloads = payloads
+	}
+
+	filters.RolloutPercentage = clonePtr(filters.RolloutPercentage)
+	return filters
+}
+
+func cloneUser(user *models.PostHogUser) *models.PostHogUser {
+	if user == nil {
+		return nil
+	}
+	copied := *user
+	if user.HedgehogConfig != nil {
+		copied.HedgehogConfig = cloneValue(user.HedgehogConfig).(map[string]interface{})
+	}
+	return &copied
+}
+
+// cloneValue deep-copies decoded JSON: maps and slices are copied, everything else is
+// immutable
+func cloneValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = cloneValue(item)
+		}
+		return copied
+	case []interface{}:
+		return cloneValues(v)
+	default:
+		return value
+	}
+}
+
+func cloneValues(values []interface{}) []interface{} {
+	if values == nil {
+		return nil
+	}
+	copied := make([]interface{}, len(values))
+	for i, value := range values {
+		copied[i] = cloneValue(value)
+	}
+	return copied
+}
+
+func cloneSlice[T any](values []T) []T {
+	if values == nil {
+		return nil
+	}
+	copied := make([]T, len(values))
+	copy(copied, values)
+	return copied
+}
+
+func clonePtr[T any](value *T) *T {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
@@ -0,0 +1,167 @@
+package posthog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// CoalescingClient is a ClientInterface decorator that collapses concurrent identical
+// reads into a single PostHog request whose result is shared with every caller, so a
+// burst of pods starting at once costs one crawl of the flag list instead of hundreds.
+// Writes are passed through.
+type CoalescingClient struct {
+	client  ClientInterface
+	metrics *telemetry.Metrics
+
+	mu    sync.Mutex
+	calls map[string]*inflightCall
+}
+
+// inflightCall is a read in flight that later identical reads wait for
+type inflightCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// NewCoalescingClient wraps a PostHog client so concurrent identical reads share one request
+func NewCoalescingClient(client ClientInterface, metrics *telemetry.Metrics) *CoalescingClient {
+	return &CoalescingClient{
+		client:  client,
+		metrics: metrics,
+		calls:   make(map[string]*inflightCall),
+	}
+}
+
+// GetFeatureFlags fetches the flag list, joining a fetch already in flight
+func (c *CoalescingClient) GetFeatureFlags(ctx context.Context) ([]models.PostHogFeatureFlag, error) {
+	value, err := c.do(ctx, "GetFeatureFlags", "flags", func(ctx context.Context) (interface{}, error) {
+		return c.client.GetFeatureFlags(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyFlags(value.([]models.PostHogFeatureFlag)), nil
+}
+
+// GetFeatureFlagsWithOptions fetches a filtered flag list, joining a fetch with the same
+// options already in flight
+func (c *CoalescingClient) GetFeatureFlagsWithOptions(ctx context.Context, opts *ListFlagsOptions) ([]models.PostHogFeatureFlag, error) {
+	key := "flags?"
+	if opts != nil {
+		key += opts.ToQueryParams().Encode()
+	}
+	value, err := c.do(ctx, "GetFeatureFlagsWithOptions", key, func(ctx context.Context) (interface{}, error) {
+		return c.client.GetFeatureFlagsWithOptions(ctx, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyFlags(value.([]models.PostHogFeatureFlag)), nil
+}
+
+// GetFeatureFlag fetches a flag by ID, joining a fetch of it already in flight
+func (c *CoalescingClient) GetFeatureFlag(ctx context.Context, id int) (*models.PostHogFeatureFlag, error) {
+	value, err := c.do(ctx, "GetFeatureFlag", fmt.Sprintf("flag-id:%d", id), func(ctx context.Context) (interface{}, error) {
+		return c.client.GetFeatureFlag(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyFlag(value.(*models.PostHogFeatureFlag)), nil
+}
+
+// GetFeatureFlagByKey fetches a flag by key, joining a fetch of it already in flight
+func (c *CoalescingClient) GetFeatureFlagByKey(ctx context.Context, key string) (*models.PostHogFeatureFlag, error) {
+	value, err := c.do(ctx, "GetFeatureFlagByKey", "flag-key:"+key, func(ctx context.Context) (interface{}, error) {
+		return c.client.GetFeatureFlagByKey(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyFlag(value.(*models.PostHogFeatureFlag)), nil
+}
+
+// GetFeatureFlagActivity fetches a page of a flag's activity log, joining a fetch of
+// the same page already in flight
+func (c *CoalescingClient) GetFeatureFlagActivity(ctx context.Context, id int, page, limit int) (*models.PostHogActivityResponse, error) {
+	key := fmt.Sprintf("activity:%d:%d:%d", id, page, limit)
+	value, err := c.do(ctx, "GetFeatureFlagActivity", key, func(ctx context.Context) (interface{}, error) {
+		return c.client.GetFeatureFlagActivity(ctx, id, page, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	activity, _ := value.(*models.PostHogActivityResponse)
+	return copyActivity(activity), nil
+}
+
+// CreateFeatureFlag creates a flag in PostHog
+func (c *CoalescingClient) CreateFeatureFlag(ctx context.Context, req models.PostHogCreateFlagRequest) (*models.PostHogFeatureFlag, error) {
+	defer c.forget()
+	return c.client.CreateFeatureFlag(ctx, req)
+}
+
+// UpdateFeatureFlag updates a flag in PostHog
+func (c *CoalescingClient) UpdateFeatureFlag(ctx context.Context, id int, req models.PostHogUpdateFlagRequest) (*models.PostHogFeatureFlag, error) {
+	defer c.forget()
+	return c.client.UpdateFeatureFlag(ctx, id, req)
+}
+
+// DeleteFeatureFlag deletes a flag in PostHog
+func (c *CoalescingClient) DeleteFeatureFlag(ctx context.Context, id int) error {
+	defer c.forget()
+	return c.client.DeleteFeatureFlag(ctx, id)
+}
+
+// do runs fn once for all concurrent callers with the same key. The shared request runs
+// detached from the cancellation of whichever caller started it, while each caller
+// stops waiting when its own context is done.
+func (c *CoalescingClient) do(ctx context.Context, operation, key string, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	call, inFlight := c.calls[key]
+	if !inFlight {
+		call = &inflightCall{done: make(chan struct{})}
+		c.calls[key] = call
+	}
+	c.mu.Unlock()
+
+	if inFlight {
+		if c.metrics != nil {
+			c.metrics.CoalescedRequests.Add(ctx, 1, metric.WithAttributes(attribute.String("operation", operation)))
+		}
+	} else {
+		go func() {
+			value, err := fn(context.WithoutCancel(ctx))
+
+			c.mu.Lock()
+			call.value, call.err = value, err
+			if c.calls[key] == call {
+				delete(c.calls, key)
+			}
+			c.mu.Unlock()
+			close(call.done)
+		}()
+	}
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// forget stops later reads from joining those in flight, which may have started before
+// a write and so miss it
+func (c *CoalescingClient) forget() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.calls)
+}
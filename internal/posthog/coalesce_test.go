@@ -0,0 +1,196 @@
+package posthog
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// newObservedCoalescingClient wraps inner with a coalescing client whose metrics are
+// read back by waitForJoined
+func newObservedCoalescingClient(t *testing.T, inner ClientInterface) (*CoalescingClient, *sdkmetric.ManualReader) {
+	t.Helper()
+	reader := sdkmetric.NewManualReader()
+	counter, err := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).
+		Meter("test").Int64Counter("posthog_coalesced_requests_total")
+	require.NoError(t, err)
+	return NewCoalescingClient(inner, &telemetry.Metrics{CoalescedRequests: counter}), reader
+}
+
+// waitForJoined blocks until n reads have joined a read in flight
+func waitForJoined(t *testing.T, reader *sdkmetric.ManualReader, n int64) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		var joined int64
+		for _, scope := range rm.ScopeMetrics {
+			for _, m := range scope.Metrics {
+				if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+					for _, point := range sum.DataPoints {
+						joined += point.Value
+					}
+				}
+			}
+		}
+		return joined == n
+	}, time.Second, time.Millisecond)
+}
+
+// waitForCall blocks until a read is in flight under key
+func waitForCall(t *testing.T, c *CoalescingClient, key string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		_, ok := c.calls[key]
+		return ok
+	}, time.Second, time.Millisecond)
+}
+
+func TestCoalescingClient_SharesConcurrentReads(t *testing.T) {
+	release := make(chan struct{})
+	inner := new(MockClient)
+	inner.On("GetFeatureFlags", mock.Anything).
+		Run(func(mock.Arguments) { <-release }).
+		Return([]models.PostHogFeatureFlag{{
+			ID:      1,
+			Key:     "checkout-button",
+			Tags:    []string{"checkout"},
+			Filters: models.PostHogFilters{Payloads: map[string]string{"true": `"blue"`}},
+		}}, nil).Once()
+	client, reader := newObservedCoalescingClient(t, inner)
+
+	const callers = 5
+	results := make([][]models.PostHogFeatureFlag, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			flags, err := client.GetFeatureFlags(context.Background())
+			assert.NoError(t, err)
+			results[i] = flags
+		}(i)
+	}
+
+	waitForJoined(t, reader, callers-1)
+	close(release)
+	wg.Wait()
+
+	inner.AssertNumberOfCalls(t, "GetFeatureFlags", 1)
+	for _, flags := range results {
+		require.Len(t, flags, 1)
+		assert.Equal(t, "checkout-button", flags[0].Key)
+	}
+
+	// Every caller gets its own copy of the shared result
+	results[0][0].Key = "changed"
+	results[0][0].Tags[0] = "changed"
+	results[0][0].Filters.Payloads["true"] = `"red"`
+	assert.Equal(t, "checkout-button", results[1][0].Key)
+	assert.Equal(t, []string{"checkout"}, results[1][0].Tags)
+	assert.Equal(t, `"blue"`, results[1][0].Filters.Payloads["true"])
+}
+
+func TestCoalescingClient_KeysReadsByArguments(t *testing.T) {
+	release := make(chan struct{})
+	inner := new(MockClient)
+	for _, key := range []string{"checkout-button", "search-ranking"} {
+		inner.On("GetFeatureFlagByKey", mock.Anything, key).
+			Run(func(mock.Arguments) { <-release }).
+			Return(&models.PostHogFeatureFlag{Key: key}, nil).Once()
+	}
+	client, reader := newObservedCoalescingClient(t, inner)
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"checkout-button", "checkout-button", "search-ranking"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			flag, err := client.GetFeatureFlagByKey(context.Background(), key)
+			assert.NoError(t, err)
+			assert.Equal(t, key, flag.Key)
+		}(key)
+	}
+
+	waitForJoined(t, reader, 1)
+	close(release)
+	wg.Wait()
+
+	inner.AssertNumberOfCalls(t, "GetFeatureFlagByKey", 2)
+}
+
+func TestCoalescingClient_CancelledCallerDoesNotFailOthers(t *testing.T) {
+	release := make(chan struct{})
+	inner := new(MockClient)
+	inner.On("GetFeatureFlags", mock.Anything).
+		Run(func(args mock.Arguments) {
+			<-release
+			assert.NoError(t, args.Get(0).(context.Context).Err())
+		}).
+		Return([]models.PostHogFeatureFlag{{Key: "checkout-button"}}, nil).Once()
+	client, reader := newObservedCoalescingClient(t, inner)
+
+	// The caller that started the read gives up
+	ctx, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error)
+	go func() {
+		_, err := client.GetFeatureFlags(ctx)
+		firstDone <- err
+	}()
+
+	secondDone := make(chan []models.PostHogFeatureFlag)
+	waitForCall(t, client, "flags")
+	go func() {
+		flags, err := client.GetFeatureFlags(context.Background())
+		assert.NoError(t, err)
+		secondDone <- flags
+	}()
+	waitForJoined(t, reader, 1)
+
+	cancel()
+	assert.ErrorIs(t, <-firstDone, context.Canceled)
+
+	close(release)
+	assert.Len(t, <-secondDone, 1)
+}
+
+func TestCoalescingClient_WritesStopJoiningEarlierReads(t *testing.T) {
+	release := make(chan struct{})
+	inner := new(MockClient)
+	inner.On("GetFeatureFlags", mock.Anything).
+		Run(func(mock.Arguments) { <-release }).
+		Return([]models.PostHogFeatureFlag{}, nil).Once()
+	inner.On("GetFeatureFlags", mock.Anything).
+		Return([]models.PostHogFeatureFlag{{Key: "checkout-button"}}, nil).Once()
+	inner.On("DeleteFeatureFlag", mock.Anything, 1).Return(nil)
+	client := NewCoalescingClient(inner, nil)
+
+	staleDone := make(chan struct{})
+	go func() {
+		defer close(staleDone)
+		_, err := client.GetFeatureFlags(context.Background())
+		assert.NoError(t, err)
+	}()
+	waitForCall(t, client, "flags")
+
+	// A read after the write starts its own request instead of joining the earlier one
+	require.NoError(t, client.DeleteFeatureFlag(context.Background(), 1))
+	flags, err := client.GetFeatureFlags(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, flags, 1)
+
+	close(release)
+	<-staleDone
+	inner.AssertNumberOfCalls(t, "GetFeatureFlags", 2)
+}
@@ -1,6 +1,9 @@
 package posthog
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 // APIError represents a structured error response from PostHog API
 type APIError struct {
@@ -34,3 +37,19 @@ func (e *APIError) IsValidationError() bool {
 func (e *APIError) IsAuthError() bool {
 	return e.StatusCode == 401 || e.StatusCode == 403
 }
+
+// notFoundError marks a request PostHog answered with 404, whether or not the body
+// parsed as a structured error
+type notFoundError struct {
+	err error
+}
+
+func (e *notFoundError) Error() string { return e.err.Error() }
+
+func (e *notFoundError) Unwrap() error { return e.err }
+
+// isNotFound returns true if PostHog answered the request with 404
+func isNotFound(err error) bool {
+	var nf *notFoundError
+	return errors.As(err, &nf)
+}
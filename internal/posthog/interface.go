@@ -8,9 +8,11 @@ import (
 // ClientInterface defines the interface for PostHog client operations
 type ClientInterface interface {
 	GetFeatureFlags(ctx context.Context) ([]models.PostHogFeatureFlag, error)
+	GetFeatureFlagsWithOptions(ctx context.Context, opts *ListFlagsOptions) ([]models.PostHogFeatureFlag, error)
 	GetFeatureFlag(ctx context.Context, id int) (*models.PostHogFeatureFlag, error)
 	GetFeatureFlagByKey(ctx context.Context, key string) (*models.PostHogFeatureFlag, error)
 	CreateFeatureFlag(ctx context.Context, req models.PostHogCreateFlagRequest) (*models.PostHogFeatureFlag, error)
 	UpdateFeatureFlag(ctx context.Context, id int, req models.PostHogUpdateFlagRequest) (*models.PostHogFeatureFlag, error)
 	DeleteFeatureFlag(ctx context.Context, id int) error
+	GetFeatureFlagActivity(ctx context.Context, id int, page, limit int) (*models.PostHogActivityResponse, error)
 }
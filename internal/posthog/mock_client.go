@@ -20,6 +20,14 @@ func (m *MockClient) GetFeatureFlags(ctx context.Context) ([]models.PostHogFeatu
 	return args.Get(0).([]models.PostHogFeatureFlag), args.Error(1)
 }
 
+func (m *MockClient) GetFeatureFlagsWithOptions(ctx context.Context, opts *ListFlagsOptions) ([]models.PostHogFeatureFlag, error) {
+	args := m.Called(ctx, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.PostHogFeatureFlag), args.Error(1)
+}
+
 func (m *MockClient) GetFeatureFlag(ctx context.Context, id int) (*models.PostHogFeatureFlag, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -56,3 +64,11 @@ func (m *MockClient) DeleteFeatureFlag(ctx context.Context, id int) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
+
+func (m *MockClient) GetFeatureFlagActivity(ctx context.Context, id int, page, limit int) (*models.PostHogActivityResponse, error) {
+	args := m.Called(ctx, id, page, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PostHogActivityResponse), args.Error(1)
+}
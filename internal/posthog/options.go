@@ -1,5 +1,11 @@
 package posthog
 
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
 // ListFlagsOptions represents query parameters for listing feature flags
 type ListFlagsOptions struct {
 	// Active filters by active/inactive status
@@ -8,6 +14,10 @@ type ListFlagsOptions struct {
 	CreatedByID *int
 	// EvaluationRuntime filters by evaluation runtime
 	EvaluationRuntime *string
+	// Search matches flag keys and names containing the text
+	Search string
+	// Tags filters to flags carrying any of the tags
+	Tags []string
 	// Limit sets pagination limit (max 100)
 	Limit int
 	// Offset sets pagination offset
@@ -15,31 +25,37 @@ type ListFlagsOptions struct {
 }
 
 // ToQueryParams converts options to URL query parameters
-func (o *ListFlagsOptions) ToQueryParams() map[string]string {
-	params := make(map[string]string)
+func (o *ListFlagsOptions) ToQueryParams() url.Values {
+	params := url.Values{}
 
 	if o.Active != nil {
-		if *o.Active {
-			params["active"] = "true"
-		} else {
-			params["active"] = "false"
-		}
+		params.Set("active", strconv.FormatBool(*o.Active))
 	}
 
 	if o.CreatedByID != nil {
-		params["created_by_id"] = string(rune(*o.CreatedByID))
+		params.Set("created_by_id", strconv.Itoa(*o.CreatedByID))
 	}
 
 	if o.EvaluationRuntime != nil {
-		params["evaluation_runtime"] = *o.EvaluationRuntime
+		params.Set("evaluation_runtime", *o.EvaluationRuntime)
+	}
+
+	if o.Search != "" {
+		params.Set("search", o.Search)
+	}
+
+	// PostHog takes the tags as a JSON array
+	if len(o.Tags) > 0 {
+		tags, _ := json.Marshal(o.Tags)
+		params.Set("tags", string(tags))
 	}
 
 	if o.Limit > 0 {
-		params["limit"] = string(rune(o.Limit))
+		params.Set("limit", strconv.Itoa(o.Limit))
 	}
 
 	if o.Offset > 0 {
-		params["offset"] = string(rune(o.Offset))
+		params.Set("offset", strconv.Itoa(o.Offset))
 	}
 
 	return params
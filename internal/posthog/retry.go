@@ -2,6 +2,7 @@ package posthog
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -10,6 +11,8 @@ import (
 	"net/http"
 	"strconv"
 	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
 )
 
 const (
@@ -34,15 +37,63 @@ func DefaultRetryConfig() RetryConfig {
 	}
 }
 
-// doWithRetry executes an HTTP request with exponential backoff retry logic
+// newRetryConfig converts the loaded retry settings, falling back to the defaults when
+// none were loaded. config.Load never allows a zero initial backoff.
+func newRetryConfig(cfg config.RetryConfig) RetryConfig {
+	if cfg.InitialBackoff <= 0 {
+		return DefaultRetryConfig()
+	}
+	return RetryConfig{
+		MaxRetries:     cfg.MaxRetries,
+		InitialBackoff: time.Duration(cfg.InitialBackoff) * time.Millisecond,
+		MaxBackoff:     time.Duration(cfg.MaxBackoff) * time.Millisecond,
+	}
+}
+
+// errRequestLanded stops the retries of a request that turned out to have been applied
+// by PostHog even though the attempt failed
+var errRequestLanded = errors.New("request was applied by PostHog")
+
+// retryPolicy decides which failed attempts of a request are retried
+type retryPolicy struct {
+	// retryStatuses retries transient error statuses, not just connection errors. Only
+	// safe for idempotent requests, since PostHog may have applied them before failing.
+	retryStatuses bool
+	// landed is asked before retrying after a connection error, which may have happened
+	// after PostHog applied the request. If it reports true the retries stop with
+	// errRequestLanded; if it fails they stop with the connection error.
+	landed func(ctx context.Context) (bool, error)
+}
+
+// policyFor returns the retry policy for requests of the given method. POST creates
+// flags, and retrying one that PostHog applied would fail as a duplicate key.
+func policyFor(method string) retryPolicy {
+	return retryPolicy{retryStatuses: method != http.MethodPost}
+}
+
+// doWithRetry executes an HTTP request with exponential backoff retry logic, retrying
+// as allowed for its method
 func (c *Client) doWithRetry(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return c.doWithPolicy(ctx, req, policyFor(req.Method))
+}
+
+func (c *Client) doWithPolicy(ctx context.Context, req *http.Request, policy retryPolicy) (*http.Response, error) {
 	config := c.retryConfig
 
 	var lastErr error
 	var resp *http.Response
 
+	if c.retryBudget != nil {
+		c.retryBudget.request()
+	}
+
 	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
 		if attempt > 0 {
+			if c.retryBudget != nil && !c.retryBudget.allowRetry() {
+				slog.WarnContext(ctx, "Retry budget exhausted, not retrying", "url", req.URL.String())
+				return nil, fmt.Errorf("retry budget exhausted: %w", lastErr)
+			}
+
 			// Calculate backoff: initial * 2^(attempt-1)
 			backoff := time.Duration(math.Pow(2, float64(attempt-1))) * config.InitialBackoff
 			if backoff > config.MaxBackoff {
@@ -90,6 +141,17 @@ func (c *Client) doWithRetry(ctx context.Context, req *http.Request) (*http.Resp
 			case <-time.After(backoff):
 			}
 
+			if resp == nil && policy.landed != nil {
+				landed, err := policy.landed(ctx)
+				if err != nil {
+					slog.WarnContext(ctx, "Could not tell whether the failed request was applied, not retrying", "error", err)
+					return nil, fmt.Errorf("request failed and could not be checked: %w", lastErr)
+				}
+				if landed {
+					return nil, errRequestLanded
+				}
+			}
+
 			// Reset request body for retry if available
 			if req.GetBody != nil {
 				body, err := req.GetBody()
@@ -107,7 +169,15 @@ func (c *Client) doWithRetry(ctx context.Context, req *http.Request) (*http.Resp
 			}
 		}
 
+		// Fail fast while PostHog is known to be down instead of waiting out the retries
+		if c.breaker != nil {
+			if err := c.breaker.allow(); err != nil {
+				return nil, err
+			}
+		}
+
 		resp, lastErr = c.httpClient.Do(req)
+		c.recordOutcome(ctx, resp, lastErr)
 		if lastErr != nil {
 			// Network error, retry
 			slog.WarnContext(ctx, "Request failed", "error", lastErr, "attempt", attempt)
@@ -115,7 +185,7 @@ func (c *Client) doWithRetry(ctx context.Context, req *http.Request) (*http.Resp
 		}
 
 		// Check for 5xx errors or 429 Too Many Requests
-		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		if policy.retryStatuses && isTransientStatus(resp.StatusCode) {
 			// Read and close body to ensure connection reuse
 			io.Copy(io.Discard, resp.Body)
 			resp.Body.Close()
@@ -131,3 +201,24 @@ func (c *Client) doWithRetry(ctx context.Context, req *http.Request) (*http.Resp
 
 	return nil, fmt.Errorf("max retries exceeded: %w", lastErr)
 }
+
+// isTransientStatus reports whether PostHog answered with an error worth retrying
+func isTransientStatus(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests
+}
+
+// recordOutcome tells the circuit breaker how a request went. Requests cancelled by
+// the caller don't count, since they say nothing about PostHog's health.
+func (c *Client) recordOutcome(ctx context.Context, resp *http.Response, err error) {
+	if c.breaker == nil {
+		return
+	}
+	switch {
+	case err != nil && ctx.Err() != nil:
+		c.breaker.record(outcomeIgnored)
+	case err != nil || isTransientStatus(resp.StatusCode):
+		c.breaker.record(outcomeFailure)
+	default:
+		c.breaker.record(outcomeSuccess)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -260,3 +261,141 @@ func TestDoWithRetry_RetryAfterHeader(t *testing.T) {
 	// We allow some buffer for execution time
 	assert.True(t, time.Since(start) >= 1*time.Second, "Should have waited for Retry-After duration")
 }
+
+func newFastRetryClient(transport http.RoundTripper) *Client {
+	client := NewClient(config.PostHogConfig{Host: "http://localhost", ProjectID: "123"}, false)
+	client.httpClient.Transport = transport
+	client.retryConfig = RetryConfig{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	return client
+}
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString(body))}
+}
+
+func TestDoWithRetry_PostNotRetriedOnServerError(t *testing.T) {
+	mockTransport := new(MockRoundTripper)
+	mockTransport.On("RoundTrip", mock.Anything).Return(jsonResponse(http.StatusInternalServerError, "error"), nil)
+	client := newFastRetryClient(mockTransport)
+
+	// PostHog may have created the flag before failing, so the create isn't repeated
+	_, err := client.CreateFeatureFlag(context.Background(), models.PostHogCreateFlagRequest{Key: "checkout-button"})
+	assert.Error(t, err)
+	mockTransport.AssertNumberOfCalls(t, "RoundTrip", 1)
+}
+
+func TestCreateFeatureFlag_ConnectionErrorChecksForLandedCreate(t *testing.T) {
+	tests := []struct {
+		name        string
+		lookup      *http.Response
+		wantPosts   int
+		wantCreated bool
+	}{
+		{
+			name:        "create had landed",
+			lookup:      jsonResponse(http.StatusOK, `{"id": 7, "key": "checkout-button"}`),
+			wantPosts:   1,
+			wantCreated: false,
+		},
+		{
+			name:        "create had not landed",
+			lookup:      jsonResponse(http.StatusNotFound, `{"detail": "Not found."}`),
+			wantPosts:   2,
+			wantCreated: true,
+		},
+		{
+			name:        "unstructured not found",
+			lookup:      jsonResponse(http.StatusNotFound, "not found"),
+			wantPosts:   2,
+			wantCreated: true,
+		},
+		{
+			name:        "only a deleted flag has the key",
+			lookup:      jsonResponse(http.StatusOK, `{"id": 7, "key": "checkout-button", "deleted": true}`),
+			wantPosts:   2,
+			wantCreated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTransport := new(MockRoundTripper)
+			posts := 0
+			mockTransport.On("RoundTrip", mock.Anything).Return(func(req *http.Request) *http.Response {
+				if req.Method == http.MethodGet {
+					assert.Equal(t, "/api/projects/123/feature_flags/checkout-button/", req.URL.Path)
+					return tt.lookup
+				}
+				posts++
+				if posts == 1 {
+					return nil
+				}
+				return jsonResponse(http.StatusCreated, `{"id": 8, "key": "checkout-button"}`)
+			}, func(req *http.Request) error {
+				if req.Method == http.MethodPost && posts == 1 {
+					return errors.New("connection reset by peer")
+				}
+				return nil
+			})
+			client := newFastRetryClient(mockTransport)
+
+			flag, err := client.CreateFeatureFlag(context.Background(), models.PostHogCreateFlagRequest{Key: "checkout-button"})
+			assert.NoError(t, err)
+			assert.Equal(t, "checkout-button", flag.Key)
+			assert.Equal(t, tt.wantPosts, posts)
+			if tt.wantCreated {
+				assert.Equal(t, 8, flag.ID)
+			} else {
+				assert.Equal(t, 7, flag.ID)
+			}
+		})
+	}
+}
+
+func TestDoWithRetry_RetryBudget(t *testing.T) {
+	mockTransport := new(MockRoundTripper)
+	mockTransport.On("RoundTrip", mock.Anything).Return(func(req *http.Request) *http.Response {
+		return jsonResponse(http.StatusServiceUnavailable, "unavailable")
+	}, nil)
+	client := newFastRetryClient(mockTransport)
+	client.retryBudget = newRetryBudget(0.5, 0)
+
+	// One request earns half a retry, which is enough for a single retry
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/api", nil)
+	_, err := client.doWithRetry(context.Background(), req)
+	assert.ErrorContains(t, err, "retry budget exhausted")
+	mockTransport.AssertNumberOfCalls(t, "RoundTrip", 2)
+
+	// Two requests earn one retry, which was already spent
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost/api", nil)
+	_, err = client.doWithRetry(context.Background(), req)
+	assert.ErrorContains(t, err, "retry budget exhausted")
+	mockTransport.AssertNumberOfCalls(t, "RoundTrip", 3)
+}
+
+func TestRetryBudget_ResetsEachWindow(t *testing.T) {
+	now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	budget := newRetryBudget(0.1, 1)
+	budget.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		budget.request()
+	}
+	assert.True(t, budget.allowRetry())
+	assert.True(t, budget.allowRetry())
+	assert.False(t, budget.allowRetry())
+
+	// Only the minimum is available in a new window until requests are made in it
+	now = now.Add(retryBudgetWindow)
+	assert.True(t, budget.allowRetry())
+	assert.False(t, budget.allowRetry())
+}
+
+func TestNewRetryConfig(t *testing.T) {
+	assert.Equal(t, DefaultRetryConfig(), newRetryConfig(config.RetryConfig{}))
+	assert.Equal(t, RetryConfig{
+		MaxRetries:     0,
+		InitialBackoff: 250 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+	}, newRetryConfig(config.RetryConfig{MaxRetries: 0, InitialBackoff: 250, MaxBackoff: 2000}))
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	l := New(2, 3)
+	l.now = func() time.Time { return now }
+
+	// The burst is available straight away
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("ci")
+		assert.True(t, ok, "request %d", i)
+	}
+	ok, wait := l.Allow("ci")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// Other keys have their own bucket
+	ok, _ = l.Allow("dashboard")
+	assert.True(t, ok)
+
+	// Tokens refill at the configured rate
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = l.Allow("ci")
+	assert.True(t, ok)
+	ok, _ = l.Allow("ci")
+	assert.False(t, ok)
+}
+
+func TestLimiter_CheckDoesNotTakeTokens(t *testing.T) {
+	now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	l := New(1, 1)
+	l.now = func() time.Time { return now }
+
+	ok, _ := l.Check("ci")
+	assert.True(t, ok)
+	ok, _ = l.Check("ci")
+	assert.True(t, ok)
+
+	l.Allow("ci")
+	ok, wait := l.Check("ci")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait)
+}
+
+func TestLimiter_SweepsRefilledBuckets(t *testing.T) {
+	now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	l := New(1, 1)
+	l.now = func() time.Time { return now }
+
+	l.Allow("a")
+	l.Allow("b")
+	assert.Len(t, l.buckets, 2)
+
+	now = now.Add(2 * sweepInterval)
+	l.Allow("c")
+	assert.Len(t, l.buckets, 1)
+}
@@ -0,0 +1,328 @@
+// Package stream watches the PostHog flag list and turns differences between polls
+// into manifest change events for streaming clients.
+package stream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/openfeature/posthog-proxy/internal/transformer"
+)
+
+const (
+	defaultPollInterval = 15 * time.Second
+	defaultHistorySize  = 256
+
+	// subscriberBuffer is how many events a slow client may fall behind before it is
+	// disconnected; it then reconnects with Last-Event-ID and catches up from history
+	subscriberBuffer = 64
+)
+
+// ErrNotReady is returned by Subscribe until the first successful poll of PostHog
+var ErrNotReady = errors.New("manifest watcher has not loaded flags from PostHog yet")
+
+// EventType identifies the kind of manifest change
+type EventType string
+
+const (
+	EventSnapshot    EventType = "snapshot"
+	EventFlagAdded   EventType = "flag-added"
+	EventFlagChanged EventType = "flag-changed"
+	EventFlagRemoved EventType = "flag-removed"
+)
+
+// Event is a manifest change. Data is a models.Manifest for snapshots, a
+// models.ManifestFlag for added and changed flags and a FlagRemoved for removals.
+type Event struct {
+	ID   string
+	Type EventType
+	Data interface{}
+}
+
+// FlagRemoved is the payload of a flag-removed event
+type FlagRemoved struct {
+	Key string `json:"key"`
+}
+
+// Subscription delivers events to a single client
+type Subscription struct {
+	// Initial holds the snapshot, or the events missed since Last-Event-ID on reconnect
+	Initial []Event
+	// Events receives live changes; it is closed when the client falls too far behind
+	Events <-chan Event
+
+	watcher *Watcher
+	ch      chan Event
+}
+
+// Close unregisters the subscription
+func (s *Subscription) Close() {
+	s.watcher.unsubscribe(s.ch)
+}
+
+// Watcher polls PostHog in the background and publishes manifest change events
+type Watcher struct {
+	client      posthog.ClientInterface
+	coercion    config.TypeCoercionConfig
+	interval    time.Duration
+	historySize int
+
+	// epoch makes event IDs from a previous process unrecognisable after a restart
+	epoch string
+
+	// refreshMu keeps polls in order so events are never published out of sequence
+	refreshMu sync.Mutex
+
+	mu          sync.RWMutex
+	loaded      bool
+	seq         uint64
+	flags       map[string]models.ManifestFlag
+	encoded     map[string][]byte
+	history     []Event
+	subscribers map[chan Event]struct{}
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewWatcher creates a watcher that polls PostHog every interval and keeps the last
+// historySize events for clients resuming with Last-Event-ID
+func NewWatcher(client posthog.ClientInterface, coercion config.TypeCoercionConfig, interval time.Duration, historySize int) *Watcher {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	if historySize <= 0 {
+		historySize = defaultHistorySize
+	}
+
+	return &Watcher{
+		client:      client,
+		coercion:    coercion,
+		interval:    interval,
+		historySize: historySize,
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Start performs an initial poll and launches the background poll loop
+func (w *Watcher) Start(ctx context.Context) {
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	if err := w.Refresh(ctx); err != nil {
+		slog.WarnContext(ctx, "Initial manifest watcher poll failed", "error", err)
+	}
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := w.Refresh(ctx); err != nil {
+					slog.WarnContext(ctx, "Manifest watcher poll failed", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop terminates the poll loop started by Start
+func (w *Watcher) Stop() {
+	if w.stop == nil {
+		return
+	}
+	close(w.stop)
+	<-w.done
+	w.stop = nil
+}
+
+// Refresh polls PostHog once and publishes an event for every flag that was
+// added, changed or removed since the previous poll
+func (w *Watcher) Refresh(ctx context.Context) error {
+	w.refreshMu.Lock()
+	defer w.refreshMu.Unlock()
+
+	posthogFlags, err := w.client.GetFeatureFlags(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Archived flags are hidden from the manifest, so archiving a flag removes it
+	flags := make(map[string]models.ManifestFlag, len(posthogFlags))
+	encoded := make(map[string][]byte, len(posthogFlags))
+	for _, phFlag := range transformer.WithoutArchived(posthogFlags) {
+		if phFlag.Deleted {
+			continue
+		}
+		flag := transformer.PostHogToOpenFeatureFlag(phFlag, w.coercion)
+		body, err := json.Marshal(flag)
+		if err != nil {
+			return fmt.Errorf("failed to encode flag %q: %w", flag.Key, err)
+		}
+		flags[flag.Key] = flag
+		encoded[flag.Key] = body
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.loaded {
+		// The first poll only establishes the baseline that snapshots are built from
+		w.flags, w.encoded, w.loaded = flags, encoded, true
+		return nil
+	}
+
+	for _, key := range sortedKeys(flags) {
+		previous, existed := w.encoded[key]
+		switch {
+		case !existed:
+			w.publish(EventFlagAdded, flags[key])
+		case !bytes.Equal(previous, encoded[key]):
+			w.publish(EventFlagChanged, flags[key])
+		}
+	}
+	for _, key := range sortedKeys(w.flags) {
+		if _, exists := flags[key]; !exists {
+			w.publish(EventFlagRemoved, FlagRemoved{Key: key})
+		}
+	}
+
+	w.flags, w.encoded = flags, encoded
+	return nil
+}
+
+// Subscribe registers a client. When lastEventID names an event still in history the
+// client receives only the events it missed, otherwise it starts from a full snapshot.
+func (w *Watcher) Subscribe(lastEventID string) (*Subscription, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.loaded {
+		return nil, ErrNotReady
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	w.subscribers[ch] = struct{}{}
+
+	initial, ok := w.replay(lastEventID)
+	if !ok {
+		initial = []Event{{ID: w.eventID(w.seq), Type: EventSnapshot, Data: w.manifest()}}
+	}
+
+	return &Subscription{Initial: initial, Events: ch, watcher: w, ch: ch}, nil
+}
+
+// publish records an event and fans it out; callers must hold w.mu
+func (w *Watcher) publish(eventType EventType, data interface{}) {
+	w.seq++
+	event := Event{ID: w.eventID(w.seq), Type: eventType, Data: data}
+
+	w.history = append(w.history, event)
+	if len(w.history) > w.historySize {
+		w.history = w.history[len(w.history)-w.historySize:]
+	}
+
+	for ch := range w.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Disconnect clients that stopped reading rather than block the poller
+			delete(w.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// replay returns the events after lastEventID, reporting false when the ID is unknown
+// or too old to resume from; callers must hold w.mu
+func (w *Watcher) replay(lastEventID string) ([]Event, bool) {
+	seq, ok := w.parseEventID(lastEventID)
+	if !ok || seq > w.seq {
+		return nil, false
+	}
+	if seq == w.seq {
+		return nil, true
+	}
+
+	// The oldest retained event must directly follow the client's last event
+	if len(w.history) == 0 || seq+1 < w.firstRetainedSeq() {
+		return nil, false
+	}
+
+	missed := make([]Event, 0, w.seq-seq)
+	for _, event := range w.history {
+		if eventSeq, _ := w.parseEventID(event.ID); eventSeq > seq {
+			missed = append(missed, event)
+		}
+	}
+	return missed, true
+}
+
+func (w *Watcher) firstRetainedSeq() uint64 {
+	seq, _ := w.parseEventID(w.history[0].ID)
+	return seq
+}
+
+func (w *Watcher) unsubscribe(ch chan Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.subscribers[ch]; ok {
+		delete(w.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (w *Watcher) manifest() models.Manifest {
+	flags := make([]models.ManifestFlag, 0, len(w.flags))
+	for _, key := range sortedKeys(w.flags) {
+		flags = append(flags, w.flags[key])
+	}
+	return models.Manifest{Flags: flags}
+}
+
+func (w *Watcher) eventID(seq uint64) string {
+	return w.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+func (w *Watcher) parseEventID(id string) (uint64, bool) {
+	epoch, rawSeq, found := strings.Cut(id, "-")
+	if !found || epoch != w.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(rawSeq, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
+
+func sortedKeys(flags map[string]models.ManifestFlag) []string {
+	keys := make([]string, 0, len(flags))
+	for key := range flags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
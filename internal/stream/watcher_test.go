@@ -0,0 +1,228 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/openfeature/posthog-proxy/internal/posthog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func flag(id int, key string, active bool) models.PostHogFeatureFlag {
+	rollout := 100
+	return models.PostHogFeatureFlag{
+		ID:     id,
+		Key:    key,
+		Active: active,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: &rollout}},
+		},
+	}
+}
+
+// newTestWatcher returns a watcher whose successive polls return the given flag lists
+func newTestWatcher(t *testing.T, historySize int, polls ...[]models.PostHogFeatureFlag) *Watcher {
+	client := new(posthog.MockClient)
+	for _, flags := range polls {
+		client.On("GetFeatureFlags", mock.Anything).Return(flags, nil).Once()
+	}
+	w := NewWatcher(client, config.TypeCoercionConfig{}, 0, historySize)
+	require.NoError(t, w.Refresh(context.Background()))
+	return w
+}
+
+func TestWatcher_SubscribeBeforeFirstPoll(t *testing.T) {
+	client := new(posthog.MockClient)
+	client.On("GetFeatureFlags", mock.Anything).Return(nil, errors.New("unavailable"))
+	w := NewWatcher(client, config.TypeCoercionConfig{}, 0, 0)
+
+	assert.Error(t, w.Refresh(context.Background()))
+
+	_, err := w.Subscribe("")
+	assert.ErrorIs(t, err, ErrNotReady)
+}
+
+func TestWatcher_SnapshotOnConnect(t *testing.T) {
+	w := newTestWatcher(t, 0, []models.PostHogFeatureFlag{flag(2, "b", true), flag(1, "a", true)})
+
+	sub, err := w.Subscribe("")
+	require.NoError(t, err)
+	defer sub.Close()
+
+	require.Len(t, sub.Initial, 1)
+	assert.Equal(t, EventSnapshot, sub.Initial[0].Type)
+	manifest := sub.Initial[0].Data.(models.Manifest)
+	require.Len(t, manifest.Flags, 2)
+	assert.Equal(t, "a", manifest.Flags[0].Key)
+	assert.Equal(t, "b", manifest.Flags[1].Key)
+}
+
+func TestWatcher_PublishesDifferences(t *testing.T) {
+	deleted := flag(4, "d", true)
+	deleted.Deleted = true
+
+	w := newTestWatcher(t, 0,
+		[]models.PostHogFeatureFlag{flag(1, "a", true), flag(2, "b", true)},
+		[]models.PostHogFeatureFlag{flag(1, "a", false), flag(3, "c", true), deleted},
+	)
+
+	sub, err := w.Subscribe("")
+	require.NoError(t, err)
+	defer sub.Close()
+
+	require.NoError(t, w.Refresh(context.Background()))
+
+	var events []Event
+	for i := 0; i < 3; i++ {
+		events = append(events, <-sub.Events)
+	}
+
+	assert.Equal(t, EventFlagChanged, events[0].Type)
+	assert.Equal(t, models.FlagStateDisabled, events[0].Data.(models.ManifestFlag).State)
+	assert.Equal(t, EventFlagAdded, events[1].Type)
+	assert.Equal(t, "c", events[1].Data.(models.ManifestFlag).Key)
+	assert.Equal(t, EventFlagRemoved, events[2].Type)
+	assert.Equal(t, FlagRemoved{Key: "b"}, events[2].Data)
+	assert.Empty(t, sub.Events)
+}
+
+func TestWatcher_HidesArchivedFlags(t *testing.T) {
+	archived := flag(2, "b", false)
+	archived.Tags = []string{"archived-at:2026-03-01T10:00:00Z", "archived-state:ENABLED"}
+
+	w := newTestWatcher(t, 0,
+		[]models.PostHogFeatureFlag{flag(1, "a", true), flag(2, "b", true)},
+		[]models.PostHogFeatureFlag{flag(1, "a", true), archived},
+		[]models.PostHogFeatureFlag{flag(1, "a", true), flag(2, "b", true)},
+	)
+
+	sub, err := w.Subscribe("")
+	require.NoError(t, err)
+	defer sub.Close()
+
+	// Archiving a flag removes it rather than changing its state
+	require.NoError(t, w.Refresh(context.Background()))
+	event := <-sub.Events
+	assert.Equal(t, EventFlagRemoved, event.Type)
+	assert.Equal(t, FlagRemoved{Key: "b"}, event.Data)
+
+	// Snapshots taken while the flag is archived leave it out
+	later, err := w.Subscribe("")
+	require.NoError(t, err)
+	defer later.Close()
+	manifest := later.Initial[0].Data.(models.Manifest)
+	require.Len(t, manifest.Flags, 1)
+	assert.Equal(t, "a", manifest.Flags[0].Key)
+
+	// Restoring it adds it back
+	require.NoError(t, w.Refresh(context.Background()))
+	event = <-sub.Events
+	assert.Equal(t, EventFlagAdded, event.Type)
+	assert.Equal(t, "b", event.Data.(models.ManifestFlag).Key)
+}
+
+func TestWatcher_NoEventsWhenNothingChanged(t *testing.T) {
+	flags := []models.PostHogFeatureFlag{flag(1, "a", true)}
+	w := newTestWatcher(t, 0, flags, flags)
+
+	sub, err := w.Subscribe("")
+	require.NoError(t, err)
+	defer sub.Close()
+
+	require.NoError(t, w.Refresh(context.Background()))
+	assert.Empty(t, sub.Events)
+}
+
+func TestWatcher_ResumeFromLastEventID(t *testing.T) {
+	w := newTestWatcher(t, 0,
+		[]models.PostHogFeatureFlag{flag(1, "a", true)},
+		[]models.PostHogFeatureFlag{flag(1, "a", true), flag(2, "b", true)},
+		[]models.PostHogFeatureFlag{flag(1, "a", true), flag(2, "b", true), flag(3, "c", true)},
+	)
+
+	first, err := w.Subscribe("")
+	require.NoError(t, err)
+	snapshotID := first.Initial[0].ID
+	first.Close()
+
+	require.NoError(t, w.Refresh(context.Background()))
+	require.NoError(t, w.Refresh(context.Background()))
+
+	// Missed events are replayed in order
+	resumed, err := w.Subscribe(snapshotID)
+	require.NoError(t, err)
+	defer resumed.Close()
+	require.Len(t, resumed.Initial, 2)
+	assert.Equal(t, "b", resumed.Initial[0].Data.(models.ManifestFlag).Key)
+	assert.Equal(t, "c", resumed.Initial[1].Data.(models.ManifestFlag).Key)
+
+	// A client that saw the latest event gets nothing to replay
+	upToDate, err := w.Subscribe(resumed.Initial[1].ID)
+	require.NoError(t, err)
+	defer upToDate.Close()
+	assert.Empty(t, upToDate.Initial)
+
+	// IDs from another process or garbage fall back to a snapshot
+	for _, id := range []string{"other-1", "garbage", ""} {
+		sub, err := w.Subscribe(id)
+		require.NoError(t, err)
+		require.Len(t, sub.Initial, 1, id)
+		assert.Equal(t, EventSnapshot, sub.Initial[0].Type, id)
+		sub.Close()
+	}
+}
+
+func TestWatcher_SnapshotWhenHistoryTooShort(t *testing.T) {
+	w := newTestWatcher(t, 1,
+		[]models.PostHogFeatureFlag{flag(1, "a", true)},
+		[]models.PostHogFeatureFlag{flag(1, "a", true), flag(2, "b", true)},
+		[]models.PostHogFeatureFlag{flag(1, "a", true), flag(2, "b", true), flag(3, "c", true)},
+	)
+
+	first, err := w.Subscribe("")
+	require.NoError(t, err)
+	snapshotID := first.Initial[0].ID
+	first.Close()
+
+	require.NoError(t, w.Refresh(context.Background()))
+	require.NoError(t, w.Refresh(context.Background()))
+
+	// Only the latest event is retained, so the first change cannot be replayed
+	sub, err := w.Subscribe(snapshotID)
+	require.NoError(t, err)
+	defer sub.Close()
+	require.Len(t, sub.Initial, 1)
+	assert.Equal(t, EventSnapshot, sub.Initial[0].Type)
+	assert.Len(t, sub.Initial[0].Data.(models.Manifest).Flags, 3)
+}
+
+func TestWatcher_DisconnectsSlowSubscribers(t *testing.T) {
+	polls := make([][]models.PostHogFeatureFlag, 0, subscriberBuffer+2)
+	var flags []models.PostHogFeatureFlag
+	for i := 0; i < subscriberBuffer+2; i++ {
+		flags = append(flags, flag(i, string(rune('a'+i%26))+string(rune('a'+i/26)), true))
+		polls = append(polls, append([]models.PostHogFeatureFlag(nil), flags...))
+	}
+	w := newTestWatcher(t, 0, polls...)
+
+	sub, err := w.Subscribe("")
+	require.NoError(t, err)
+
+	for i := 1; i < len(polls); i++ {
+		require.NoError(t, w.Refresh(context.Background()))
+	}
+
+	received := 0
+	for range sub.Events {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+
+	// Closing an already dropped subscription is safe
+	sub.Close()
+}
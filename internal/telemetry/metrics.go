@@ -1,6 +1,7 @@
 package telemetry
 
 import (
+	"context"
 	"fmt"
 
 	"go.opentelemetry.io/otel"
@@ -14,6 +15,17 @@ type Metrics struct {
 	FlagsDeleted      metric.Int64Counter
 	ManifestRequests  metric.Int64Counter
 	PostHogAPIErrors  metric.Int64Counter
+	CacheHits         metric.Int64Counter
+	CacheMisses       metric.Int64Counter
+	CacheStaleServes  metric.Int64Counter
+	FlagEvaluations   metric.Int64Counter
+	StreamConnections metric.Int64Counter
+	// AuthenticatedRequests counts requests by the name of the calling client
+	AuthenticatedRequests metric.Int64Counter
+	// RateLimited counts requests rejected by the rate limiter, by budget and caller
+	RateLimited metric.Int64Counter
+	// CoalescedRequests counts PostHog reads that joined an identical read in flight
+	CoalescedRequests metric.Int64Counter
 }
 
 // NewMetrics initializes and returns the application metrics
@@ -55,11 +67,121 @@ func NewMetrics() (*Metrics, error) {
 		return nil, fmt.Errorf("failed to create posthog_api_errors_total counter: %w", err)
 	}
 
+	cacheHits, err := meter.Int64Counter("flag_cache_hits_total",
+		metric.WithDescription("Total number of feature flag list reads served from the cache"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create flag_cache_hits_total counter: %w", err)
+	}
+
+	cacheMisses, err := meter.Int64Counter("flag_cache_misses_total",
+		metric.WithDescription("Total number of feature flag list reads that had to be fetched from PostHog"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create flag_cache_misses_total counter: %w", err)
+	}
+
+	cacheStaleServes, err := meter.Int64Counter("flag_cache_stale_serves_total",
+		metric.WithDescription("Total number of feature flag list reads served from stale cache data because PostHog was unavailable"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create flag_cache_stale_serves_total counter: %w", err)
+	}
+
+	flagEvaluations, err := meter.Int64Counter("flag_evaluations_total",
+		metric.WithDescription("Total number of feature flags evaluated through the OFREP endpoints"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create flag_evaluations_total counter: %w", err)
+	}
+
+	streamConnections, err := meter.Int64Counter("manifest_stream_connections_total",
+		metric.WithDescription("Total number of manifest stream connections opened"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manifest_stream_connections_total counter: %w", err)
+	}
+
+	authenticatedRequests, err := meter.Int64Counter("authenticated_requests_total",
+		metric.WithDescription("Total number of authenticated requests, by calling client"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create authenticated_requests_total counter: %w", err)
+	}
+
+	rateLimited, err := meter.Int64Counter("rate_limited_requests_total",
+		metric.WithDescription("Total number of requests rejected because the caller or the proxy exceeded its rate limit"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rate_limited_requests_total counter: %w", err)
+	}
+
+	coalescedRequests, err := meter.Int64Counter("posthog_coalesced_requests_total",
+		metric.WithDescription("Total number of PostHog reads served by an identical read already in flight, by operation"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create posthog_coalesced_requests_total counter: %w", err)
+	}
+
 	return &Metrics{
-		FlagsCreated:     flagsCreated,
-		FlagsUpdated:     flagsUpdated,
-		FlagsDeleted:     flagsDeleted,
-		ManifestRequests: manifestRequests,
-		PostHogAPIErrors: posthogAPIErrors,
+		FlagsCreated:          flagsCreated,
+		FlagsUpdated:          flagsUpdated,
+		FlagsDeleted:          flagsDeleted,
+		ManifestRequests:      manifestRequests,
+		PostHogAPIErrors:      posthogAPIErrors,
+		CacheHits:             cacheHits,
+		CacheMisses:           cacheMisses,
+		CacheStaleServes:      cacheStaleServes,
+		FlagEvaluations:       flagEvaluations,
+		StreamConnections:     streamConnections,
+		AuthenticatedRequests: authenticatedRequests,
+		RateLimited:           rateLimited,
+		CoalescedRequests:     coalescedRequests,
 	}, nil
 }
+
+// ObserveCacheAge registers a gauge reporting the age of the cached flag list in seconds
+func (m *Metrics) ObserveCacheAge(age func() float64) error {
+	meter := otel.Meter("openfeature-posthog-proxy")
+
+	_, err := meter.Float64ObservableGauge("flag_cache_age_seconds",
+		metric.WithDescription("Seconds since the cached feature flag list was last refreshed from PostHog"),
+		metric.WithFloat64Callback(func(_ context.Context, o metric.Float64Observer) error {
+			o.Observe(age())
+			return nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create flag_cache_age_seconds gauge: %w", err)
+	}
+	return nil
+}
+
+// ObserveCircuitBreaker registers instruments reporting the PostHog circuit breaker's
+// state (0 closed, 1 half-open, 2 open) and how many requests it failed fast
+func (m *Metrics) ObserveCircuitBreaker(state func() int64, rejections func() int64) error {
+	meter := otel.Meter("openfeature-posthog-proxy")
+
+	_, err := meter.Int64ObservableGauge("posthog_circuit_breaker_state",
+		metric.WithDescription("State of the PostHog circuit breaker: 0 closed, 1 half-open, 2 open"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(state())
+			return nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create posthog_circuit_breaker_state gauge: %w", err)
+	}
+
+	_, err = meter.Int64ObservableCounter("posthog_circuit_breaker_rejections_total",
+		metric.WithDescription("Total number of PostHog API calls failed fast because the circuit breaker was open"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(rejections())
+			return nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create posthog_circuit_breaker_rejections_total counter: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package transformer
+
+import (
+	"strings"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+// Archiving sets a flag inactive and records when, and whether it was active before,
+// in tags so the flag can be told apart from one that is merely disabled and later
+// restored to its previous state.
+const (
+	archivedAtTagPrefix    = "archived-at:"
+	archivedStateTagPrefix = "archived-state:"
+)
+
+// ArchivedAt returns when the proxy archived the flag, or nil when it is not archived.
+// A flag that was re-enabled outside the proxy no longer counts as archived.
+func ArchivedAt(phFlag models.PostHogFeatureFlag) *time.Time {
+	if phFlag.Active {
+		return nil
+	}
+	for _, tag := range phFlag.Tags {
+		if strings.HasPrefix(tag, archivedAtTagPrefix) {
+			parsed, err := time.Parse(time.RFC3339, strings.TrimPrefix(tag, archivedAtTagPrefix))
+			if err == nil {
+				return &parsed
+			}
+		}
+	}
+	return nil
+}
+
+// ArchiveUpdate builds the update that archives a flag at the given time
+func ArchiveUpdate(phFlag models.PostHogFeatureFlag, archivedAt time.Time) models.PostHogUpdateFlagRequest {
+	previous := models.FlagStateDisabled
+	if phFlag.Active {
+		previous = models.FlagStateEnabled
+	}
+
+	tags := append(withoutArchiveTags(phFlag.Tags),
+		archivedAtTagPrefix+archivedAt.UTC().Format(time.RFC3339),
+		archivedStateTagPrefix+string(previous),
+	)
+	inactive := false
+	return models.PostHogUpdateFlagRequest{Active: &inactive, Tags: &tags}
+}
+
+// RestoreUpdate builds the update that un-archives a flag, reinstating the active
+// state it had when it was archived
+func RestoreUpdate(phFlag models.PostHogFeatureFlag) models.PostHogUpdateFlagRequest {
+	active := true
+	for _, tag := range phFlag.Tags {
+		if strings.HasPrefix(tag, archivedStateTagPrefix) {
+			active = models.FlagState(strings.TrimPrefix(tag, archivedStateTagPrefix)) != models.FlagStateDisabled
+		}
+	}
+
+	tags := withoutArchiveTags(phFlag.Tags)
+	if len(tags) == 0 {
+		tags = nil
+	}
+	return models.PostHogUpdateFlagRequest{Active: &active, Tags: &tags}
+}
+
+// ClearArchiveTags removes the archive tags of phFlag from an update, so an update
+// that brings an archived flag back does not leave it carrying archive metadata
+func ClearArchiveTags(update models.PostHogUpdateFlagRequest, phFlag models.PostHogFeatureFlag) models.PostHogUpdateFlagRequest {
+	tags := phFlag.Tags
+	if update.Tags != nil {
+		tags = *update.Tags
+	}
+
+	tags = withoutArchiveTags(tags)
+	if len(tags) == 0 {
+		tags = nil
+	}
+	update.Tags = &tags
+	return update
+}
+
+// WithoutArchived filters out flags the proxy has archived
+func WithoutArchived(posthogFlags []models.PostHogFeatureFlag) []models.PostHogFeatureFlag {
+	filtered := make([]models.PostHogFeatureFlag, 0, len(posthogFlags))
+	for _, phFlag := range posthogFlags {
+		if ArchivedAt(phFlag) == nil {
+			filtered = append(filtered, phFlag)
+		}
+	}
+	return filtered
+}
+
+func withoutArchiveTags(tags []string) []string {
+	filtered := make([]string, 0, len(tags)+2)
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, archivedAtTagPrefix) && !strings.HasPrefix(tag, archivedStateTagPrefix) {
+			filtered = append(filtered, tag)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,75 @@
+package transformer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveAndRestore(t *testing.T) {
+	archivedAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		active bool
+	}{
+		{name: "active flag is re-enabled", active: true},
+		{name: "disabled flag stays disabled", active: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flag := models.PostHogFeatureFlag{Key: "checkout", Active: tt.active, Tags: []string{"owner:payments"}}
+			assert.Nil(t, ArchivedAt(flag))
+
+			archive := ArchiveUpdate(flag, archivedAt)
+			require.NotNil(t, archive.Active)
+			assert.False(t, *archive.Active)
+			require.NotNil(t, archive.Tags)
+
+			flag.Active = false
+			flag.Tags = *archive.Tags
+			require.NotNil(t, ArchivedAt(flag))
+			assert.True(t, archivedAt.Equal(*ArchivedAt(flag)))
+
+			restore := RestoreUpdate(flag)
+			require.NotNil(t, restore.Active)
+			assert.Equal(t, tt.active, *restore.Active)
+			assert.Equal(t, []string{"owner:payments"}, *restore.Tags)
+		})
+	}
+}
+
+func TestArchivedAt_IgnoresActiveFlags(t *testing.T) {
+	flag := models.PostHogFeatureFlag{Key: "checkout", Active: true, Tags: []string{"archived-at:2026-03-01T10:00:00Z"}}
+	assert.Nil(t, ArchivedAt(flag))
+}
+
+func TestArchiveUpdate_ReplacesStaleArchiveTags(t *testing.T) {
+	flag := models.PostHogFeatureFlag{
+		Key:    "checkout",
+		Active: true,
+		Tags:   []string{"archived-at:2025-01-01T00:00:00Z", "archived-state:DISABLED"},
+	}
+
+	update := ArchiveUpdate(flag, time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, []string{"archived-at:2026-03-01T10:00:00Z", "archived-state:ENABLED"}, *update.Tags)
+}
+
+func TestWithoutArchived(t *testing.T) {
+	flags := []models.PostHogFeatureFlag{
+		{Key: "active", Active: true},
+		{Key: "disabled", Active: false},
+		{Key: "archived", Active: false, Tags: []string{"archived-at:2026-03-01T10:00:00Z"}},
+	}
+
+	filtered := WithoutArchived(flags)
+
+	require.Len(t, filtered, 2)
+	assert.Equal(t, "active", filtered[0].Key)
+	assert.Equal(t, "disabled", filtered[1].Key)
+}
@@ -281,3 +281,67 @@ func TestNonBooleanFlagRolloutNotAffected(t *testing.T) {
 	assert.NotNil(t, result.Filters.Groups[0].RolloutPercentage)
 	assert.Equal(t, 100, *result.Filters.Groups[0].RolloutPercentage, "Non-boolean flags should always have rollout 100")
 }
+
+// TestUpdateBooleanDefaultValue tests that updating the default of a boolean flag sets
+// the catch-all rollout, and that a partial rollout already reading as true is kept
+func TestUpdateBooleanDefaultValue(t *testing.T) {
+	rule := models.PostHogFilterGroup{
+		Properties:        []models.PostHogProperty{{Key: "plan", Value: "pro", Operator: "exact", Type: "person"}},
+		RolloutPercentage: intPtr(100),
+	}
+
+	tests := []struct {
+		name         string
+		groups       []models.PostHogFilterGroup
+		defaultValue bool
+		wantRollouts []int
+	}{
+		{
+			name:         "true to false",
+			groups:       []models.PostHogFilterGroup{{Properties: []models.PostHogProperty{}, RolloutPercentage: intPtr(100)}},
+			defaultValue: false,
+			wantRollouts: []int{0},
+		},
+		{
+			name:         "false to true",
+			groups:       []models.PostHogFilterGroup{rule, {Properties: []models.PostHogProperty{}, RolloutPercentage: intPtr(0)}},
+			defaultValue: true,
+			wantRollouts: []int{100, 100},
+		},
+		{
+			name:         "partial rollout already true",
+			groups:       []models.PostHogFilterGroup{{Properties: []models.PostHogProperty{}, RolloutPercentage: intPtr(30)}},
+			defaultValue: true,
+			wantRollouts: []int{30},
+		},
+		{
+			name:         "rules only",
+			groups:       []models.PostHogFilterGroup{rule},
+			defaultValue: false,
+			wantRollouts: []int{100, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := models.PostHogFeatureFlag{Key: "checkout", Active: true, Filters: models.PostHogFilters{Groups: tt.groups}}
+			catchAll := existing.Filters.Groups[len(tt.groups)-1].RolloutPercentage
+			before := *catchAll
+
+			update := OpenFeatureToPostHogUpdate(models.UpdateFlagRequest{DefaultValue: tt.defaultValue}, &existing)
+
+			if assert.NotNil(t, update.Filters) {
+				rollouts := make([]int, 0, len(update.Filters.Groups))
+				for _, group := range update.Filters.Groups {
+					rollouts = append(rollouts, *group.RolloutPercentage)
+				}
+				assert.Equal(t, tt.wantRollouts, rollouts)
+				assert.Nil(t, update.Filters.Payloads, "boolean defaults are not stored as payloads")
+
+				flag := PostHogToOpenFeatureFlag(models.PostHogFeatureFlag{Key: "checkout", Active: true, Filters: *update.Filters}, config.TypeCoercionConfig{})
+				assert.Equal(t, tt.defaultValue, flag.DefaultValue)
+			}
+			assert.Equal(t, before, *catchAll, "the existing flag must not be mutated")
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package transformer
+
+import (
+	"strings"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+// ManifestFilter selects manifest flags by their OpenFeature representation. Zero
+// fields match every flag.
+type ManifestFilter struct {
+	// KeyPrefix matches flags whose key starts with it
+	KeyPrefix string
+	// Search matches flags whose key or description contains it, ignoring case
+	Search string
+	State  models.FlagState
+	Type   models.FlagType
+	// Metadata matches flags carrying every one of these metadata entries
+	Metadata map[string]string
+	// ExpiredAt matches flags whose expiry has passed by then
+	ExpiredAt *time.Time
+}
+
+// Matches reports whether the manifest flag is selected by the filter
+func (f ManifestFilter) Matches(flag models.ManifestFlag) bool {
+	if f.KeyPrefix != "" && !strings.HasPrefix(flag.Key, f.KeyPrefix) {
+		return false
+	}
+	if f.Search != "" {
+		search := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(flag.Key), search) && !strings.Contains(strings.ToLower(flag.Description), search) {
+			return false
+		}
+	}
+	if f.State != "" && flag.State != f.State {
+		return false
+	}
+	if f.Type != "" && flag.Type != f.Type.Normalize() {
+		return false
+	}
+	for key, value := range f.Metadata {
+		if flag.Metadata[key] != value {
+			return false
+		}
+	}
+	if f.ExpiredAt != nil && (flag.Expiry == nil || flag.Expiry.After(*f.ExpiredAt)) {
+		return false
+	}
+	return true
+}
+
+// FilterPostHogFlags keeps the PostHog flags whose manifest representation matches the
+// filter. Type and expiry only exist once a flag is transformed, so this is where
+// filters PostHog can't apply itself are applied.
+func FilterPostHogFlags(posthogFlags []models.PostHogFeatureFlag, filter ManifestFilter, cfg config.TypeCoercionConfig) []models.PostHogFeatureFlag {
+	filtered := make([]models.PostHogFeatureFlag, 0, len(posthogFlags))
+	for _, phFlag := range posthogFlags {
+		if filter.Matches(PostHogToOpenFeatureFlag(phFlag, cfg)) {
+			filtered = append(filtered, phFlag)
+		}
+	}
+	return filtered
+}
+
+// MetadataTag returns the PostHog tag a manifest metadata entry is stored as
+func MetadataTag(key, value string) string {
+	return key + ":" + value
+}
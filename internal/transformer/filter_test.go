@@ -0,0 +1,49 @@
+package transformer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifestFilter_Matches(t *testing.T) {
+	expiry := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	flag := models.ManifestFlag{
+		Key:         "checkout-button",
+		Description: "Green Checkout Button",
+		Type:        models.FlagTypeFloat,
+		State:       models.FlagStateEnabled,
+		Expiry:      &expiry,
+		Metadata:    map[string]string{"owner": "payments", "domain": "web"},
+	}
+	before := expiry.Add(-time.Hour)
+	after := expiry.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		filter ManifestFilter
+		want   bool
+	}{
+		{name: "empty filter", filter: ManifestFilter{}, want: true},
+		{name: "key prefix", filter: ManifestFilter{KeyPrefix: "checkout-"}, want: true},
+		{name: "other key prefix", filter: ManifestFilter{KeyPrefix: "button"}, want: false},
+		{name: "search in description ignores case", filter: ManifestFilter{Search: "green"}, want: true},
+		{name: "search without match", filter: ManifestFilter{Search: "search"}, want: false},
+		{name: "state", filter: ManifestFilter{State: models.FlagStateDisabled}, want: false},
+		{name: "type alias", filter: ManifestFilter{Type: models.FlagTypeNumber}, want: true},
+		{name: "other type", filter: ManifestFilter{Type: models.FlagTypeBoolean}, want: false},
+		{name: "all metadata", filter: ManifestFilter{Metadata: map[string]string{"owner": "payments", "domain": "web"}}, want: true},
+		{name: "partial metadata", filter: ManifestFilter{Metadata: map[string]string{"owner": "payments", "domain": "mobile"}}, want: false},
+		{name: "expired", filter: ManifestFilter{ExpiredAt: &after}, want: true},
+		{name: "not yet expired", filter: ManifestFilter{ExpiredAt: &before}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Matches(flag))
+		})
+	}
+
+	assert.False(t, ManifestFilter{ExpiredAt: &after}.Matches(models.ManifestFlag{Key: "no-expiry"}))
+}
@@ -3,6 +3,7 @@ package transformer
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -75,3 +76,26 @@ func tryParseNumericString(s string) (interface{}, bool) {
 
 	return nil, false
 }
+
+// sortedPayloadKeys returns payload keys in a stable order so that detection
+// does not depend on Go's randomized map iteration
+func sortedPayloadKeys(payloads map[string]string) []string {
+	keys := make([]string, 0, len(payloads))
+	for key := range payloads {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// moveToFront moves key to the start of keys, keeping the order of the others
+func moveToFront(keys []string, key string) []string {
+	for i, candidate := range keys {
+		if candidate == key {
+			copy(keys[1:i+1], keys[:i])
+			keys[0] = key
+			break
+		}
+	}
+	return keys
+}
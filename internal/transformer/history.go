@@ -0,0 +1,83 @@
+package transformer
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/manifestdiff"
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+// PostHogActivityToHistory translates a flag's activity log into OpenFeature terms.
+// Each entry's raw field changes are applied to the current flag to rebuild the flag
+// before and after the change, and the two versions are compared as manifest flags,
+// so a filters change is reported as default value, variant or targeting changes.
+func PostHogActivityToHistory(current models.PostHogFeatureFlag, entries []models.PostHogActivityEntry, cfg config.TypeCoercionConfig) []models.FlagHistoryEntry {
+	history := make([]models.FlagHistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		history = append(history, models.FlagHistoryEntry{
+			Timestamp: entry.CreatedAt,
+			Action:    entry.Activity,
+			ChangedBy: activityActor(entry),
+			Changes:   activityChanges(current, entry.Detail.Changes, cfg),
+		})
+	}
+	return history
+}
+
+func activityChanges(current models.PostHogFeatureFlag, changes []models.PostHogActivityChange, cfg config.TypeCoercionConfig) []models.FieldChange {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	before, errBefore := applyActivityChanges(current, changes, func(change models.PostHogActivityChange) json.RawMessage { return change.Before })
+	after, errAfter := applyActivityChanges(current, changes, func(change models.PostHogActivityChange) json.RawMessage { return change.After })
+	if errBefore != nil || errAfter != nil {
+		return nil
+	}
+
+	return manifestdiff.Flag(PostHogToOpenFeatureFlag(before, cfg), PostHogToOpenFeatureFlag(after, cfg))
+}
+
+// applyActivityChanges overwrites the flag fields named in the changes with one side
+// of each change, going through JSON since the field names are PostHog's
+func applyActivityChanges(flag models.PostHogFeatureFlag, changes []models.PostHogActivityChange, side func(models.PostHogActivityChange) json.RawMessage) (models.PostHogFeatureFlag, error) {
+	encoded, err := json.Marshal(flag)
+	if err != nil {
+		return models.PostHogFeatureFlag{}, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return models.PostHogFeatureFlag{}, err
+	}
+
+	for _, change := range changes {
+		value := side(change)
+		if len(value) == 0 {
+			value = json.RawMessage("null")
+		}
+		fields[change.Field] = value
+	}
+
+	encoded, err = json.Marshal(fields)
+	if err != nil {
+		return models.PostHogFeatureFlag{}, err
+	}
+	var rebuilt models.PostHogFeatureFlag
+	if err := json.Unmarshal(encoded, &rebuilt); err != nil {
+		return models.PostHogFeatureFlag{}, err
+	}
+	return rebuilt, nil
+}
+
+// activityActor names who made a change: their email, else their name
+func activityActor(entry models.PostHogActivityEntry) string {
+	if entry.IsSystem || entry.User == nil {
+		return "system"
+	}
+	if entry.User.Email != "" {
+		return entry.User.Email
+	}
+	return strings.TrimSpace(entry.User.FirstName + " " + entry.User.LastName)
+}
@@ -0,0 +1,92 @@
+package transformer
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostHogActivityToHistory(t *testing.T) {
+	rollout := 100
+	current := models.PostHogFeatureFlag{
+		ID:     7,
+		Key:    "button-color",
+		Name:   "Button color",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: &rollout}},
+			Multivariate: &models.PostHogMultivariate{Variants: []models.PostHogVariant{
+				{Key: "blue", RolloutFlag: 20},
+				{Key: "green", RolloutFlag: 80},
+			}},
+		},
+	}
+
+	filtersBefore, _ := json.Marshal(models.PostHogFilters{
+		Groups: []models.PostHogFilterGroup{{RolloutPercentage: &rollout}},
+		Multivariate: &models.PostHogMultivariate{Variants: []models.PostHogVariant{
+			{Key: "blue", RolloutFlag: 50},
+			{Key: "green", RolloutFlag: 50},
+		}},
+	})
+	filtersAfter, _ := json.Marshal(current.Filters)
+	changedAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	entries := []models.PostHogActivityEntry{
+		{
+			User:     &models.PostHogUser{FirstName: "Ada", Email: "ada@example.com"},
+			Activity: "updated",
+			Detail: models.PostHogActivityDetail{Changes: []models.PostHogActivityChange{
+				{Field: "filters", Before: filtersBefore, After: filtersAfter},
+				{Field: "active", Before: json.RawMessage("false"), After: json.RawMessage("true")},
+			}},
+			CreatedAt: changedAt,
+		},
+		{
+			User:      &models.PostHogUser{FirstName: "Grace", LastName: "Hopper"},
+			Activity:  "created",
+			CreatedAt: changedAt.Add(-time.Hour),
+		},
+		{
+			Activity:  "updated",
+			IsSystem:  true,
+			CreatedAt: changedAt.Add(-2 * time.Hour),
+		},
+	}
+
+	history := PostHogActivityToHistory(current, entries, config.TypeCoercionConfig{})
+
+	require.Len(t, history, 3)
+	assert.Equal(t, changedAt, history[0].Timestamp)
+	assert.Equal(t, "updated", history[0].Action)
+	assert.Equal(t, "ada@example.com", history[0].ChangedBy)
+	assert.Equal(t, []models.FieldChange{
+		{Field: "variants.blue.weight", From: intPtr(50), To: intPtr(20)},
+		{Field: "variants.green.weight", From: intPtr(50), To: intPtr(80)},
+		{Field: "state", From: models.FlagStateDisabled, To: models.FlagStateEnabled},
+	}, history[0].Changes)
+
+	assert.Equal(t, "Grace Hopper", history[1].ChangedBy)
+	assert.Empty(t, history[1].Changes)
+	assert.Equal(t, "system", history[2].ChangedBy)
+}
+
+func TestPostHogActivityToHistory_UntranslatableChange(t *testing.T) {
+	current := models.PostHogFeatureFlag{ID: 7, Key: "checkout", Active: true}
+	entries := []models.PostHogActivityEntry{{
+		Activity: "updated",
+		Detail: models.PostHogActivityDetail{Changes: []models.PostHogActivityChange{
+			{Field: "filters", Before: json.RawMessage(`"not an object"`), After: json.RawMessage(`{}`)},
+		}},
+	}}
+
+	history := PostHogActivityToHistory(current, entries, config.TypeCoercionConfig{})
+
+	require.Len(t, history, 1)
+	assert.Empty(t, history[0].Changes)
+}
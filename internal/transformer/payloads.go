@@ -0,0 +1,201 @@
+package transformer
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+// booleanPayloadKey is the only payload key PostHog accepts for flags without variants
+const booleanPayloadKey = "true"
+
+// variantPayloads serializes OpenFeature variant values into PostHog payloads, the
+// inverse of convertPostHogVariants. Variants without a value keep their existing
+// payload; payloads of variants that no longer exist are dropped.
+func variantPayloads(flagType models.FlagType, variants map[string]models.Variant, existing map[string]string) map[string]string {
+	payloads := make(map[string]string, len(variants))
+
+	for key, variant := range variants {
+		if variant.Value == nil {
+			if payload, ok := existing[key]; ok {
+				payloads[key] = payload
+			}
+			continue
+		}
+
+		// A string value equal to the key is what PostHog reports without a payload
+		if str, ok := variant.Value.(string); ok && str == key {
+			continue
+		}
+
+		if payload, ok := encodeFlagPayload(flagType, variant.Value); ok {
+			payloads[key] = payload
+		}
+	}
+
+	if len(payloads) == 0 {
+		return nil
+	}
+	return payloads
+}
+
+// encodePayload renders a value as the JSON PostHog stores in payloads, so that
+// decodePayload reads back the same type
+func encodePayload(value interface{}) (string, bool) {
+	if value == nil {
+		return "", false
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+	return string(encoded), true
+}
+
+// encodeFlagPayload encodes a value of the given flag type. Whole numbers of float
+// flags keep a decimal point so they are not read back as integers.
+func encodeFlagPayload(flagType models.FlagType, value interface{}) (string, bool) {
+	if flagType.Normalize() == models.FlagTypeFloat {
+		if number, ok := value.(float64); ok && number == math.Trunc(number) && !math.IsInf(number, 0) {
+			return strconv.FormatFloat(number, 'f', 1, 64), true
+		}
+		if number, ok := value.(int); ok {
+			return strconv.Itoa(number) + ".0", true
+		}
+	}
+	return encodePayload(value)
+}
+
+// payloadValue reads a payload back into a typed value. Payloads are JSON, so
+// numbers, booleans, strings and objects keep their type; integers stay integers.
+// Payloads that are not valid JSON are coerced as configured or returned verbatim.
+func payloadValue(payload string, cfg config.TypeCoercionConfig) interface{} {
+	if isJSONObject(payload) {
+		if obj, err := parseJSONObject(payload); err == nil {
+			return obj
+		}
+	}
+
+	if json.Valid([]byte(payload)) {
+		decoder := json.NewDecoder(strings.NewReader(payload))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err == nil && value != nil {
+			if number, ok := value.(json.Number); ok {
+				if numValue, isNum := tryParseNumericString(number.String()); isNum {
+					return numValue
+				}
+			}
+			return value
+		}
+	}
+
+	if cfg.CoerceBooleanStrings {
+		if boolValue, isBool := tryParseBooleanString(payload); isBool {
+			return boolValue
+		}
+	}
+	if cfg.CoerceNumericStrings {
+		if numValue, isNum := tryParseNumericString(payload); isNum {
+			return numValue
+		}
+	}
+	return payload
+}
+
+// valueFlagType reports the flag type a decoded payload value belongs to
+func valueFlagType(value interface{}) models.FlagType {
+	switch value.(type) {
+	case bool:
+		return models.FlagTypeBoolean
+	case int, int64:
+		return models.FlagTypeInteger
+	case float64:
+		return models.FlagTypeFloat
+	case map[string]interface{}, []interface{}:
+		return models.FlagTypeObject
+	default:
+		return models.FlagTypeString
+	}
+}
+
+// valuesFlagType reports the flag type shared by all variant values. Integers mixed
+// with floats make a float flag; any other mix is reported as a string flag.
+func valuesFlagType(values []interface{}) models.FlagType {
+	flagType := valueFlagType(values[0])
+	for _, value := range values[1:] {
+		next := valueFlagType(value)
+		switch {
+		case next == flagType:
+		case isNumericFlagType(next) && isNumericFlagType(flagType):
+			flagType = models.FlagTypeFloat
+		default:
+			return models.FlagTypeString
+		}
+	}
+	return flagType
+}
+
+func isNumericFlagType(flagType models.FlagType) bool {
+	return flagType == models.FlagTypeInteger || flagType == models.FlagTypeFloat
+}
+
+// variantKeysDefaultFirst orders the variant keys of a new flag for PostHog. The
+// default variant, the first one holding the default value, goes first because the
+// manifest reads the default value back from the first variant unless a tag names
+// another; the others follow by key.
+func variantKeysDefaultFirst(variants map[string]models.Variant, defaultKey string) []string {
+	keys := make([]string, 0, len(variants))
+	for key := range variants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return moveToFront(keys, defaultKey)
+}
+
+// defaultVariantKey returns the key of the first variant, by key, whose value is the
+// default value. Variants without a value stand for their key.
+func defaultVariantKey(variants map[string]models.Variant, defaultValue interface{}) string {
+	if defaultValue == nil {
+		return ""
+	}
+	want, ok := encodePayload(defaultValue)
+	if !ok {
+		return ""
+	}
+
+	keys := make([]string, 0, len(variants))
+	for key := range variants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := variants[key].Value
+		if value == nil {
+			value = key
+		}
+		if got, ok := encodePayload(value); ok && got == want {
+			return key
+		}
+	}
+	return ""
+}
+
+// WithoutPayloadVariant drops the variant the manifest reports for the flag-level
+// payload of a flag without PostHog variants. It mirrors the flag's value rather than
+// a PostHog variant, so it has no weight and is never written back as a variant.
+func WithoutPayloadVariant(variants map[string]models.Variant) map[string]models.Variant {
+	if len(variants) != 1 {
+		return variants
+	}
+	if variant, ok := variants[booleanPayloadKey]; ok && variant.Weight == nil {
+		return nil
+	}
+	return variants
+}
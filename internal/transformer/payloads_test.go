@@ -0,0 +1,312 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodePayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected string
+		ok       bool
+	}{
+		{name: "object", value: map[string]interface{}{"color": "blue", "size": 2.0}, expected: `{"color":"blue","size":2}`, ok: true},
+		{name: "array", value: []interface{}{"a", "b"}, expected: `["a","b"]`, ok: true},
+		{name: "string", value: "hello", expected: `"hello"`, ok: true},
+		{name: "numeric string", value: "3", expected: `"3"`, ok: true},
+		{name: "integer", value: 42, expected: "42", ok: true},
+		{name: "float", value: 1.5, expected: "1.5", ok: true},
+		{name: "boolean", value: false, expected: "false", ok: true},
+		{name: "nil", value: nil, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, ok := encodePayload(tt.value)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, payload)
+		})
+	}
+}
+
+func TestVariantPayloads(t *testing.T) {
+	payloads := variantPayloads(models.FlagTypeObject, map[string]models.Variant{
+		"blue":    {Value: map[string]interface{}{"hex": "#0000ff"}},
+		"green":   {Value: "green"},
+		"label":   {Value: "Buy now"},
+		"kept":    {},
+		"missing": {},
+	}, map[string]string{"kept": `{"a":1}`, "removed": "stale"})
+
+	assert.Equal(t, map[string]string{
+		"blue":  `{"hex":"#0000ff"}`,
+		"label": `"Buy now"`,
+		"kept":  `{"a":1}`,
+	}, payloads)
+
+	assert.Nil(t, variantPayloads(models.FlagTypeString, map[string]models.Variant{"on": {Value: "on"}}, nil))
+}
+
+func TestObjectFlagRoundTrip(t *testing.T) {
+	req := models.CreateFlagRequest{
+		Key:          "checkout-theme",
+		Type:         models.FlagTypeObject,
+		DefaultValue: map[string]interface{}{"primary": "#000000"},
+		Variants: map[string]models.Variant{
+			"dark":  {Value: map[string]interface{}{"primary": "#000000"}, Weight: intPtr(50)},
+			"light": {Value: map[string]interface{}{"primary": "#ffffff", "rounded": true}, Weight: intPtr(50)},
+		},
+	}
+
+	created := OpenFeatureToPostHogCreate(req, 100)
+	require.NotNil(t, created.Filters.Payloads)
+
+	flag := PostHogToOpenFeatureFlag(models.PostHogFeatureFlag{
+		Key:     req.Key,
+		Active:  true,
+		Filters: created.Filters,
+	}, config.TypeCoercionConfig{})
+
+	assert.Equal(t, models.FlagTypeObject, flag.Type)
+	assert.Equal(t, req.DefaultValue, flag.DefaultValue)
+	require.Len(t, flag.Variants, 2)
+	for key, variant := range req.Variants {
+		assert.Equal(t, variant.Value, flag.Variants[key].Value, key)
+		assert.Equal(t, *variant.Weight, *flag.Variants[key].Weight, key)
+	}
+}
+
+func TestStringVariantValuesRoundTrip(t *testing.T) {
+	req := models.CreateFlagRequest{
+		Key:          "cta-copy",
+		Type:         models.FlagTypeString,
+		DefaultValue: "control",
+		Variants: map[string]models.Variant{
+			"control":   {Value: "control", Weight: intPtr(50)},
+			"treatment": {Value: "Start your free trial", Weight: intPtr(50)},
+		},
+	}
+
+	created := OpenFeatureToPostHogCreate(req, 100)
+	assert.Equal(t, map[string]string{"treatment": `"Start your free trial"`}, created.Filters.Payloads)
+
+	flag := PostHogToOpenFeatureFlag(models.PostHogFeatureFlag{
+		Key:     req.Key,
+		Active:  true,
+		Filters: created.Filters,
+	}, config.TypeCoercionConfig{})
+
+	assert.Equal(t, "control", flag.Variants["control"].Value)
+	assert.Equal(t, "Start your free trial", flag.Variants["treatment"].Value)
+}
+
+func TestNumericVariantValuesRoundTripWithCoercion(t *testing.T) {
+	req := models.CreateFlagRequest{
+		Key:          "max-items",
+		Type:         models.FlagTypeInteger,
+		DefaultValue: 10,
+		Variants: map[string]models.Variant{
+			"small": {Value: 10, Weight: intPtr(50)},
+			"large": {Value: 50, Weight: intPtr(50)},
+		},
+	}
+
+	created := OpenFeatureToPostHogCreate(req, 100)
+	assert.Equal(t, map[string]string{"small": "10", "large": "50"}, created.Filters.Payloads)
+
+	flag := PostHogToOpenFeatureFlag(models.PostHogFeatureFlag{
+		Key:     req.Key,
+		Active:  true,
+		Filters: created.Filters,
+	}, config.TypeCoercionConfig{CoerceNumericStrings: true})
+
+	assert.Equal(t, 10, flag.Variants["small"].Value)
+	assert.Equal(t, 50, flag.Variants["large"].Value)
+}
+
+func TestObjectFlagWithoutVariantsWritesFlagPayload(t *testing.T) {
+	created := OpenFeatureToPostHogCreate(models.CreateFlagRequest{
+		Key:          "limits",
+		Type:         models.FlagTypeObject,
+		DefaultValue: map[string]interface{}{"requests": 100.0},
+	}, 100)
+
+	assert.Equal(t, map[string]string{"true": `{"requests":100}`}, created.Filters.Payloads)
+
+	flag := PostHogToOpenFeatureFlag(models.PostHogFeatureFlag{
+		Key:     "limits",
+		Active:  true,
+		Filters: created.Filters,
+	}, config.TypeCoercionConfig{})
+
+	assert.Equal(t, models.FlagTypeObject, flag.Type)
+	assert.Equal(t, map[string]interface{}{"requests": 100.0}, flag.DefaultValue)
+}
+
+func TestUpdateVariantsWritesPayloads(t *testing.T) {
+	existing := models.PostHogFeatureFlag{
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: intPtr(100)}},
+			Multivariate: &models.PostHogMultivariate{
+				Variants: []models.PostHogVariant{{Key: "a", RolloutFlag: 50}, {Key: "b", RolloutFlag: 50}},
+			},
+			Payloads: map[string]string{"a": `{"v":1}`, "b": `{"v":2}`},
+		},
+	}
+
+	update := OpenFeatureToPostHogUpdate(models.UpdateFlagRequest{
+		Variants: &map[string]models.Variant{
+			"a": {Weight: intPtr(50)},
+			"c": {Value: map[string]interface{}{"v": 3}, Weight: intPtr(50)},
+		},
+	}, &existing)
+
+	require.NotNil(t, update.Filters)
+	assert.Equal(t, map[string]string{"a": `{"v":1}`, "c": `{"v":3}`}, update.Filters.Payloads)
+
+	cleared := OpenFeatureToPostHogUpdate(models.UpdateFlagRequest{
+		Variants: &map[string]models.Variant{},
+	}, &existing)
+
+	require.NotNil(t, cleared.Filters)
+	assert.Nil(t, cleared.Filters.Payloads)
+}
+
+func TestCreateRoundTripKeepsTypeAndDefault(t *testing.T) {
+	tests := []struct {
+		name string
+		req  models.CreateFlagRequest
+	}{
+		{
+			name: "boolean",
+			req:  models.CreateFlagRequest{Type: models.FlagTypeBoolean, DefaultValue: false},
+		},
+		{
+			name: "string",
+			req:  models.CreateFlagRequest{Type: models.FlagTypeString, DefaultValue: "blue"},
+		},
+		{
+			name: "string variants",
+			req: models.CreateFlagRequest{
+				Type:         models.FlagTypeString,
+				DefaultValue: "red",
+				Variants: map[string]models.Variant{
+					"a": {Value: "blue", Weight: intPtr(50)},
+					"b": {Value: "red", Weight: intPtr(50)},
+				},
+			},
+		},
+		{
+			name: "integer",
+			req:  models.CreateFlagRequest{Type: models.FlagTypeInteger, DefaultValue: 3},
+		},
+		{
+			name: "integer variants",
+			req: models.CreateFlagRequest{
+				Type:         models.FlagTypeInteger,
+				DefaultValue: 50,
+				Variants: map[string]models.Variant{
+					"large": {Value: 50, Weight: intPtr(50)},
+					"small": {Value: 10, Weight: intPtr(50)},
+				},
+			},
+		},
+		{
+			name: "float",
+			req:  models.CreateFlagRequest{Type: models.FlagTypeFloat, DefaultValue: 0.25},
+		},
+		{
+			name: "whole float",
+			req:  models.CreateFlagRequest{Type: models.FlagTypeFloat, DefaultValue: 1.0},
+		},
+		{
+			name: "float variants",
+			req: models.CreateFlagRequest{
+				Type:         models.FlagTypeFloat,
+				DefaultValue: 0.25,
+				Variants: map[string]models.Variant{
+					"high": {Value: 0.75, Weight: intPtr(50)},
+					"low":  {Value: 0.25, Weight: intPtr(50)},
+				},
+			},
+		},
+		{
+			name: "object",
+			req:  models.CreateFlagRequest{Type: models.FlagTypeObject, DefaultValue: map[string]interface{}{"limit": 10.0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Key = "round-trip"
+			created := OpenFeatureToPostHogCreate(tt.req, 100)
+
+			flag := PostHogToOpenFeatureFlag(models.PostHogFeatureFlag{
+				Key:     tt.req.Key,
+				Active:  true,
+				Filters: created.Filters,
+			}, config.TypeCoercionConfig{})
+
+			assert.Equal(t, tt.req.Type, flag.Type)
+			assert.Equal(t, tt.req.DefaultValue, flag.DefaultValue)
+			for key, variant := range tt.req.Variants {
+				assert.Equal(t, variant.Value, flag.Variants[key].Value, key)
+			}
+		})
+	}
+}
+
+func TestUpdateDefaultValueRoundTrip(t *testing.T) {
+	created := OpenFeatureToPostHogCreate(models.CreateFlagRequest{
+		Key:          "sample-rate",
+		Type:         models.FlagTypeFloat,
+		DefaultValue: 0.25,
+		Variants: map[string]models.Variant{
+			"high": {Value: 0.75, Weight: intPtr(50)},
+			"low":  {Value: 0.25, Weight: intPtr(50)},
+		},
+	}, 100)
+	existing := models.PostHogFeatureFlag{Key: "sample-rate", Active: true, Filters: created.Filters}
+
+	update := OpenFeatureToPostHogUpdate(models.UpdateFlagRequest{DefaultValue: 0.75}, &existing)
+	require.NotNil(t, update.Filters)
+
+	require.NotNil(t, update.Tags)
+	assert.Equal(t, []string{"default-variant:high"}, *update.Tags)
+	assert.Equal(t, existing.Filters.Multivariate.Variants, update.Filters.Multivariate.Variants, "the variant order must not change")
+
+	flag := PostHogToOpenFeatureFlag(models.PostHogFeatureFlag{Key: "sample-rate", Active: true, Filters: *update.Filters, Tags: *update.Tags}, config.TypeCoercionConfig{})
+	assert.Equal(t, models.FlagTypeFloat, flag.Type)
+	assert.Equal(t, 0.75, flag.DefaultValue)
+	assert.Equal(t, "low", existing.Filters.Multivariate.Variants[0].Key, "the existing flag must not be mutated")
+}
+
+func TestPayloadValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		cfg      config.TypeCoercionConfig
+		expected interface{}
+	}{
+		{name: "integer", payload: "3", expected: 3},
+		{name: "float", payload: "0.25", expected: 0.25},
+		{name: "whole float", payload: "1.0", expected: 1.0},
+		{name: "boolean", payload: "false", expected: false},
+		{name: "JSON string", payload: `"3"`, expected: "3"},
+		{name: "object", payload: `{"a":1}`, expected: map[string]interface{}{"a": 1.0}},
+		{name: "plain text", payload: "Buy now", expected: "Buy now"},
+		{name: "coerced text", payload: "yes", cfg: config.TypeCoercionConfig{CoerceBooleanStrings: true}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, payloadValue(tt.payload, tt.cfg))
+		})
+	}
+}
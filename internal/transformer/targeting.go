@@ -0,0 +1,101 @@
+package transformer
+
+import (
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+const defaultPropertyType = "person"
+
+// targetingToGroups converts OpenFeature targeting rules to PostHog release conditions
+func targetingToGroups(targeting *models.Targeting) []models.PostHogFilterGroup {
+	if targeting == nil {
+		return nil
+	}
+
+	groups := make([]models.PostHogFilterGroup, 0, len(targeting.Rules))
+	for _, rule := range targeting.Rules {
+		properties := make([]models.PostHogProperty, 0, len(rule.Conditions))
+		for _, condition := range rule.Conditions {
+			propertyType := condition.Type
+			if propertyType == "" {
+				propertyType = defaultPropertyType
+			}
+			operator := condition.Operator
+			if operator == "" {
+				operator = "exact"
+			}
+			properties = append(properties, models.PostHogProperty{
+				Key:      condition.Property,
+				Type:     propertyType,
+				Value:    condition.Value,
+				Operator: operator,
+			})
+		}
+
+		rollout := 100
+		if rule.RolloutPercentage != nil {
+			rollout = *rule.RolloutPercentage
+		}
+
+		group := models.PostHogFilterGroup{
+			Properties:        properties,
+			RolloutPercentage: &rollout,
+		}
+		if rule.Variant != "" {
+			variant := rule.Variant
+			group.Variant = &variant
+		}
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// groupsToTargeting converts PostHog release conditions back to OpenFeature targeting.
+// The trailing catch-all group holds the default rollout and is not reported as a rule.
+func groupsToTargeting(groups []models.PostHogFilterGroup) *models.Targeting {
+	rules, _ := splitDefaultGroup(groups)
+	if len(rules) == 0 {
+		return nil
+	}
+
+	targeting := &models.Targeting{Rules: make([]models.TargetingRule, 0, len(rules))}
+	for _, group := range rules {
+		rule := models.TargetingRule{}
+		if group.RolloutPercentage != nil {
+			rollout := *group.RolloutPercentage
+			rule.RolloutPercentage = &rollout
+		}
+		if group.Variant != nil {
+			rule.Variant = *group.Variant
+		}
+		for _, property := range group.Properties {
+			condition := models.TargetingCondition{
+				Property: property.Key,
+				Operator: property.Operator,
+				Value:    property.Value,
+			}
+			if property.Type != defaultPropertyType {
+				condition.Type = property.Type
+			}
+			rule.Conditions = append(rule.Conditions, condition)
+		}
+		targeting.Rules = append(targeting.Rules, rule)
+	}
+
+	return targeting
+}
+
+// splitDefaultGroup separates targeting rules from the trailing catch-all group
+// (no properties, no variant override) that carries the flag's default rollout
+func splitDefaultGroup(groups []models.PostHogFilterGroup) ([]models.PostHogFilterGroup, *models.PostHogFilterGroup) {
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	last := groups[len(groups)-1]
+	if len(last.Properties) == 0 && last.Variant == nil {
+		return groups[:len(groups)-1], &last
+	}
+	return groups, nil
+}
@@ -0,0 +1,205 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargeting_CreateRoundTrip(t *testing.T) {
+	targeting := &models.Targeting{
+		Rules: []models.TargetingRule{
+			{
+				Conditions: []models.TargetingCondition{
+					{Property: "email", Operator: "icontains", Value: "@example.com"},
+					{Property: "plan", Value: []interface{}{"pro", "enterprise"}},
+				},
+				RolloutPercentage: intPtr(50),
+			},
+			{
+				Conditions: []models.TargetingCondition{{Property: "beta", Operator: "is_set"}},
+			},
+		},
+	}
+
+	req := models.CreateFlagRequest{
+		Key:          "new-checkout",
+		Type:         models.FlagTypeBoolean,
+		DefaultValue: false,
+		Targeting:    targeting,
+	}
+
+	posthogReq := OpenFeatureToPostHogCreate(req, 0)
+
+	groups := posthogReq.Filters.Groups
+	require.Len(t, groups, 3)
+	assert.Equal(t, []models.PostHogProperty{
+		{Key: "email", Type: "person", Operator: "icontains", Value: "@example.com"},
+		{Key: "plan", Type: "person", Operator: "exact", Value: []interface{}{"pro", "enterprise"}},
+	}, groups[0].Properties)
+	assert.Equal(t, 50, *groups[0].RolloutPercentage)
+	assert.Equal(t, 100, *groups[1].RolloutPercentage)
+	// The trailing default group carries the boolean default value
+	assert.Empty(t, groups[2].Properties)
+	assert.Equal(t, 0, *groups[2].RolloutPercentage)
+
+	flag := PostHogToOpenFeatureFlag(models.PostHogFeatureFlag{
+		Key:     "new-checkout",
+		Active:  true,
+		Filters: posthogReq.Filters,
+	}, config.TypeCoercionConfig{})
+
+	assert.Equal(t, false, flag.DefaultValue)
+	require.NotNil(t, flag.Targeting)
+	require.Len(t, flag.Targeting.Rules, 2)
+	assert.Equal(t, "plan", flag.Targeting.Rules[0].Conditions[1].Property)
+	assert.Equal(t, "exact", flag.Targeting.Rules[0].Conditions[1].Operator)
+	assert.Empty(t, flag.Targeting.Rules[0].Conditions[1].Type)
+	assert.Equal(t, 50, *flag.Targeting.Rules[0].RolloutPercentage)
+	assert.Equal(t, "is_set", flag.Targeting.Rules[1].Conditions[0].Operator)
+}
+
+func TestTargeting_FlagWithoutRulesHasNoTargeting(t *testing.T) {
+	flag := PostHogToOpenFeatureFlag(models.PostHogFeatureFlag{
+		Key:    "simple",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: intPtr(100)}},
+		},
+	}, config.TypeCoercionConfig{})
+
+	assert.Nil(t, flag.Targeting)
+}
+
+func TestTargeting_VariantOverride(t *testing.T) {
+	variant := "treatment"
+	flag := PostHogToOpenFeatureFlag(models.PostHogFeatureFlag{
+		Key:    "experiment",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{
+				{
+					Properties:        []models.PostHogProperty{{Key: "company", Type: "group", Operator: "exact", Value: "acme"}},
+					RolloutPercentage: intPtr(100),
+					Variant:           &variant,
+				},
+				{RolloutPercentage: intPtr(100)},
+			},
+			Multivariate: &models.PostHogMultivariate{
+				Variants: []models.PostHogVariant{{Key: "control", RolloutFlag: 50}, {Key: "treatment", RolloutFlag: 50}},
+			},
+		},
+	}, config.TypeCoercionConfig{})
+
+	require.NotNil(t, flag.Targeting)
+	require.Len(t, flag.Targeting.Rules, 1)
+	assert.Equal(t, "treatment", flag.Targeting.Rules[0].Variant)
+	assert.Equal(t, "group", flag.Targeting.Rules[0].Conditions[0].Type)
+}
+
+func TestTargeting_UpdateReplacesRulesAndKeepsDefaultGroup(t *testing.T) {
+	existing := models.PostHogFeatureFlag{
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{
+				{Properties: []models.PostHogProperty{{Key: "plan", Type: "person", Operator: "exact", Value: "pro"}}, RolloutPercentage: intPtr(100)},
+				{Properties: []models.PostHogProperty{}, RolloutPercentage: intPtr(0)},
+			},
+			Multivariate: &models.PostHogMultivariate{
+				Variants: []models.PostHogVariant{{Key: "on", RolloutFlag: 100}},
+			},
+		},
+	}
+
+	update := OpenFeatureToPostHogUpdate(models.UpdateFlagRequest{
+		Targeting: &models.Targeting{
+			Rules: []models.TargetingRule{{
+				Conditions: []models.TargetingCondition{{Property: "country", Operator: "exact", Value: "NL"}},
+				Variant:    "on",
+			}},
+		},
+	}, &existing)
+
+	require.NotNil(t, update.Filters)
+	groups := update.Filters.Groups
+	require.Len(t, groups, 2)
+	assert.Equal(t, "country", groups[0].Properties[0].Key)
+	require.NotNil(t, groups[0].Variant)
+	assert.Equal(t, "on", *groups[0].Variant)
+	assert.Equal(t, 0, *groups[1].RolloutPercentage)
+	// Targeting-only updates keep the existing variants
+	assert.Equal(t, existing.Filters.Multivariate, update.Filters.Multivariate)
+}
+
+func TestTargeting_ClearingRules(t *testing.T) {
+	existing := models.PostHogFeatureFlag{
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{
+				{Properties: []models.PostHogProperty{{Key: "plan", Value: "pro"}}, RolloutPercentage: intPtr(100)},
+			},
+		},
+	}
+
+	update := OpenFeatureToPostHogUpdate(models.UpdateFlagRequest{Targeting: &models.Targeting{}}, &existing)
+
+	require.NotNil(t, update.Filters)
+	require.Len(t, update.Filters.Groups, 1)
+	assert.Empty(t, update.Filters.Groups[0].Properties)
+	// The flag only served its rules, so clearing them must not enable it for everyone
+	assert.Equal(t, 0, *update.Filters.Groups[0].RolloutPercentage)
+}
+
+func TestTargeting_UpdateWithoutCatchAllGroupAddsNone(t *testing.T) {
+	existing := models.PostHogFeatureFlag{
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{
+				{Properties: []models.PostHogProperty{{Key: "email", Type: "person", Operator: "icontains", Value: "@example.com"}}, RolloutPercentage: intPtr(100)},
+			},
+		},
+	}
+
+	update := OpenFeatureToPostHogUpdate(models.UpdateFlagRequest{
+		Targeting: &models.Targeting{
+			Rules: []models.TargetingRule{
+				{Conditions: []models.TargetingCondition{{Property: "email", Operator: "icontains", Value: "@example.com"}}},
+				{Conditions: []models.TargetingCondition{{Property: "beta", Operator: "exact", Value: true}}, RolloutPercentage: intPtr(10)},
+			},
+		},
+	}, &existing)
+
+	require.NotNil(t, update.Filters)
+	groups := update.Filters.Groups
+	require.Len(t, groups, 2)
+	for _, group := range groups {
+		assert.NotEmpty(t, group.Properties, "no catch-all group may be added")
+	}
+	assert.Equal(t, 10, *groups[1].RolloutPercentage)
+}
+
+func TestTargeting_VariantUpdateDropsStaleOverrides(t *testing.T) {
+	removed := "old"
+	kept := "b"
+	existing := models.PostHogFeatureFlag{
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{
+				{Properties: []models.PostHogProperty{{Key: "a", Value: "1"}}, Variant: &removed},
+				{Properties: []models.PostHogProperty{{Key: "b", Value: "2"}}, Variant: &kept},
+			},
+		},
+	}
+
+	update := OpenFeatureToPostHogUpdate(models.UpdateFlagRequest{
+		Variants: &map[string]models.Variant{"a": {Weight: intPtr(50)}, "b": {Weight: intPtr(50)}},
+	}, &existing)
+
+	require.NotNil(t, update.Filters)
+	assert.Nil(t, update.Filters.Groups[0].Variant)
+	require.NotNil(t, update.Filters.Groups[1].Variant)
+	assert.Equal(t, "b", *update.Filters.Groups[1].Variant)
+	// The existing flag must not be mutated
+	assert.Equal(t, "old", *existing.Filters.Groups[0].Variant)
+}
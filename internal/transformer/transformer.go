@@ -19,7 +19,8 @@ var metadataTagWhitelist = map[string]struct{}{
 	"lifetime": {},
 }
 
-// PostHogToOpenFeatureManifest transforms PostHog feature flags to OpenFeature manifest format
+// PostHogToOpenFeatureManifest transforms PostHog feature flags to OpenFeature manifest format.
+// Flags are ordered by key so the same set of PostHog flags always yields the same manifest.
 func PostHogToOpenFeatureManifest(posthogFlags []models.PostHogFeatureFlag, cfg config.TypeCoercionConfig) models.Manifest {
 	flags := make([]models.ManifestFlag, 0, len(posthogFlags))
 
@@ -27,6 +28,10 @@ func PostHogToOpenFeatureManifest(posthogFlags []models.PostHogFeatureFlag, cfg
 		flags = append(flags, PostHogToOpenFeatureFlag(phFlag, cfg))
 	}
 
+	sort.SliceStable(flags, func(i, j int) bool {
+		return flags[i].Key < flags[j].Key
+	})
+
 	return models.Manifest{
 		Flags: flags,
 	}
@@ -63,6 +68,8 @@ func PostHogToOpenFeatureFlag(phFlag models.PostHogFeatureFlag, cfg config.TypeC
 		State:        state,
 		Expiry:       expiry,
 		Metadata:     metadata,
+		Targeting:    groupsToTargeting(phFlag.Filters.Groups),
+		ArchivedAt:   ArchivedAt(phFlag),
 	}
 }
 
@@ -111,15 +118,21 @@ func OpenFeatureToPostHogCreate(req models.CreateFlagRequest, defaultRollout int
 func OpenFeatureToPostHogUpdate(req models.UpdateFlagRequest, existingFlag *models.PostHogFeatureFlag) models.PostHogUpdateFlagRequest {
 	update := mapBasicUpdateFields(req)
 
-	// Handle filters update if variants changed
-	if req.Variants != nil {
-		filters := reconcileFilters(req, existingFlag)
-		update.Filters = filters
-	}
-
+	// Handle filters update if variants, targeting or the default changed
+	flagType := updateFlagType(req, existingFlag)
 	tagsToUpdate := existingFlag.Tags
 	tagsUpdated := false
 
+	if req.Variants != nil || req.Targeting != nil || req.DefaultValue != nil {
+		filters, defaultKey := reconcileFilters(req, existingFlag, flagType)
+		update.Filters = filters
+
+		if defaultKey == "" {
+			defaultKey = DefaultVariant(*existingFlag)
+		}
+		tagsToUpdate, tagsUpdated = applyDefaultVariantTag(tagsToUpdate, filters.Multivariate, defaultKey)
+	}
+
 	if req.Metadata != nil {
 		tagsToUpdate = applyMetadataTags(tagsToUpdate, *req.Metadata)
 		tagsUpdated = true
@@ -159,24 +172,38 @@ func mapBasicUpdateFields(req models.UpdateFlagRequest) models.PostHogUpdateFlag
 	return update
 }
 
-// reconcileFilters updates filters while preserving existing PostHog configurations
-func reconcileFilters(req models.UpdateFlagRequest, existingFlag *models.PostHogFeatureFlag) *models.PostHogFilters {
+// updateFlagType is the type the updated flag will have: the requested type, or the
+// type the flag currently reads back as
+func updateFlagType(req models.UpdateFlagRequest, existingFlag *models.PostHogFeatureFlag) models.FlagType {
+	if req.Type != nil {
+		return req.Type.Normalize()
+	}
+	flagType, _ := determineFlagTypeAndValue(*existingFlag, config.TypeCoercionConfig{})
+	return flagType
+}
+
+// reconcileFilters updates filters while preserving existing PostHog configurations. It
+// also returns the key of the variant holding a requested non-boolean default value.
+func reconcileFilters(req models.UpdateFlagRequest, existingFlag *models.PostHogFeatureFlag, flagType models.FlagType) (*models.PostHogFilters, string) {
 	filters := models.PostHogFilters{}
 
-	// Preserve existing groups if they exist, otherwise create default
-	// This ensures we don't lose targeting rules that may have been configured in PostHog UI
-	if len(existingFlag.Filters.Groups) > 0 {
-		filters.Groups = existingFlag.Filters.Groups
-	} else {
-		// Create default group with 100% rollout if none exists
-		defaultRolloutPercentage := 100
-		filters.Groups = []models.PostHogFilterGroup{
-			{
-				Properties:        []models.PostHogProperty{},
-				RolloutPercentage: &defaultRolloutPercentage,
-				Variant:           nil,
-			},
+	if req.Targeting != nil {
+		// Replace the targeting rules but keep the existing default rollout. A flag
+		// without a catch-all group only serves its rules, so none is added for it
+		filters.Groups = targetingToGroups(req.Targeting)
+		if _, defaultGroup := splitDefaultGroup(existingFlag.Filters.Groups); defaultGroup != nil {
+			filters.Groups = append(filters.Groups, *defaultGroup)
 		}
+	} else if len(existingFlag.Filters.Groups) > 0 {
+		// Preserve existing groups so we don't lose targeting rules
+		// that may have been configured in PostHog UI
+		filters.Groups = append([]models.PostHogFilterGroup(nil), existingFlag.Filters.Groups...)
+	}
+
+	// PostHog requires at least one group. A flag without release conditions
+	// matches nobody, so the group that stands in for them rolls out to 0%
+	if len(filters.Groups) == 0 {
+		filters.Groups = []models.PostHogFilterGroup{newDefaultGroup(0)}
 	}
 
 	// Preserve other filter properties that may exist
@@ -187,28 +214,115 @@ func reconcileFilters(req models.UpdateFlagRequest, existingFlag *models.PostHog
 		filters.Payloads = existingFlag.Filters.Payloads
 	}
 
-	// Update multivariate configuration with new variants
-	if len(*req.Variants) > 0 {
-		filters.Multivariate = convertVariantsToMultivariate(*req.Variants)
+	switch {
+	case req.Variants == nil:
+		// Targeting-only update keeps the current variants
+		filters.Multivariate = existingFlag.Filters.Multivariate
+	case len(*req.Variants) > 0:
+		// Update multivariate configuration and payloads with new variants, keeping
+		// the order of the variants that remain
+		filters.Multivariate = mergeVariants(existingFlag.Filters.Multivariate, *req.Variants)
+		filters.Payloads = variantPayloads(flagType, *req.Variants, existingFlag.Filters.Payloads)
+	default:
+		// Clear multivariate if no variants provided, keeping only the flag-level payload
+		filters.Multivariate = nil
+		filters.Payloads = nil
+		if payload, ok := existingFlag.Filters.Payloads[booleanPayloadKey]; ok {
+			filters.Payloads = map[string]string{booleanPayloadKey: payload}
+		}
+	}
 
-		// For multivariate flags, ensure groups don't have specific variant assignments
-		// The multivariate configuration handles the distribution
-		for i := range filters.Groups {
+	// Variant overrides must point at a variant that still exists; the
+	// multivariate configuration handles the distribution for everything else
+	for i := range filters.Groups {
+		if filters.Groups[i].Variant == nil {
+			continue
+		}
+		if filters.Multivariate == nil || !hasPostHogVariant(filters.Multivariate, *filters.Groups[i].Variant) {
 			filters.Groups[i].Variant = nil
 		}
-	} else {
-		// Clear multivariate if no variants provided
-		filters.Multivariate = nil
 	}
 
-	return &filters
+	defaultKey := ""
+	if req.DefaultValue != nil {
+		if flagType == models.FlagTypeBoolean {
+			applyBooleanDefault(&filters, req.DefaultValue)
+		} else {
+			defaultKey = applyDefaultValue(&filters, flagType, req.DefaultValue)
+		}
+	}
+
+	return &filters, defaultKey
+}
+
+// applyBooleanDefault stores a boolean default as the catch-all group's rollout, the
+// same way createPostHogFilters does: false rolls out to nobody and true to everyone.
+// A rollout that already reads back as the requested default is kept, and a flag
+// without a catch-all group gains one.
+func applyBooleanDefault(filters *models.PostHogFilters, value interface{}) {
+	enabled, ok := value.(bool)
+	if !ok {
+		return
+	}
+
+	if _, defaultGroup := splitDefaultGroup(filters.Groups); defaultGroup == nil {
+		filters.Groups = append(filters.Groups, newDefaultGroup(0))
+	}
+	group := &filters.Groups[len(filters.Groups)-1]
+	if current := group.RolloutPercentage == nil || *group.RolloutPercentage > 0; current == enabled {
+		return
+	}
+
+	rollout := 0
+	if enabled {
+		rollout = 100
+	}
+	group.RolloutPercentage = &rollout
 }
 
-// convertVariantsToMultivariate converts OpenFeature variants to PostHog multivariate configuration
-func convertVariantsToMultivariate(variants map[string]models.Variant) *models.PostHogMultivariate {
+// applyDefaultValue stores a non-boolean default value the way the manifest reads it
+// back. Flags without variants keep the value as the flag-level payload; for
+// multivariate flags it returns the key of the variant holding the value, which the
+// caller records as the default variant.
+func applyDefaultValue(filters *models.PostHogFilters, flagType models.FlagType, value interface{}) string {
+	if filters.Multivariate == nil || len(filters.Multivariate.Variants) == 0 {
+		if payload, ok := encodeFlagPayload(flagType, value); ok {
+			filters.Payloads = map[string]string{booleanPayloadKey: payload}
+		}
+		return ""
+	}
+
+	values := make(map[string]models.Variant, len(filters.Multivariate.Variants))
+	for _, variant := range filters.Multivariate.Variants {
+		values[variant.Key] = models.Variant{Value: variantValue(models.PostHogFeatureFlag{Filters: *filters}, variant, config.TypeCoercionConfig{})}
+	}
+	return defaultVariantKey(values, value)
+}
+
+func newDefaultGroup(rolloutPercentage int) models.PostHogFilterGroup {
+	return models.PostHogFilterGroup{
+		Properties:        []models.PostHogProperty{},
+		RolloutPercentage: &rolloutPercentage,
+		Variant:           nil,
+	}
+}
+
+func hasPostHogVariant(multivariate *models.PostHogMultivariate, key string) bool {
+	for _, variant := range multivariate.Variants {
+		if variant.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// convertVariantsToMultivariate converts the variants of a new flag to PostHog
+// multivariate configuration, with the default variant first
+func convertVariantsToMultivariate(variants map[string]models.Variant, defaultKey string) *models.PostHogMultivariate {
 	phVariants := make([]models.PostHogVariant, 0, len(variants))
 
-	for key, variant := range variants {
+	for _, key := range variantKeysDefaultFirst(variants, defaultKey) {
+		variant := variants[key]
 		weight := 0
 		if variant.Weight != nil {
 			weight = *variant.Weight
@@ -285,7 +399,7 @@ func metadataToTags(metadata map[string]string) []string {
 		if value == "" {
 			continue
 		}
-		tags = append(tags, key+":"+value)
+		tags = append(tags, MetadataTag(key, value))
 	}
 
 	if len(tags) == 0 {
@@ -375,45 +489,8 @@ func convertPostHogVariants(phFlag models.PostHogFeatureFlag, cfg config.TypeCoe
 	if phFlag.Filters.Multivariate != nil && len(phFlag.Filters.Multivariate.Variants) > 0 {
 		for _, variant := range phFlag.Filters.Multivariate.Variants {
 			weight := variant.RolloutFlag
-			var variantValue interface{} = variant.Key
-
-			// Check if there's a payload for this variant that's a JSON object
-			if phFlag.Filters.Payloads != nil {
-				if payload, exists := phFlag.Filters.Payloads[variant.Key]; exists {
-					if isJSONObject(payload) {
-						if obj, err := parseJSONObject(payload); err == nil {
-							variantValue = obj
-						}
-					} else {
-						// Apply type coercion if enabled
-						coerced := false
-						if cfg.CoerceBooleanStrings {
-							if boolValue, isBool := tryParseBooleanString(payload); isBool {
-								variantValue = boolValue
-								coerced = true
-							}
-						}
-						if !coerced && cfg.CoerceNumericStrings {
-							if numValue, isNum := tryParseNumericString(payload); isNum {
-								variantValue = numValue
-								coerced = true
-							}
-						}
-						if !coerced {
-							// Use the payload string as-is if no coercion applied
-							variantValue = payload
-						}
-					}
-				}
-			} else {
-				// Try to parse variant key as numeric if it looks like a number
-				if numericValue, err := parseNumeric(variant.Key); err == nil {
-					variantValue = numericValue
-				}
-			}
-
 			variants[variant.Key] = models.Variant{
-				Value:  variantValue,
+				Value:  variantValue(phFlag, variant, cfg),
 				Weight: &weight,
 			}
 		}
@@ -421,38 +498,10 @@ func convertPostHogVariants(phFlag models.PostHogFeatureFlag, cfg config.TypeCoe
 	}
 
 	// For flags with payloads but no multivariate (like simple flags with object payloads)
-	if phFlag.Filters.Payloads != nil {
-		for key, payload := range phFlag.Filters.Payloads {
-			var variantValue interface{} = payload
-
-			// Try to parse as JSON object first
-			if isJSONObject(payload) {
-				if obj, err := parseJSONObject(payload); err == nil {
-					variantValue = obj
-				}
-			} else {
-				// Apply type coercion if enabled
-				coerced := false
-				if cfg.CoerceBooleanStrings {
-					if boolValue, isBool := tryParseBooleanString(payload); isBool {
-						variantValue = boolValue
-						coerced = true
-					}
-				}
-				if !coerced && cfg.CoerceNumericStrings {
-					if numValue, isNum := tryParseNumericString(payload); isNum {
-						variantValue = numValue
-						coerced = true
-					}
-				}
-				// If no coercion applied, variantValue remains as the original payload string
-			}
-
-			variants[key] = models.Variant{
-				Value: variantValue,
-			}
+	for key, payload := range phFlag.Filters.Payloads {
+		variants[key] = models.Variant{
+			Value: payloadValue(payload, cfg),
 		}
-		return variants
 	}
 
 	// For simple boolean flags, only add variants if explicitly needed
@@ -460,6 +509,22 @@ func convertPostHogVariants(phFlag models.PostHogFeatureFlag, cfg config.TypeCoe
 	return variants
 }
 
+// variantValue is the value a PostHog variant serves: its payload when it has one,
+// otherwise its key
+func variantValue(phFlag models.PostHogFeatureFlag, variant models.PostHogVariant, cfg config.TypeCoercionConfig) interface{} {
+	if phFlag.Filters.Payloads == nil {
+		// Try to parse variant key as numeric if it looks like a number
+		if numericValue, err := parseNumeric(variant.Key); err == nil {
+			return numericValue
+		}
+		return variant.Key
+	}
+	if payload, exists := phFlag.Filters.Payloads[variant.Key]; exists {
+		return payloadValue(payload, cfg)
+	}
+	return variant.Key
+}
+
 // createPostHogFilters creates PostHog filters from OpenFeature flag request
 func createPostHogFilters(req models.CreateFlagRequest) models.PostHogFilters {
 	// PostHog requires at least one filter group with rollout_percentage
@@ -475,35 +540,22 @@ func createPostHogFilters(req models.CreateFlagRequest) models.PostHogFilters {
 		}
 	}
 
+	// Targeting rules come first; the trailing default group applies to everyone else
+	groups := targetingToGroups(req.Targeting)
+	groups = append(groups, newDefaultGroup(defaultRolloutPercentage))
+
 	filters := models.PostHogFilters{
-		Groups: []models.PostHogFilterGroup{
-			{
-				Properties:        []models.PostHogProperty{},
-				RolloutPercentage: &defaultRolloutPercentage,
-				Variant:           nil,
-			},
-		},
+		Groups: groups,
 	}
 
 	// If there are variants, create multivariate configuration
 	if req.Variants != nil && len(req.Variants) > 0 {
-		variants := make([]models.PostHogVariant, 0, len(req.Variants))
-
-		for key, variant := range req.Variants {
-			weight := 0
-			if variant.Weight != nil {
-				weight = *variant.Weight
-			}
-
-			variants = append(variants, models.PostHogVariant{
-				Key:         key,
-				Name:        key,
-				RolloutFlag: weight,
-			})
-		}
-
-		filters.Multivariate = &models.PostHogMultivariate{
-			Variants: variants,
+		filters.Multivariate = convertVariantsToMultivariate(req.Variants, defaultVariantKey(req.Variants, req.DefaultValue))
+		filters.Payloads = variantPayloads(req.Type, req.Variants, nil)
+	} else if req.Type != models.FlagTypeBoolean {
+		// Flags without variants carry a non-boolean value as the flag-level payload
+		if payload, ok := encodeFlagPayload(req.Type, req.DefaultValue); ok {
+			filters.Payloads = map[string]string{booleanPayloadKey: payload}
 		}
 	}
 
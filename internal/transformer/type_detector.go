@@ -18,7 +18,8 @@ func (d *PayloadObjectDetector) Detect(phFlag models.PostHogFeatureFlag) (models
 		return "", nil, false
 	}
 
-	for _, payload := range phFlag.Filters.Payloads {
+	for _, key := range payloadKeysDefaultFirst(phFlag) {
+		payload := phFlag.Filters.Payloads[key]
 		if isJSONObject(payload) {
 			if obj, err := parseJSONObject(payload); err == nil {
 				return models.FlagTypeObject, obj, true
@@ -38,7 +39,8 @@ func (d *PayloadCoercionDetector) Detect(phFlag models.PostHogFeatureFlag) (mode
 		return "", nil, false
 	}
 
-	for _, payload := range phFlag.Filters.Payloads {
+	for _, key := range payloadKeysDefaultFirst(phFlag) {
+		payload := phFlag.Filters.Payloads[key]
 		// Try boolean coercion first (more specific)
 		if d.Config.CoerceBooleanStrings {
 			if boolValue, isBool := tryParseBooleanString(payload); isBool {
@@ -49,13 +51,63 @@ func (d *PayloadCoercionDetector) Detect(phFlag models.PostHogFeatureFlag) (mode
 		// Try numeric coercion
 		if d.Config.CoerceNumericStrings {
 			if numValue, isNum := tryParseNumericString(payload); isNum {
-				return models.FlagTypeInteger, numValue, true
+				return d.numericPayloadType(phFlag.Filters.Payloads), numValue, true
 			}
 		}
 	}
 	return "", nil, false
 }
 
+// numericPayloadType reports float when any numeric payload is fractional, so a flag
+// whose first variant happens to be a whole number is still typed as a float
+func (d *PayloadCoercionDetector) numericPayloadType(payloads map[string]string) models.FlagType {
+	for _, payload := range payloads {
+		if numValue, isNum := tryParseNumericString(payload); isNum && numericFlagType(numValue) == models.FlagTypeFloat {
+			return models.FlagTypeFloat
+		}
+	}
+	return models.FlagTypeInteger
+}
+
+// PayloadValueDetector reads typed values from JSON payloads. The default value is
+// the value of the default variant, or the flag-level payload for flags without
+// variants.
+type PayloadValueDetector struct {
+	Config config.TypeCoercionConfig
+}
+
+func (d *PayloadValueDetector) Detect(phFlag models.PostHogFeatureFlag) (models.FlagType, interface{}, bool) {
+	if len(phFlag.Filters.Payloads) == 0 {
+		return "", nil, false
+	}
+
+	if phFlag.Filters.Multivariate != nil && len(phFlag.Filters.Multivariate.Variants) > 0 {
+		values := make([]interface{}, 0, len(phFlag.Filters.Multivariate.Variants))
+		hasPayload := false
+		for _, variant := range phFlag.Filters.Multivariate.Variants {
+			if _, ok := phFlag.Filters.Payloads[variant.Key]; ok {
+				hasPayload = true
+			}
+			values = append(values, variantValue(phFlag, variant, d.Config))
+		}
+		if !hasPayload {
+			return "", nil, false
+		}
+		return valuesFlagType(values), variantValue(phFlag, defaultPostHogVariant(phFlag), d.Config), true
+	}
+
+	payload, ok := phFlag.Filters.Payloads[booleanPayloadKey]
+	if !ok {
+		return "", nil, false
+	}
+	value := payloadValue(payload, d.Config)
+	if _, isBool := value.(bool); isBool {
+		// Boolean flags take their default value from the rollout
+		return "", nil, false
+	}
+	return valueFlagType(value), value, true
+}
+
 // MultivariateDetector handles multivariate flag type detection
 type MultivariateDetector struct{}
 
@@ -64,17 +116,23 @@ func (d *MultivariateDetector) Detect(phFlag models.PostHogFeatureFlag) (models.
 		return "", nil, false
 	}
 
-	firstVariant := phFlag.Filters.Multivariate.Variants[0]
+	defaultVariant := defaultPostHogVariant(phFlag)
 
 	// Check if variants are numeric
-	if isNumeric(firstVariant.Key) {
-		if numValue, err := parseNumeric(firstVariant.Key); err == nil {
-			return models.FlagTypeInteger, numValue, true
+	if isNumeric(defaultVariant.Key) {
+		if numValue, err := parseNumeric(defaultVariant.Key); err == nil {
+			flagType := models.FlagTypeInteger
+			for _, variant := range phFlag.Filters.Multivariate.Variants {
+				if value, err := parseNumeric(variant.Key); err == nil && numericFlagType(value) == models.FlagTypeFloat {
+					flagType = models.FlagTypeFloat
+				}
+			}
+			return flagType, numValue, true
 		}
 	}
 
 	// Default to string variants
-	return models.FlagTypeString, firstVariant.Key, true
+	return models.FlagTypeString, defaultVariant.Key, true
 }
 
 // BooleanDetector handles simple boolean flags
@@ -90,8 +148,10 @@ func (d *BooleanDetector) Detect(phFlag models.PostHogFeatureFlag) (models.FlagT
 	// PostHog boolean flags use rollout_percentage to control the default behavior:
 	// - rollout 0% = defaultValue: false (no users get true)
 	// - rollout > 0% = defaultValue: true (some/all users get true)
-	if len(phFlag.Filters.Groups) > 0 && phFlag.Filters.Groups[0].RolloutPercentage != nil {
-		rollout := *phFlag.Filters.Groups[0].RolloutPercentage
+	// Targeting rules come before the default group, so prefer the trailing catch-all group
+	group := defaultOrFirstGroup(phFlag.Filters.Groups)
+	if group != nil && group.RolloutPercentage != nil {
+		rollout := *group.RolloutPercentage
 		return models.FlagTypeBoolean, rollout > 0, true
 	}
 
@@ -99,6 +159,35 @@ func (d *BooleanDetector) Detect(phFlag models.PostHogFeatureFlag) (models.FlagT
 	return models.FlagTypeBoolean, true, true
 }
 
+// numericFlagType labels parsed numbers: fractional values are floats, everything else integers
+func numericFlagType(value interface{}) models.FlagType {
+	if _, isFloat := value.(float64); isFloat {
+		return models.FlagTypeFloat
+	}
+	return models.FlagTypeInteger
+}
+
+func defaultOrFirstGroup(groups []models.PostHogFilterGroup) *models.PostHogFilterGroup {
+	if _, defaultGroup := splitDefaultGroup(groups); defaultGroup != nil {
+		return defaultGroup
+	}
+	if len(groups) > 0 {
+		return &groups[0]
+	}
+	return nil
+}
+
+// payloadKeysDefaultFirst orders payload keys by key, except that the payload of the
+// default variant comes first
+func payloadKeysDefaultFirst(phFlag models.PostHogFeatureFlag) []string {
+	keys := sortedPayloadKeys(phFlag.Filters.Payloads)
+	if phFlag.Filters.Multivariate == nil || len(phFlag.Filters.Multivariate.Variants) == 0 {
+		return keys
+	}
+
+	return moveToFront(keys, DefaultVariant(phFlag))
+}
+
 // TypeDetectionChain orchestrates detection strategies using Chain of Responsibility pattern
 type TypeDetectionChain struct {
 	detectors []TypeDetector
@@ -110,6 +199,7 @@ func NewTypeDetectionChain(cfg config.TypeCoercionConfig) *TypeDetectionChain {
 		detectors: []TypeDetector{
 			&PayloadObjectDetector{},
 			&PayloadCoercionDetector{Config: cfg},
+			&PayloadValueDetector{Config: cfg},
 			&MultivariateDetector{},
 			&BooleanDetector{},
 		},
@@ -163,9 +163,26 @@ func TestPayloadCoercionDetector(t *testing.T) {
 				},
 			},
 			expectFound: true,
-			expectType:  models.FlagTypeInteger,
+			expectType:  models.FlagTypeFloat,
 			expectValue: 123.45,
 		},
+		{
+			name: "Any fractional payload makes the flag a float",
+			config: config.TypeCoercionConfig{
+				CoerceNumericStrings: true,
+			},
+			phFlag: models.PostHogFeatureFlag{
+				Filters: models.PostHogFilters{
+					Payloads: map[string]string{
+						"full":   "1",
+						"sample": "0.25",
+					},
+				},
+			},
+			expectFound: true,
+			expectType:  models.FlagTypeFloat,
+			expectValue: 1,
+		},
 		{
 			name: "No coercion possible",
 			config: config.TypeCoercionConfig{
@@ -239,6 +256,22 @@ func TestMultivariateDetector(t *testing.T) {
 			expectType:  models.FlagTypeInteger,
 			expectValue: 100,
 		},
+		{
+			name: "Fractional variants",
+			phFlag: models.PostHogFeatureFlag{
+				Filters: models.PostHogFilters{
+					Multivariate: &models.PostHogMultivariate{
+						Variants: []models.PostHogVariant{
+							{Key: "0.25", Name: "Quarter"},
+							{Key: "0.5", Name: "Half"},
+						},
+					},
+				},
+			},
+			expectFound: true,
+			expectType:  models.FlagTypeFloat,
+			expectValue: 0.25,
+		},
 		{
 			name: "No multivariate",
 			phFlag: models.PostHogFeatureFlag{
@@ -0,0 +1,111 @@
+package transformer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/openfeature/posthog-proxy/internal/models"
+)
+
+// PostHog assigns users to variants by cumulative weight in list order, so moving a
+// variant moves users even when no weight changed. New flags list their default
+// variant first; on updates the order is kept and a default that is not the first
+// variant is recorded in a tag instead.
+const defaultVariantTagPrefix = "default-variant:"
+
+// DefaultVariant returns the key of the flag's default variant: the variant named by
+// its default-variant tag, or else the first PostHog variant. Flags without variants
+// have none.
+func DefaultVariant(phFlag models.PostHogFeatureFlag) string {
+	multivariate := phFlag.Filters.Multivariate
+	if multivariate == nil || len(multivariate.Variants) == 0 {
+		return ""
+	}
+	for _, tag := range phFlag.Tags {
+		if key := strings.TrimPrefix(tag, defaultVariantTagPrefix); key != tag && hasPostHogVariant(multivariate, key) {
+			return key
+		}
+	}
+	return multivariate.Variants[0].Key
+}
+
+// defaultPostHogVariant returns the flag's default variant; the flag must have variants
+func defaultPostHogVariant(phFlag models.PostHogFeatureFlag) models.PostHogVariant {
+	key := DefaultVariant(phFlag)
+	for _, variant := range phFlag.Filters.Multivariate.Variants {
+		if variant.Key == key {
+			return variant
+		}
+	}
+	return phFlag.Filters.Multivariate.Variants[0]
+}
+
+// mergeVariants sets the variants and weights of an existing multivariate
+// configuration. Variants that remain keep their position and new ones are appended
+// by key, so users are not moved between variants whose weights did not change.
+func mergeVariants(existing *models.PostHogMultivariate, variants map[string]models.Variant) *models.PostHogMultivariate {
+	weight := func(key string) int {
+		if variant := variants[key]; variant.Weight != nil {
+			return *variant.Weight
+		}
+		return 0
+	}
+
+	phVariants := make([]models.PostHogVariant, 0, len(variants))
+	kept := make(map[string]struct{}, len(variants))
+	if existing != nil {
+		for _, variant := range existing.Variants {
+			if _, ok := variants[variant.Key]; !ok {
+				continue
+			}
+			variant.RolloutFlag = weight(variant.Key)
+			phVariants = append(phVariants, variant)
+			kept[variant.Key] = struct{}{}
+		}
+	}
+
+	added := make([]string, 0, len(variants)-len(kept))
+	for key := range variants {
+		if _, ok := kept[key]; !ok {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	for _, key := range added {
+		phVariants = append(phVariants, models.PostHogVariant{Key: key, Name: key, RolloutFlag: weight(key)})
+	}
+
+	return &models.PostHogMultivariate{Variants: phVariants}
+}
+
+// applyDefaultVariantTag records defaultKey in tags when it is a variant other than the
+// first one, and drops the tag otherwise. It reports whether the tags changed.
+func applyDefaultVariantTag(tags []string, multivariate *models.PostHogMultivariate, defaultKey string) ([]string, bool) {
+	want := ""
+	if multivariate != nil && len(multivariate.Variants) > 0 &&
+		multivariate.Variants[0].Key != defaultKey && hasPostHogVariant(multivariate, defaultKey) {
+		want = defaultVariantTagPrefix + defaultKey
+	}
+
+	updated := make([]string, 0, len(tags)+1)
+	changed := false
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, defaultVariantTagPrefix) {
+			updated = append(updated, tag)
+		} else if tag != want {
+			changed = true
+		} else {
+			want = ""
+			updated = append(updated, tag)
+		}
+	}
+	if want != "" {
+		updated = append(updated, want)
+		changed = true
+	}
+
+	if len(updated) == 0 {
+		updated = nil
+	}
+	return updated, changed
+}
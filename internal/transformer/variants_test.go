@@ -0,0 +1,104 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/openfeature/posthog-proxy/internal/config"
+	"github.com/openfeature/posthog-proxy/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func variantKeys(multivariate *models.PostHogMultivariate) []string {
+	keys := make([]string, 0, len(multivariate.Variants))
+	for _, variant := range multivariate.Variants {
+		keys = append(keys, variant.Key)
+	}
+	return keys
+}
+
+func TestDefaultVariant(t *testing.T) {
+	flag := models.PostHogFeatureFlag{
+		Filters: models.PostHogFilters{Multivariate: &models.PostHogMultivariate{
+			Variants: []models.PostHogVariant{{Key: "control", RolloutFlag: 70}, {Key: "beta", RolloutFlag: 30}},
+		}},
+	}
+	assert.Equal(t, "control", DefaultVariant(flag))
+
+	flag.Tags = []string{"owner:growth", "default-variant:beta"}
+	assert.Equal(t, "beta", DefaultVariant(flag))
+
+	// A tag naming a variant that no longer exists is ignored
+	flag.Tags = []string{"default-variant:removed"}
+	assert.Equal(t, "control", DefaultVariant(flag))
+
+	assert.Equal(t, "", DefaultVariant(models.PostHogFeatureFlag{Tags: []string{"default-variant:beta"}}))
+}
+
+func TestUpdateDefaultKeepsVariantOrder(t *testing.T) {
+	existing := models.PostHogFeatureFlag{
+		Key:    "checkout",
+		Active: true,
+		Tags:   []string{"owner:growth"},
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: intPtr(100)}},
+			Multivariate: &models.PostHogMultivariate{
+				Variants: []models.PostHogVariant{{Key: "control", RolloutFlag: 70}, {Key: "beta", RolloutFlag: 30}},
+			},
+		},
+	}
+
+	update := OpenFeatureToPostHogUpdate(models.UpdateFlagRequest{DefaultValue: "beta"}, &existing)
+
+	require.NotNil(t, update.Filters)
+	assert.Equal(t, existing.Filters.Multivariate.Variants, update.Filters.Multivariate.Variants)
+	require.NotNil(t, update.Tags)
+	assert.Equal(t, []string{"owner:growth", "default-variant:beta"}, *update.Tags)
+
+	flag := PostHogToOpenFeatureFlag(models.PostHogFeatureFlag{Key: "checkout", Active: true, Filters: *update.Filters, Tags: *update.Tags}, config.TypeCoercionConfig{})
+	assert.Equal(t, "beta", flag.DefaultValue)
+
+	// Moving the default back to the first variant drops the tag
+	existing.Tags = *update.Tags
+	update = OpenFeatureToPostHogUpdate(models.UpdateFlagRequest{DefaultValue: "control"}, &existing)
+	require.NotNil(t, update.Tags)
+	assert.Equal(t, []string{"owner:growth"}, *update.Tags)
+}
+
+func TestUpdateVariantsKeepsOrder(t *testing.T) {
+	existing := models.PostHogFeatureFlag{
+		Key:    "theme",
+		Active: true,
+		Filters: models.PostHogFilters{
+			Groups: []models.PostHogFilterGroup{{RolloutPercentage: intPtr(100)}},
+			Multivariate: &models.PostHogMultivariate{
+				Variants: []models.PostHogVariant{
+					{Key: "zeta", Name: "Zeta", RolloutFlag: 40},
+					{Key: "beta", Name: "Beta", RolloutFlag: 30},
+					{Key: "alpha", Name: "Alpha", RolloutFlag: 30},
+				},
+			},
+		},
+	}
+
+	unchanged := OpenFeatureToPostHogUpdate(models.UpdateFlagRequest{Variants: &map[string]models.Variant{
+		"alpha": {Weight: intPtr(30)},
+		"beta":  {Weight: intPtr(30)},
+		"zeta":  {Weight: intPtr(40)},
+	}}, &existing)
+	require.NotNil(t, unchanged.Filters)
+	assert.Equal(t, existing.Filters.Multivariate.Variants, unchanged.Filters.Multivariate.Variants)
+	assert.Nil(t, unchanged.Tags, "the default variant is unchanged")
+
+	// Remaining variants keep their position and new ones are appended by key
+	changed := OpenFeatureToPostHogUpdate(models.UpdateFlagRequest{Variants: &map[string]models.Variant{
+		"delta": {Weight: intPtr(10)},
+		"alpha": {Weight: intPtr(30)},
+		"gamma": {Weight: intPtr(20)},
+		"zeta":  {Weight: intPtr(40)},
+	}}, &existing)
+	require.NotNil(t, changed.Filters)
+	assert.Equal(t, []string{"zeta", "alpha", "delta", "gamma"}, variantKeys(changed.Filters.Multivariate))
+	assert.Equal(t, "Zeta", changed.Filters.Multivariate.Variants[0].Name)
+	assert.Equal(t, 40, changed.Filters.Multivariate.Variants[0].RolloutFlag)
+}